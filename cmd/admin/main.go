@@ -0,0 +1,121 @@
+// Command admin manages admin accounts directly against the configured
+// storage backend, for bootstrapping and for recovering from a lost password.
+//
+// Usage:
+//
+//	go run ./cmd/admin list
+//	go run ./cmd/admin create -username alice -role organizer [-password secret]
+//	go run ./cmd/admin role -username alice -role viewer
+//	go run ./cmd/admin disable -username alice
+//	go run ./cmd/admin enable -username alice
+//	go run ./cmd/admin rotate -username alice [-password secret]
+//
+// Roles are owner, organizer, coordinator, checkin and viewer.
+//
+// When -password is omitted the password is read from the first line of
+// standard input so it does not end up in shell history.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"appdirect-workshop/internal/admins"
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/storage"
+
+	"github.com/joho/godotenv"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: admin <list|create|role|disable|enable|rotate> [-username name] [-role role] [-password secret]")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	username := flags.String("username", "", "admin username")
+	password := flags.String("password", "", "new password (read from stdin when empty)")
+	role := flags.String("role", "", "role for create and role commands")
+	flags.Parse(os.Args[2:])
+
+	if _, err := os.Stat(".env"); err == nil {
+		if err := godotenv.Load(".env"); err != nil {
+			log.Printf("Failed to load .env: %v", err)
+		}
+	}
+
+	ctx := context.Background()
+	stores, closeStores, err := storage.Open(ctx)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	defer closeStores()
+
+	svc := admins.NewService(stores.Admins)
+
+	if command != "list" && *username == "" {
+		closeStores()
+		log.Fatal("-username is required")
+	}
+
+	switch command {
+	case "list":
+		list, err := svc.List(ctx)
+		if err != nil {
+			closeStores()
+			log.Fatalf("Failed to list admins: %v", err)
+		}
+		for _, a := range list {
+			status := "enabled"
+			if a.Disabled {
+				status = "disabled"
+			}
+			fmt.Printf("%-24s %-12s %-8s password changed %s\n", a.Username, a.Role, status, a.PasswordChangedAt.Format("2006-01-02 15:04"))
+		}
+	case "create":
+		_, err = svc.Create(ctx, *username, readPassword(*password), models.Role(*role))
+	case "role":
+		_, err = svc.SetRole(ctx, *username, models.Role(*role))
+	case "disable":
+		_, err = svc.SetDisabled(ctx, *username, true)
+	case "enable":
+		_, err = svc.SetDisabled(ctx, *username, false)
+	case "rotate":
+		_, err = svc.RotatePassword(ctx, *username, readPassword(*password))
+	default:
+		closeStores()
+		usage()
+	}
+
+	if err != nil {
+		closeStores()
+		log.Fatalf("%s %s: %v", command, *username, err)
+	}
+	if command != "list" {
+		fmt.Printf("%s %s: ok\n", command, *username)
+	}
+}
+
+// readPassword returns flagValue or, when it is empty, the first line of stdin.
+func readPassword(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		log.Fatalf("Failed to read password: %v", err)
+	}
+	return strings.TrimRight(line, "\r\n")
+}
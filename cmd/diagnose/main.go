@@ -3,10 +3,10 @@ package main
 import (
 	"context"
 	"fmt"
+	"google.golang.org/api/iterator"
 	"log"
 	"os"
 	"path/filepath"
-	"google.golang.org/api/iterator"
 
 	"appdirect-workshop/internal/firestore"
 
@@ -31,7 +31,7 @@ func main() {
 
 	// 3. Resolve Service Account Path
 	if serviceAccountPath == "" {
-		serviceAccountPath = serviceAccountPath
+		serviceAccountPath = "./serviceAccountKey.json"
 		fmt.Printf("Defaulting serviceAccountPath to: %s\n", serviceAccountPath)
 	}
 
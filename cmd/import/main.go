@@ -0,0 +1,123 @@
+// Command import loads attendees, speakers or sessions from a CSV or JSON
+// file directly into the configured storage backend, with the checks of the
+// /api/admin/import endpoints.
+//
+// Usage:
+//
+//	go run ./cmd/import -kind speakers -file speakers.csv [-dry-run]
+//	go run ./cmd/import -kind sessions -file agenda.json -workshop spring-2027
+//	go run ./cmd/import -kind attendees -format csv -file - < attendees.csv
+//
+// Kinds are attendees, speakers and sessions. The format defaults to the
+// file's extension. Without -workshop the default workshop is imported into.
+// Imported attendees are not emailed, and reminders for imported sessions
+// are scheduled by the server's next periodic sync.
+//
+// It exits with status 1 and lists every problem when a row is invalid, in
+// which case nothing is stored.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"appdirect-workshop/internal/handlers"
+	"appdirect-workshop/internal/importer"
+	"appdirect-workshop/internal/storage"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	kind := flag.String("kind", "", "what the file holds: attendees, speakers or sessions")
+	file := flag.String("file", "", "file to import, or - for standard input")
+	format := flag.String("format", "", "csv or json (default: the file's extension)")
+	workshop := flag.String("workshop", "", "workshop ID (default: the default workshop)")
+	dryRun := flag.Bool("dry-run", false, "check every row without storing anything")
+	flag.Parse()
+
+	if *kind == "" || *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*file)), ".")
+	}
+
+	if _, err := os.Stat(".env"); err == nil {
+		if err := godotenv.Load(".env"); err != nil {
+			log.Printf("Failed to load .env: %v", err)
+		}
+	}
+
+	var in io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Fatalf("Failed to open %s: %v", *file, err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	ctx := context.Background()
+	stores, closeStores, err := storage.Open(ctx)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	defer closeStores()
+
+	// The default workshop keeps its data outside of any workshop; see
+	// cmd/server.
+	defaultWorkshop := os.Getenv("DEFAULT_WORKSHOP_ID")
+	if defaultWorkshop == "" {
+		defaultWorkshop = os.Getenv("FIRESTORE_SUBCOLLECTION_ID")
+	}
+	if defaultWorkshop == "" {
+		defaultWorkshop = "workshop_attendees"
+	}
+	if *workshop != "" && *workshop != defaultWorkshop {
+		if _, err := stores.Workshops.GetWorkshop(ctx, *workshop); err != nil {
+			closeStores()
+			log.Fatalf("Workshop %s: %v", *workshop, err)
+		}
+		stores = stores.Workshops.Scoped(*workshop)
+	}
+
+	im := &importer.Importer{Stores: stores, Location: handlers.EventLocation()}
+	report, err := im.Import(ctx, in, importer.Options{
+		Kind:   importer.Kind(*kind),
+		Format: importer.Format(*format),
+		DryRun: *dryRun,
+	})
+	if report == nil {
+		closeStores()
+		log.Fatalf("Failed to import %s: %v", *file, err)
+	}
+
+	for _, e := range report.Errors {
+		where := *file
+		if e.Row > 0 {
+			where = fmt.Sprintf("row %d", e.Row)
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s\n", where, strings.TrimSpace(e.Field+" "+e.Message))
+	}
+	switch {
+	case err != nil:
+		closeStores()
+		log.Fatalf("Imported %d of %d %s, then failed: %v", report.Imported, report.Rows, *kind, err)
+	case len(report.Errors) > 0:
+		closeStores()
+		log.Fatalf("Nothing was imported because of the errors above (%d rows read)", report.Rows)
+	case *dryRun:
+		fmt.Printf("%d %s are valid; nothing was imported (dry run)\n", report.Rows, *kind)
+	default:
+		fmt.Printf("Imported %d %s\n", report.Imported, *kind)
+	}
+}
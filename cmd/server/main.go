@@ -6,12 +6,16 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"syscall"
 	"time"
 
+	"appdirect-workshop/internal/admins"
+	"appdirect-workshop/internal/auth"
 	"appdirect-workshop/internal/handlers"
-	"appdirect-workshop/internal/firestore"
+	"appdirect-workshop/internal/storage"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
@@ -22,17 +26,17 @@ func main() {
 	// Load environment variables from .env file
 	// Get the current working directory
 	wd, _ := os.Getwd()
-	
+
 	// Try to find .env file - start from project root (two levels up from cmd/server/)
 	envPaths := []string{
-		".env",                              // Current directory
-		filepath.Join(wd, ".env"),           // Absolute from working dir
-		filepath.Join(wd, "..", ".env"),     // One level up
-		filepath.Join(wd, "../..", ".env"),  // Two levels up (project root)
-		"../.env",                           // Relative one level up
-		"../../.env",                        // Relative two levels up
-	}
-	
+		".env",                             // Current directory
+		filepath.Join(wd, ".env"),          // Absolute from working dir
+		filepath.Join(wd, "..", ".env"),    // One level up
+		filepath.Join(wd, "../..", ".env"), // Two levels up (project root)
+		"../.env",                          // Relative one level up
+		"../../.env",                       // Relative two levels up
+	}
+
 	// Try relative to source file location
 	_, filename, _, ok := runtime.Caller(0)
 	if ok {
@@ -40,7 +44,7 @@ func main() {
 		projectRoot := filepath.Join(sourceDir, "../..")
 		envPaths = append(envPaths, filepath.Join(projectRoot, ".env"))
 	}
-	
+
 	var envLoaded bool
 	for _, path := range envPaths {
 		// Check if file exists first
@@ -66,40 +70,43 @@ func main() {
 		port = "8080"
 	}
 
-	projectID := os.Getenv("FIREBASE_PROJECT_ID")
-	serviceAccountPath := os.Getenv("FIREBASE_SERVICE_ACCOUNT_PATH")
-	
-	// For Cloud Run, use Application Default Credentials (ADC)
-	// Set FIREBASE_SERVICE_ACCOUNT_PATH=ADC or leave empty in Cloud Run
-	// For local development, use service account file path
-	if serviceAccountPath == "" {
-		// Check if running in Cloud Run (has K_SERVICE env var)
-		if os.Getenv("K_SERVICE") != "" {
-			// Running in Cloud Run, use ADC
-			serviceAccountPath = "ADC"
-		} else {
-			// Local development, try to use service account file
-			serviceAccountPath = serviceAccountPath
-		}
+	// The workshop the routes directly under /api serve. Deployments from
+	// before workshops named it with FIRESTORE_SUBCOLLECTION_ID.
+	defaultWorkshop := os.Getenv("DEFAULT_WORKSHOP_ID")
+	if defaultWorkshop == "" {
+		defaultWorkshop = os.Getenv("FIRESTORE_SUBCOLLECTION_ID")
 	}
-
-	subcollectionID := os.Getenv("FIRESTORE_SUBCOLLECTION_ID")
-	if subcollectionID == "" {
-		subcollectionID = "workshop_attendees"
+	if defaultWorkshop == "" {
+		defaultWorkshop = "workshop_attendees"
 	}
 
-	// Initialize Firestore client
-	databaseID := os.Getenv("FIRESTORE_DATABASE_ID")
-	log.Printf("DEBUG: FIRESTORE_DATABASE_ID is: '%s'", databaseID)
+	// Initialize storage
 	ctx := context.Background()
-	fsClient, err := firestore.NewClient(ctx, projectID, databaseID, serviceAccountPath)
+	stores, closeStores, err := storage.Open(ctx)
 	if err != nil {
-		log.Fatalf("Failed to initialize Firestore: %v", err)
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	defer closeStores()
+
+	// Make sure an admin account exists before accepting logins
+	if err := admins.NewService(stores.Admins).Bootstrap(ctx, os.Getenv("ADMIN_PASSWORD"), isProduction()); err != nil {
+		log.Fatalf("Failed to bootstrap admin accounts: %v", err)
 	}
-	defer fsClient.Close()
 
 	// Initialize handlers
-	h := handlers.NewHandlers(fsClient, subcollectionID)
+	h := handlers.NewHandlers(stores, defaultWorkshop)
+	if err := h.EnsureDefaultWorkshop(ctx); err != nil {
+		log.Fatalf("Failed to create the default workshop: %v", err)
+	}
+
+	// Give sessions saved with only a date and time their start and end
+	// times before reminders are scheduled from them
+	if n, err := h.MigrateSessions(ctx); err != nil {
+		log.Printf("⚠ Failed to migrate sessions: %v", err)
+	} else if n > 0 {
+		log.Printf("Migrated %d sessions to start and end times", n)
+	}
+	h.StartReminders()
 
 	// Setup router
 	r := mux.NewRouter()
@@ -115,9 +122,10 @@ func main() {
 			"version": "1.0.0",
 			"endpoints": map[string]interface{}{
 				"attendees": map[string]string{
-					"GET":  "/api/attendees",
-					"POST": "/api/attendees",
-					"GET_count": "/api/attendees/count",
+					"GET":        "/api/attendees",
+					"POST":       "/api/attendees",
+					"GET_count":  "/api/attendees/count",
+					"GET_export": "/api/attendees/export?format=csv|xlsx",
 				},
 				"speakers": map[string]string{
 					"GET":    "/api/speakers",
@@ -131,32 +139,74 @@ func main() {
 					"PUT":    "/api/sessions/{id}",
 					"DELETE": "/api/sessions/{id}",
 				},
+				"workshops": map[string]string{
+					"GET":        "/api/workshops",
+					"POST":       "/api/workshops",
+					"GET_one":    "/api/workshops/{workshopId}",
+					"PUT":        "/api/workshops/{workshopId}",
+					"DELETE":     "/api/workshops/{workshopId}",
+					"POST_clone": "/api/workshops/{workshopId}/clone",
+					"GET_scoped": "/api/workshops/{workshopId}/sessions",
+				},
+				"agenda": map[string]string{
+					"GET":     "/api/agenda",
+					"GET_ics": "/api/agenda.ics",
+				},
 				"admin": map[string]string{
-					"POST": "/api/admin/login",
+					"POST":            "/api/admin/login",
+					"POST_logout":     "/api/admin/logout",
+					"POST_refresh":    "/api/admin/refresh",
+					"GET_me":          "/api/admin/me",
+					"GET_duplicates":  "/api/admin/attendees/duplicates",
+					"POST_duplicates": "/api/admin/attendees/duplicates/merge",
+					"POST_import":     "/api/admin/import/{attendees|speakers|sessions}?format=csv|json&dryRun=true",
+				},
+				"admin_users": map[string]string{
+					"GET":          "/api/admin/users",
+					"POST":         "/api/admin/users",
+					"POST_disable": "/api/admin/users/{username}/disable",
+					"POST_enable":  "/api/admin/users/{username}/enable",
+					"PUT_password": "/api/admin/users/{username}/password",
 				},
 			},
 		})
 	}).Methods("GET")
 
-	// Attendees
-	api.HandleFunc("/attendees", h.GetAttendees).Methods("GET")
-	api.HandleFunc("/attendees", h.RegisterAttendee).Methods("POST")
-	api.HandleFunc("/attendees/count", h.GetAttendeeCount).Methods("GET")
-
-	// Speakers
-	api.HandleFunc("/speakers", h.GetSpeakers).Methods("GET")
-	api.HandleFunc("/speakers", h.CreateSpeaker).Methods("POST")
-	api.HandleFunc("/speakers/{id}", h.UpdateSpeaker).Methods("PUT")
-	api.HandleFunc("/speakers/{id}", h.DeleteSpeaker).Methods("DELETE")
+	// Admin-only routes require a session token from /api/admin/login and
+	// the permission named next to each handler
+	admin := api.NewRoute().Subrouter()
+	admin.Use(h.RequireAdmin)
+	can := func(perm auth.Permission, f http.HandlerFunc) http.Handler {
+		return h.Require(perm)(f)
+	}
 
-	// Sessions
-	api.HandleFunc("/sessions", h.GetSessions).Methods("GET")
-	api.HandleFunc("/sessions", h.CreateSession).Methods("POST")
-	api.HandleFunc("/sessions/{id}", h.UpdateSession).Methods("PUT")
-	api.HandleFunc("/sessions/{id}", h.DeleteSession).Methods("DELETE")
+	// Workshops; the event routes below serve the default workshop directly
+	// under /api and any workshop under /api/workshops/{workshopId}
+	api.HandleFunc("/workshops", h.ListWorkshops).Methods("GET")
+	admin.Handle("/workshops", can(auth.PermManageEvent, h.CreateWorkshop)).Methods("POST")
+	api.HandleFunc("/workshops/{workshopId}", h.GetWorkshop).Methods("GET")
+	admin.Handle("/workshops/{workshopId}", can(auth.PermManageEvent, h.UpdateWorkshop)).Methods("PUT")
+	admin.Handle("/workshops/{workshopId}", can(auth.PermManageEvent, h.DeleteWorkshop)).Methods("DELETE")
+	admin.Handle("/workshops/{workshopId}/clone", can(auth.PermManageEvent, h.CloneWorkshop)).Methods("POST")
+	eventRoutes(api, admin, h, can)
+	workshopAPI := api.PathPrefix("/workshops/{workshopId}").Subrouter()
+	workshopAdmin := workshopAPI.NewRoute().Subrouter()
+	workshopAdmin.Use(h.RequireAdmin)
+	eventRoutes(workshopAPI, workshopAdmin, h, can)
 
 	// Admin
 	api.HandleFunc("/admin/login", h.AdminLogin).Methods("POST")
+	admin.HandleFunc("/admin/logout", h.AdminLogout).Methods("POST")
+	admin.HandleFunc("/admin/refresh", h.AdminRefresh).Methods("POST")
+	admin.HandleFunc("/admin/me", h.AdminMe).Methods("GET")
+
+	// Admin accounts; every admin may change their own password
+	admin.Handle("/admin/users", can(auth.PermManageAdmins, h.ListAdmins)).Methods("GET")
+	admin.Handle("/admin/users", can(auth.PermManageAdmins, h.CreateAdmin)).Methods("POST")
+	admin.Handle("/admin/users/{username}/disable", can(auth.PermManageAdmins, h.DisableAdmin)).Methods("POST")
+	admin.Handle("/admin/users/{username}/enable", can(auth.PermManageAdmins, h.EnableAdmin)).Methods("POST")
+	admin.Handle("/admin/users/{username}/role", can(auth.PermManageAdmins, h.SetAdminRole)).Methods("PUT")
+	admin.HandleFunc("/admin/users/{username}/password", h.RotateAdminPassword).Methods("PUT")
 
 	// Health check
 	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -204,7 +254,93 @@ func main() {
 		ReadTimeout:  15 * time.Second,
 	}
 
-	log.Printf("Server starting on port %s", port)
-	log.Fatal(srv.ListenAndServe())
+	go func() {
+		log.Printf("Server starting on port %s", port)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	// Wait for an interrupt so storage can be flushed and closed cleanly
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+
+	log.Println("Shutting down server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Graceful shutdown failed: %v", err)
+	}
+	if err := h.Close(shutdownCtx); err != nil {
+		log.Printf("Unsent emails dropped at shutdown: %v", err)
+	}
+}
+
+// isProduction reports whether the server runs in production, either because
+// APP_ENV says so or because it is deployed on Cloud Run (K_SERVICE is set).
+func isProduction() bool {
+	return os.Getenv("APP_ENV") == "production" || os.Getenv("K_SERVICE") != ""
 }
 
+// eventRoutes registers the routes that serve one workshop's attendees,
+// speakers, sessions and settings on api, and on admin for those that need
+// an admin session. The handlers serve the workshop named by the
+// workshopId path variable, or the default workshop without one.
+func eventRoutes(api, admin *mux.Router, h *handlers.Handlers, can func(auth.Permission, http.HandlerFunc) http.Handler) {
+	in := h.InWorkshop
+
+	// Attendees
+	admin.Handle("/attendees", can(auth.PermViewAttendees, in((*handlers.Handlers).GetAttendees))).Methods("GET")
+	admin.Handle("/attendees/export", can(auth.PermViewAttendees, in((*handlers.Handlers).ExportAttendees))).Methods("GET")
+	api.HandleFunc("/attendees", in((*handlers.Handlers).RegisterAttendee)).Methods("POST")
+	api.HandleFunc("/attendees/count", in((*handlers.Handlers).GetAttendeeCount)).Methods("GET")
+	admin.Handle("/attendees/{id}", can(auth.PermManageAttendees, in((*handlers.Handlers).CancelAttendee))).Methods("DELETE")
+
+	// Self-service registration management, authorized by the signed
+	// token issued at registration
+	api.HandleFunc("/registrations/{token}", in((*handlers.Handlers).GetRegistration)).Methods("GET")
+	api.HandleFunc("/registrations/{token}", in((*handlers.Handlers).UpdateRegistration)).Methods("PUT")
+	api.HandleFunc("/registrations/{token}", in((*handlers.Handlers).CancelRegistration)).Methods("DELETE")
+	api.HandleFunc("/registrations/{token}/ticket", in((*handlers.Handlers).GetRegistrationTicket)).Methods("GET")
+	api.HandleFunc("/registrations/{token}/sessions", in((*handlers.Handlers).GetRegistrationSessions)).Methods("GET")
+	api.HandleFunc("/registrations/{token}/schedule.ics", in((*handlers.Handlers).GetRegistrationSchedule)).Methods("GET")
+	api.HandleFunc("/registrations/{token}/sessions/{sessionId}", in((*handlers.Handlers).EnrollRegistration)).Methods("PUT")
+	api.HandleFunc("/registrations/{token}/sessions/{sessionId}", in((*handlers.Handlers).UnenrollRegistration)).Methods("DELETE")
+
+	// Speakers
+	api.HandleFunc("/speakers", in((*handlers.Handlers).GetSpeakers)).Methods("GET")
+	admin.Handle("/speakers", can(auth.PermManageSpeakers, in((*handlers.Handlers).CreateSpeaker))).Methods("POST")
+	admin.Handle("/speakers/{id}", can(auth.PermManageSpeakers, in((*handlers.Handlers).UpdateSpeaker))).Methods("PUT")
+	admin.Handle("/speakers/{id}", can(auth.PermManageSpeakers, in((*handlers.Handlers).DeleteSpeaker))).Methods("DELETE")
+
+	// Sessions
+	api.HandleFunc("/agenda", in((*handlers.Handlers).GetAgenda)).Methods("GET")
+	api.HandleFunc("/agenda.ics", in((*handlers.Handlers).GetAgendaICS)).Methods("GET")
+	api.HandleFunc("/sessions", in((*handlers.Handlers).GetSessions)).Methods("GET")
+	admin.Handle("/sessions", can(auth.PermManageSessions, in((*handlers.Handlers).CreateSession))).Methods("POST")
+	admin.Handle("/sessions/{id}", can(auth.PermManageSessions, in((*handlers.Handlers).UpdateSession))).Methods("PUT")
+	admin.Handle("/sessions/{id}", can(auth.PermManageSessions, in((*handlers.Handlers).DeleteSession))).Methods("DELETE")
+	admin.Handle("/admin/sessions/{id}/enrollees", can(auth.PermViewAttendees, in((*handlers.Handlers).GetSessionEnrollees))).Methods("GET")
+	admin.Handle("/admin/sessions/{id}/enrollees/{attendeeId}", can(auth.PermManageAttendees, in((*handlers.Handlers).EnrollAttendee))).Methods("PUT")
+	admin.Handle("/admin/sessions/{id}/enrollees/{attendeeId}", can(auth.PermManageAttendees, in((*handlers.Handlers).UnenrollAttendee))).Methods("DELETE")
+
+	// Attendee administration, check-in, settings and reminders
+	admin.Handle("/admin/attendees/duplicates", can(auth.PermViewAttendees, in((*handlers.Handlers).GetDuplicateAttendees))).Methods("GET")
+	admin.Handle("/admin/attendees/duplicates/merge", can(auth.PermManageAttendees, in((*handlers.Handlers).MergeDuplicateAttendees))).Methods("POST")
+	admin.Handle("/admin/attendees/{id}/cancel", can(auth.PermManageAttendees, in((*handlers.Handlers).CancelAttendee))).Methods("POST")
+	admin.Handle("/admin/attendees/{id}/ticket", can(auth.PermCheckIn, in((*handlers.Handlers).GetAttendeeTicket))).Methods("GET")
+	admin.Handle("/admin/checkin/stats", can(auth.PermViewAttendees, in((*handlers.Handlers).GetCheckInStats))).Methods("GET")
+	admin.Handle("/checkin", can(auth.PermCheckIn, in((*handlers.Handlers).CheckIn))).Methods("POST")
+	admin.Handle("/admin/waitlist", can(auth.PermViewAttendees, in((*handlers.Handlers).GetWaitlist))).Methods("GET")
+	admin.Handle("/admin/settings", can(auth.PermViewAttendees, in((*handlers.Handlers).GetEventSettings))).Methods("GET")
+	admin.Handle("/admin/settings", can(auth.PermManageEvent, in((*handlers.Handlers).UpdateEventSettings))).Methods("PUT")
+	admin.Handle("/admin/reminders", can(auth.PermViewAttendees, in((*handlers.Handlers).GetReminders))).Methods("GET")
+	admin.Handle("/admin/reminders/{id}/cancel", can(auth.PermManageEvent, in((*handlers.Handlers).CancelReminder))).Methods("POST")
+	admin.Handle("/admin/reminders/{id}/resend", can(auth.PermManageEvent, in((*handlers.Handlers).ResendReminder))).Methods("POST")
+
+	// Bulk imports from CSV or JSON files
+	admin.Handle("/admin/import/attendees", can(auth.PermManageAttendees, in((*handlers.Handlers).ImportAttendees))).Methods("POST")
+	admin.Handle("/admin/import/speakers", can(auth.PermManageSpeakers, in((*handlers.Handlers).ImportSpeakers))).Methods("POST")
+	admin.Handle("/admin/import/sessions", can(auth.PermManageSessions, in((*handlers.Handlers).ImportSessions))).Methods("POST")
+}
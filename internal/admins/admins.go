@@ -0,0 +1,259 @@
+// Package admins manages administrator accounts: password hashing,
+// authentication and the first-run bootstrap account.
+package admins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultUsername is the account created on first start.
+const DefaultUsername = "admin"
+
+// DefaultPassword is the development-only password used when ADMIN_PASSWORD
+// is not set. It is rejected in production.
+const DefaultPassword = "admin123"
+
+// ErrInvalidCredentials is returned for an unknown username, a wrong
+// password or a disabled account, without saying which.
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+// ErrLastOwner is returned when a change would leave no enabled owner able to
+// manage accounts.
+var ErrLastOwner = errors.New("at least one enabled owner account is required")
+
+// Service manages admin accounts on top of an AdminStore.
+type Service struct {
+	store store.AdminStore
+	cost  int
+	now   func() time.Time
+
+	dummyOnce sync.Once
+	dummyHash []byte
+}
+
+// NewService returns a Service hashing passwords with bcrypt's default cost.
+func NewService(s store.AdminStore) *Service {
+	return &Service{store: s, cost: bcrypt.DefaultCost, now: time.Now}
+}
+
+func (s *Service) hash(password string) (string, error) {
+	h, err := bcrypt.GenerateFromPassword([]byte(password), s.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(h), nil
+}
+
+// Authenticate returns the account if the password matches and the account
+// is enabled. Unknown usernames still pay for a bcrypt comparison so response
+// times do not reveal which accounts exist.
+func (s *Service) Authenticate(ctx context.Context, username, password string) (*models.Admin, error) {
+	admin, err := s.store.GetAdmin(ctx, username)
+	if errors.Is(err, store.ErrNotFound) {
+		s.dummyOnce.Do(func() {
+			s.dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), s.cost)
+		})
+		bcrypt.CompareHashAndPassword(s.dummyHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(admin.PasswordHash), []byte(password)) != nil || admin.Disabled {
+		return nil, ErrInvalidCredentials
+	}
+	return withDefaultRole(admin), nil
+}
+
+// withDefaultRole treats accounts stored before roles existed as owners,
+// since every admin had full access at the time.
+func withDefaultRole(admin *models.Admin) *models.Admin {
+	if admin.Role == "" {
+		admin.Role = models.RoleOwner
+	}
+	return admin
+}
+
+// Get returns an account by username.
+func (s *Service) Get(ctx context.Context, username string) (*models.Admin, error) {
+	admin, err := s.store.GetAdmin(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	return withDefaultRole(admin), nil
+}
+
+// List returns every account ordered by username.
+func (s *Service) List(ctx context.Context) ([]*models.Admin, error) {
+	list, err := s.store.ListAdmins(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, admin := range list {
+		withDefaultRole(admin)
+	}
+	return list, nil
+}
+
+// Create adds an enabled account after checking the username, password
+// policy and role.
+func (s *Service) Create(ctx context.Context, username, password string, role models.Role) (*models.Admin, error) {
+	if err := models.ValidateNewAdmin(username, password, role); err != nil {
+		return nil, err
+	}
+	return s.create(ctx, username, password, role)
+}
+
+func (s *Service) create(ctx context.Context, username, password string, role models.Role) (*models.Admin, error) {
+	hash, err := s.hash(password)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	admin := &models.Admin{
+		Username:          username,
+		PasswordHash:      hash,
+		Role:              role,
+		CreatedAt:         now,
+		PasswordChangedAt: now,
+	}
+	if err := s.store.CreateAdmin(ctx, admin); err != nil {
+		return nil, err
+	}
+	return admin, nil
+}
+
+// SetDisabled enables or disables an account. Disabled accounts cannot log
+// in and their existing tokens stop working.
+func (s *Service) SetDisabled(ctx context.Context, username string, disabled bool) (*models.Admin, error) {
+	return s.update(ctx, username, func(admin *models.Admin) { admin.Disabled = disabled })
+}
+
+// SetRole changes an account's role. It takes effect on the account's next
+// request.
+func (s *Service) SetRole(ctx context.Context, username string, role models.Role) (*models.Admin, error) {
+	if err := models.ValidateRole(role); err != nil {
+		return nil, err
+	}
+	return s.update(ctx, username, func(admin *models.Admin) { admin.Role = role })
+}
+
+// update applies change to an account, refusing changes that would leave no
+// enabled owner.
+func (s *Service) update(ctx context.Context, username string, change func(*models.Admin)) (*models.Admin, error) {
+	admin, err := s.Get(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	wasOwner := isActiveOwner(admin)
+	change(admin)
+	if wasOwner && !isActiveOwner(admin) {
+		others, err := s.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		remaining := 0
+		for _, other := range others {
+			if other.Username != username && isActiveOwner(other) {
+				remaining++
+			}
+		}
+		if remaining == 0 {
+			return nil, ErrLastOwner
+		}
+	}
+
+	if err := s.store.UpdateAdmin(ctx, admin); err != nil {
+		return nil, err
+	}
+	return admin, nil
+}
+
+func isActiveOwner(admin *models.Admin) bool {
+	return admin.Role == models.RoleOwner && !admin.Disabled
+}
+
+// RotatePassword replaces an account's password. Tokens issued before the
+// change stop working.
+func (s *Service) RotatePassword(ctx context.Context, username, password string) (*models.Admin, error) {
+	if err := models.ValidatePassword(password); err != nil {
+		return nil, err
+	}
+
+	admin, err := s.Get(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := s.hash(password)
+	if err != nil {
+		return nil, err
+	}
+	admin.PasswordHash = hash
+	admin.PasswordChangedAt = s.now()
+	if err := s.store.UpdateAdmin(ctx, admin); err != nil {
+		return nil, err
+	}
+	return admin, nil
+}
+
+// Bootstrap makes sure at least one account exists. On first start it creates
+// DefaultUsername as an owner with password, falling back to DefaultPassword outside
+// production. In production it refuses to continue while any enabled account
+// still accepts DefaultPassword.
+func (s *Service) Bootstrap(ctx context.Context, password string, production bool) error {
+	existing, err := s.store.ListAdmins(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(existing) > 0 {
+		if !production {
+			return nil
+		}
+		for _, admin := range existing {
+			if !admin.Disabled && bcrypt.CompareHashAndPassword([]byte(admin.PasswordHash), []byte(DefaultPassword)) == nil {
+				return fmt.Errorf("admin account %q still uses the default password; rotate it before running in production", admin.Username)
+			}
+		}
+		return nil
+	}
+
+	if password == "" {
+		if production {
+			return errors.New("ADMIN_PASSWORD must be set to create the first admin account in production")
+		}
+		log.Printf("⚠ ADMIN_PASSWORD not set; creating %q with the development default password", DefaultUsername)
+		password = DefaultPassword
+	}
+	if production && password == DefaultPassword {
+		return errors.New("refusing to create the first admin account with the default password in production")
+	}
+	if err := models.ValidatePassword(password); err != nil && password != DefaultPassword {
+		log.Printf("⚠ ADMIN_PASSWORD does not meet the password policy (%v); rotate it after logging in", err)
+	}
+
+	if _, err := s.create(ctx, DefaultUsername, password, models.RoleOwner); err != nil {
+		var dup *store.DuplicateError
+		if errors.As(err, &dup) {
+			// Another instance bootstrapped concurrently.
+			return nil
+		}
+		return err
+	}
+	log.Printf("Created initial admin account %q", DefaultUsername)
+	return nil
+}
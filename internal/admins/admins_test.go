@@ -0,0 +1,138 @@
+package admins
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"appdirect-workshop/internal/memory"
+	"appdirect-workshop/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestService() (*Service, *memory.Store) {
+	mem := memory.New()
+	s := NewService(mem)
+	s.cost = bcrypt.MinCost
+	return s, mem
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService()
+
+	_, err := s.Create(ctx, "alice", "correct horse", models.RoleOrganizer)
+	require.NoError(t, err)
+
+	admin, err := s.Authenticate(ctx, "alice", "correct horse")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", admin.Username)
+	assert.NotEqual(t, "correct horse", admin.PasswordHash, "passwords are stored hashed")
+
+	_, err = s.Authenticate(ctx, "alice", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = s.Authenticate(ctx, "nobody", "correct horse")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = s.SetDisabled(ctx, "alice", true)
+	require.NoError(t, err)
+	_, err = s.Authenticate(ctx, "alice", "correct horse")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestCreateValidates(t *testing.T) {
+	s, _ := newTestService()
+
+	_, err := s.Create(context.Background(), "Alice Smith", "short", "root")
+	var errs models.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	assert.Len(t, errs, 3)
+}
+
+func TestRotatePassword(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService()
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return created }
+
+	_, err := s.Create(ctx, "alice", "correct horse", models.RoleOrganizer)
+	require.NoError(t, err)
+
+	rotated := created.Add(time.Hour)
+	s.now = func() time.Time { return rotated }
+	admin, err := s.RotatePassword(ctx, "alice", "battery staple")
+	require.NoError(t, err)
+	assert.Equal(t, rotated, admin.PasswordChangedAt)
+	assert.Equal(t, created, admin.CreatedAt)
+
+	_, err = s.Authenticate(ctx, "alice", "correct horse")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = s.Authenticate(ctx, "alice", "battery staple")
+	assert.NoError(t, err)
+}
+
+func TestBootstrap(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("development falls back to the default password", func(t *testing.T) {
+		s, _ := newTestService()
+		require.NoError(t, s.Bootstrap(ctx, "", false))
+		_, err := s.Authenticate(ctx, DefaultUsername, DefaultPassword)
+		assert.NoError(t, err)
+
+		// Later starts leave existing accounts alone
+		require.NoError(t, s.Bootstrap(ctx, "something else", false))
+		_, err = s.Authenticate(ctx, DefaultUsername, DefaultPassword)
+		assert.NoError(t, err)
+	})
+
+	t.Run("production requires a password", func(t *testing.T) {
+		s, _ := newTestService()
+		assert.Error(t, s.Bootstrap(ctx, "", true))
+		assert.Error(t, s.Bootstrap(ctx, DefaultPassword, true))
+
+		require.NoError(t, s.Bootstrap(ctx, "a strong password", true))
+		_, err := s.Authenticate(ctx, DefaultUsername, "a strong password")
+		assert.NoError(t, err)
+	})
+
+	t.Run("production refuses default credentials", func(t *testing.T) {
+		s, _ := newTestService()
+		require.NoError(t, s.Bootstrap(ctx, "", false))
+		assert.Error(t, s.Bootstrap(ctx, "", true))
+
+		_, err := s.RotatePassword(ctx, DefaultUsername, "a strong password")
+		require.NoError(t, err)
+		assert.NoError(t, s.Bootstrap(ctx, "", true))
+	})
+}
+
+func TestLastOwnerIsProtected(t *testing.T) {
+	ctx := context.Background()
+	s, mem := newTestService()
+	require.NoError(t, s.Bootstrap(ctx, "", false))
+
+	_, err := s.SetRole(ctx, DefaultUsername, models.RoleViewer)
+	assert.ErrorIs(t, err, ErrLastOwner)
+	_, err = s.SetDisabled(ctx, DefaultUsername, true)
+	assert.ErrorIs(t, err, ErrLastOwner)
+
+	_, err = s.Create(ctx, "alice", "correct horse", models.RoleOwner)
+	require.NoError(t, err)
+	admin, err := s.SetRole(ctx, DefaultUsername, models.RoleViewer)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleViewer, admin.Role)
+
+	_, err = s.SetRole(ctx, "alice", "root")
+	var errs models.ValidationErrors
+	assert.ErrorAs(t, err, &errs)
+
+	// Accounts stored before roles existed keep full access
+	require.NoError(t, mem.CreateAdmin(ctx, &models.Admin{Username: "legacy"}))
+	legacy, err := s.Get(ctx, "legacy")
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleOwner, legacy.Role)
+}
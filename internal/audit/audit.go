@@ -0,0 +1,74 @@
+// Package audit records security-relevant events such as admin logins and
+// door check-ins.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+// Entry is a single audited event.
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	Actor  string    `json:"actor,omitempty"`
+	IP     string    `json:"ip,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// Actions recorded by the API.
+const (
+	LoginSucceeded = "admin.login.succeeded"
+	LoginFailed    = "admin.login.failed"
+	LoginBlocked   = "admin.login.blocked"
+	CheckedIn      = "attendee.checked_in"
+	// AttendeesExported records who downloaded attendee data, and how many
+	// rows in which format.
+	AttendeesExported = "attendees.exported"
+	// Imported records a bulk import: what was imported and how many rows.
+	Imported = "data.imported"
+)
+
+// Logger records audit entries. Implementations must be safe for concurrent
+// use.
+type Logger interface {
+	Record(ctx context.Context, e Entry)
+}
+
+// StdLogger writes entries as single-line JSON through the standard logger,
+// which Cloud Run forwards to Cloud Logging.
+type StdLogger struct{}
+
+func (StdLogger) Record(ctx context.Context, e Entry) {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("audit: %s %s", e.Action, e.Actor)
+		return
+	}
+	log.Printf("audit: %s", line)
+}
+
+// Memory keeps entries in memory, for tests.
+type Memory struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+func (m *Memory) Record(ctx context.Context, e Entry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, e)
+}
+
+// Entries returns a copy of the recorded entries.
+func (m *Memory) Entries() []Entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Entry(nil), m.entries...)
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter throttles repeated attempts per key, such as a client IP. Every
+// attempt counts as a failure until it is forgiven. After
+// Policy.FreeAttempts failures a key is locked out, and every further failure
+// doubles the lockout up to Policy.MaxLockout.
+//
+// Methods take a context and return an error so implementations backed by
+// shared state, such as Redis, can be plugged in when running more than one
+// instance.
+type Limiter interface {
+	// Attempt records an attempt for key and returns zero, or returns how
+	// long key must wait, without recording anything, while it is locked
+	// out. Checking and recording is one step, so concurrent attempts
+	// cannot all slip in under the limit.
+	Attempt(ctx context.Context, key string) (time.Duration, error)
+	// Forgive takes back one attempt recorded for key, for an attempt that
+	// succeeded. It leaves the other failures alone, so a key still over
+	// its free attempts stays locked out.
+	Forgive(ctx context.Context, key string) error
+	// Reset forgets the failures recorded for key.
+	Reset(ctx context.Context, key string) error
+}
+
+// Policy configures when a Limiter locks a key out and for how long.
+type Policy struct {
+	// FreeAttempts is how many failures are allowed before the first lockout.
+	FreeAttempts int
+	// BaseLockout is the first lockout; each further failure doubles it.
+	BaseLockout time.Duration
+	// MaxLockout caps the lockout.
+	MaxLockout time.Duration
+	// Window is how long a key must go without failures before its count
+	// starts over.
+	Window time.Duration
+}
+
+// lockout returns the lockout after failures consecutive failures.
+func (p Policy) lockout(failures int) time.Duration {
+	over := failures - p.FreeAttempts
+	if over <= 0 {
+		return 0
+	}
+	d := p.BaseLockout
+	for i := 1; i < over && d < p.MaxLockout; i++ {
+		d *= 2
+	}
+	if d > p.MaxLockout {
+		d = p.MaxLockout
+	}
+	return d
+}
+
+type limiterEntry struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// MemoryLimiter keeps failure counts in process memory, which is enough for a
+// single instance. Entries are dropped once their window has passed.
+type MemoryLimiter struct {
+	policy Policy
+
+	mu      sync.Mutex
+	entries map[string]*limiterEntry
+	swept   time.Time
+	now     func() time.Time
+}
+
+// NewMemoryLimiter returns a MemoryLimiter enforcing policy.
+func NewMemoryLimiter(policy Policy) *MemoryLimiter {
+	return &MemoryLimiter{policy: policy, entries: map[string]*limiterEntry{}, now: time.Now}
+}
+
+// entry returns the live entry for key, dropping it if its window has passed.
+// The caller must hold l.mu.
+func (l *MemoryLimiter) entry(key string, now time.Time) *limiterEntry {
+	if now.Sub(l.swept) > l.policy.Window {
+		for k, e := range l.entries {
+			if l.expired(e, now) {
+				delete(l.entries, k)
+			}
+		}
+		l.swept = now
+	}
+
+	e, ok := l.entries[key]
+	if ok && l.expired(e, now) {
+		delete(l.entries, key)
+		return nil
+	}
+	return e
+}
+
+func (l *MemoryLimiter) expired(e *limiterEntry, now time.Time) bool {
+	return now.After(e.lockedUntil) && now.Sub(e.lastFailure) > l.policy.Window
+}
+
+func (l *MemoryLimiter) Attempt(ctx context.Context, key string) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	e := l.entry(key, now)
+	if e == nil {
+		e = &limiterEntry{}
+		l.entries[key] = e
+	}
+	if now.Before(e.lockedUntil) {
+		return e.lockedUntil.Sub(now), nil
+	}
+	e.failures++
+	e.lastFailure = now
+	if lockout := l.policy.lockout(e.failures); lockout > 0 {
+		e.lockedUntil = now.Add(lockout)
+	}
+	return 0, nil
+}
+
+func (l *MemoryLimiter) Forgive(ctx context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e := l.entry(key, l.now())
+	if e == nil || e.failures == 0 {
+		return nil
+	}
+	e.failures--
+	e.lockedUntil = time.Time{}
+	if lockout := l.policy.lockout(e.failures); lockout > 0 {
+		e.lockedUntil = e.lastFailure.Add(lockout)
+	}
+	return nil
+}
+
+func (l *MemoryLimiter) Reset(ctx context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, key)
+	return nil
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyLockout(t *testing.T) {
+	p := Policy{FreeAttempts: 3, BaseLockout: time.Second, MaxLockout: 5 * time.Second}
+
+	assert.Equal(t, time.Duration(0), p.lockout(3))
+	assert.Equal(t, time.Second, p.lockout(4))
+	assert.Equal(t, 2*time.Second, p.lockout(5))
+	assert.Equal(t, 4*time.Second, p.lockout(6))
+	assert.Equal(t, 5*time.Second, p.lockout(7))
+	assert.Equal(t, 5*time.Second, p.lockout(100))
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewMemoryLimiter(Policy{FreeAttempts: 2, BaseLockout: 10 * time.Second, MaxLockout: time.Minute, Window: time.Hour})
+	l.now = func() time.Time { return now }
+
+	// The attempt past the free ones still goes ahead, and locks the key
+	for i := 0; i < 3; i++ {
+		wait, err := l.Attempt(ctx, "ip")
+		require.NoError(t, err)
+		assert.Zero(t, wait)
+	}
+
+	now = now.Add(4 * time.Second)
+	wait, err := l.Attempt(ctx, "ip")
+	require.NoError(t, err)
+	assert.Equal(t, 6*time.Second, wait)
+	wait, _ = l.Attempt(ctx, "other")
+	assert.Zero(t, wait, "keys are independent")
+
+	// Blocked attempts are not counted; the next one after the lockout
+	// doubles it
+	now = now.Add(6 * time.Second)
+	wait, _ = l.Attempt(ctx, "ip")
+	assert.Zero(t, wait)
+	wait, _ = l.Attempt(ctx, "ip")
+	assert.Equal(t, 20*time.Second, wait)
+
+	// Forgiving a successful attempt leaves the earlier failures counted
+	require.NoError(t, l.Forgive(ctx, "ip"))
+	wait, _ = l.Attempt(ctx, "ip")
+	assert.Equal(t, 10*time.Second, wait)
+
+	require.NoError(t, l.Reset(ctx, "ip"))
+	wait, _ = l.Attempt(ctx, "ip")
+	assert.Zero(t, wait)
+	require.NoError(t, l.Forgive(ctx, "ip"))
+	wait, _ = l.Attempt(ctx, "ip")
+	assert.Zero(t, wait)
+
+	// Failures are forgotten after a quiet window
+	l.Attempt(ctx, "ip")
+	now = now.Add(2 * time.Hour)
+	l.Attempt(ctx, "ip")
+	l.Attempt(ctx, "ip")
+	wait, _ = l.Attempt(ctx, "ip")
+	assert.Zero(t, wait)
+}
+
+func TestMemoryLimiterConcurrentAttempts(t *testing.T) {
+	ctx := context.Background()
+	l := NewMemoryLimiter(Policy{FreeAttempts: 5, BaseLockout: time.Minute, MaxLockout: time.Hour, Window: time.Hour})
+
+	var wg sync.WaitGroup
+	var allowed int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if wait, _ := l.Attempt(ctx, "ip"); wait == 0 {
+				atomic.AddInt32(&allowed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(6), allowed, "the free attempts and the one that locks the key")
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkSigner(t *testing.T) {
+	s := NewLinkSigner([]byte("secret"))
+
+	token := s.Sign("abc123")
+	id, err := s.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", id)
+
+	forged := NewLinkSigner([]byte("other-secret")).Sign("abc123")
+	_, sig, _ := strings.Cut(token, ".")
+	for name, bad := range map[string]string{
+		"garbage":       "not-a-token",
+		"wrong key":     forged,
+		"swapped id":    "abc124." + sig,
+		"missing id":    "." + sig,
+		"truncated mac": token[:len(token)-2],
+	} {
+		_, err := s.Verify(bad)
+		assert.ErrorIs(t, err, ErrInvalidToken, name)
+	}
+}
+
+func TestTicketsAreNotManagementTokens(t *testing.T) {
+	secret := []byte("secret")
+	links, tickets := NewLinkSigner(secret), NewTicketSigner(secret)
+
+	ticket := tickets.Sign("abc123")
+	id, err := tickets.Verify(ticket)
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", id)
+
+	_, err = links.Verify(ticket)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = tickets.Verify(links.Sign("abc123"))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
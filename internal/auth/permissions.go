@@ -0,0 +1,47 @@
+package auth
+
+import "appdirect-workshop/internal/models"
+
+// Permission names an action on the admin API. Routes declare the permission
+// they need and roles grant a fixed set of them.
+type Permission string
+
+// Permissions checked by the admin routes.
+const (
+	PermViewAttendees   Permission = "attendees:read"
+	PermManageAttendees Permission = "attendees:write"
+	PermCheckIn         Permission = "attendees:checkin"
+	PermManageSpeakers  Permission = "speakers:write"
+	PermManageSessions  Permission = "sessions:write"
+	PermManageAdmins    Permission = "admins:write"
+	PermManageEvent     Permission = "event:write"
+)
+
+var rolePermissions = map[models.Role][]Permission{
+	models.RoleOwner: {
+		PermViewAttendees, PermManageAttendees, PermCheckIn,
+		PermManageSpeakers, PermManageSessions, PermManageEvent, PermManageAdmins,
+	},
+	models.RoleOrganizer: {
+		PermViewAttendees, PermManageAttendees, PermCheckIn,
+		PermManageSpeakers, PermManageSessions, PermManageEvent,
+	},
+	models.RoleCoordinator: {PermManageSpeakers},
+	models.RoleCheckin:     {PermViewAttendees, PermCheckIn},
+	models.RoleViewer:      {PermViewAttendees},
+}
+
+// Permissions returns the permissions granted to role. Unknown roles get none.
+func Permissions(role models.Role) []Permission {
+	return append([]Permission(nil), rolePermissions[role]...)
+}
+
+// Allowed reports whether role grants perm.
+func Allowed(role models.Role, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
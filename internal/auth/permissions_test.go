@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"testing"
+
+	"appdirect-workshop/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRolePermissions(t *testing.T) {
+	for _, role := range models.Roles {
+		assert.NotEmpty(t, Permissions(role), "role %s grants nothing", role)
+	}
+
+	assert.True(t, Allowed(models.RoleOwner, PermManageAdmins))
+	assert.False(t, Allowed(models.RoleOrganizer, PermManageAdmins))
+	assert.True(t, Allowed(models.RoleOrganizer, PermManageEvent))
+	assert.False(t, Allowed(models.RoleCoordinator, PermManageEvent))
+	assert.True(t, Allowed(models.RoleCoordinator, PermManageSpeakers))
+	assert.False(t, Allowed(models.RoleCoordinator, PermManageSessions))
+	assert.True(t, Allowed(models.RoleCheckin, PermCheckIn))
+	assert.False(t, Allowed(models.RoleCheckin, PermManageAttendees))
+	assert.False(t, Allowed(models.RoleViewer, PermCheckIn))
+	assert.False(t, Allowed(models.Role("root"), PermViewAttendees))
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// RevocationList records tokens that were logged out or replaced by a
+// refresh before they expired.
+type RevocationList interface {
+	Revoke(id string, until time.Time)
+	IsRevoked(id string) bool
+}
+
+// MemoryRevocationList keeps revoked token IDs in process memory. Entries are
+// dropped once the token would have expired. Deployments running more than
+// one instance need a shared implementation instead.
+type MemoryRevocationList struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+	now     func() time.Time
+}
+
+// NewMemoryRevocationList returns an empty MemoryRevocationList.
+func NewMemoryRevocationList() *MemoryRevocationList {
+	return &MemoryRevocationList{entries: map[string]time.Time{}, now: time.Now}
+}
+
+func (l *MemoryRevocationList) Revoke(id string, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for jti, exp := range l.entries {
+		if now.After(exp) {
+			delete(l.entries, jti)
+		}
+	}
+	l.entries[id] = until
+}
+
+func (l *MemoryRevocationList) IsRevoked(id string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.entries[id]
+	return ok
+}
@@ -0,0 +1,102 @@
+// Package auth issues and verifies the signed session tokens used by the
+// admin API.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, expired, signed
+// with another key or revoked.
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// Claims are the contents of an admin session token.
+type Claims struct {
+	jwt.RegisteredClaims
+	// Version is the account's token version when the token was issued.
+	// Changing the version revokes every token issued before.
+	Version int64 `json:"ver,omitempty"`
+}
+
+// Manager signs tokens with an HMAC key and checks them against a revocation
+// list.
+type Manager struct {
+	secret  []byte
+	ttl     time.Duration
+	revoked RevocationList
+	now     func() time.Time
+}
+
+// NewManager returns a Manager issuing HS256 tokens valid for ttl.
+func NewManager(secret []byte, ttl time.Duration, revoked RevocationList) *Manager {
+	return &Manager{secret: secret, ttl: ttl, revoked: revoked, now: time.Now}
+}
+
+// Issue returns a signed token for subject at version together with its
+// claims.
+func (m *Manager) Issue(subject string, version int64) (string, *Claims, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", nil, err
+	}
+
+	now := m.now()
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        hex.EncodeToString(jti),
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.ttl)),
+		},
+		Version: version,
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
+}
+
+// Verify parses token and returns its claims if the signature is valid, it
+// has not expired and it has not been revoked.
+func (m *Manager) Verify(token string) (*Claims, error) {
+	claims := &Claims{}
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	_, err := parser.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if m.revoked.IsRevoked(claims.ID) {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// Revoke invalidates a token until it would have expired anyway.
+func (m *Manager) Revoke(claims *Claims) {
+	m.revoked.Revoke(claims.ID, claims.ExpiresAt.Time)
+}
+
+type claimsKey struct{}
+
+// WithClaims returns a copy of ctx carrying the caller's verified claims.
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims stored by WithClaims, if any.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
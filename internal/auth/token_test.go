@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueAndVerify(t *testing.T) {
+	m := NewManager([]byte("secret"), time.Hour, NewMemoryRevocationList())
+
+	token, issued, err := m.Issue("admin", 1)
+	require.NoError(t, err)
+
+	claims, err := m.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "admin", claims.Subject)
+	assert.Equal(t, issued.ID, claims.ID)
+}
+
+func TestVerifyRejectsBadTokens(t *testing.T) {
+	m := NewManager([]byte("secret"), time.Hour, NewMemoryRevocationList())
+	other := NewManager([]byte("other-secret"), time.Hour, NewMemoryRevocationList())
+
+	expired := NewManager([]byte("secret"), time.Hour, NewMemoryRevocationList())
+	expired.now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+
+	forged, _, err := other.Issue("admin", 1)
+	require.NoError(t, err)
+	stale, _, err := expired.Issue("admin", 1)
+	require.NoError(t, err)
+
+	for name, token := range map[string]string{"garbage": "not-a-token", "wrong key": forged, "expired": stale} {
+		_, err := m.Verify(token)
+		assert.ErrorIs(t, err, ErrInvalidToken, name)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	m := NewManager([]byte("secret"), time.Hour, NewMemoryRevocationList())
+
+	token, claims, err := m.Issue("admin", 1)
+	require.NoError(t, err)
+	m.Revoke(claims)
+
+	_, err = m.Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestRevocationListPrunesExpiredEntries(t *testing.T) {
+	l := NewMemoryRevocationList()
+	l.Revoke("old", time.Now().Add(-time.Minute))
+	l.Revoke("new", time.Now().Add(time.Hour))
+
+	assert.False(t, l.IsRevoked("old"))
+	assert.True(t, l.IsRevoked("new"))
+}
@@ -0,0 +1,49 @@
+package firestore
+
+import (
+	"context"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ store.AdminStore = (*Client)(nil)
+
+// Admin accounts use the username as their document ID so it stays unique.
+const adminsCollection = "admins"
+
+func (c *Client) ListAdmins(ctx context.Context) ([]*models.Admin, error) {
+	return listDocuments(ctx, c.GetCollection(ctx, adminsCollection), func(a *models.Admin, id string) { a.Username = id })
+}
+
+func (c *Client) GetAdmin(ctx context.Context, username string) (*models.Admin, error) {
+	snap, err := c.GetCollection(ctx, adminsCollection).Doc(username).Get(ctx)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	var admin models.Admin
+	if err := snap.DataTo(&admin); err != nil {
+		return nil, err
+	}
+	admin.Username = snap.Ref.ID
+	return &admin, nil
+}
+
+func (c *Client) CreateAdmin(ctx context.Context, admin *models.Admin) error {
+	_, err := c.GetCollection(ctx, adminsCollection).Doc(admin.Username).Create(ctx, admin)
+	if status.Code(err) == codes.AlreadyExists {
+		return &store.DuplicateError{ExistingID: admin.Username}
+	}
+	return err
+}
+
+func (c *Client) UpdateAdmin(ctx context.Context, admin *models.Admin) error {
+	return c.replaceDocument(ctx, adminsCollection, admin.Username, admin)
+}
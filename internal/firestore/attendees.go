@@ -0,0 +1,621 @@
+package firestore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// attendeeEmailsCollection holds one document per normalized email, keyed by
+// its SHA-256, pointing at the attendee that owns it. Creating the index
+// document in the same transaction as the attendee makes registration
+// idempotent even under concurrent double submits.
+const attendeeEmailsCollection = "attendee_emails"
+
+type emailIndex struct {
+	AttendeeID string `firestore:"attendeeId"`
+}
+
+func (c *Client) emailIndexRef(ctx context.Context, email string) *firestore.DocumentRef {
+	sum := sha256.Sum256([]byte(models.NormalizeEmail(email)))
+	return c.GetCollection(ctx, attendeeEmailsCollection).Doc(hex.EncodeToString(sum[:]))
+}
+
+// BackfillEmailIndex gives the attendees registered before the email index
+// existed their index entries, so duplicate checks never have to match the
+// stored address itself. The earliest registration of an address owns it;
+// later ones stay duplicates for an admin to merge. It returns how many
+// entries were created.
+func (c *Client) BackfillEmailIndex(ctx context.Context) (int, error) {
+	attendees, err := c.ListAttendees(ctx)
+	if err != nil {
+		return 0, err
+	}
+	sort.SliceStable(attendees, func(i, j int) bool {
+		return attendees[i].CreatedAt.Before(attendees[j].CreatedAt)
+	})
+
+	created := 0
+	seen := map[string]bool{}
+	for _, a := range attendees {
+		email := models.NormalizeEmail(a.Email)
+		if a.Status == models.StatusCancelled || seen[email] {
+			continue
+		}
+		seen[email] = true
+
+		indexRef := c.emailIndexRef(ctx, a.Email)
+		added := false
+		err := c.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			added = false
+			_, err := tx.Get(indexRef)
+			if !isNotFound(err) {
+				return err
+			}
+			added = true
+			return tx.Create(indexRef, emailIndex{AttendeeID: a.ID})
+		})
+		if err != nil {
+			return created, fmt.Errorf("attendee %s: %w", a.ID, err)
+		}
+		if added {
+			created++
+		}
+	}
+	return created, nil
+}
+
+// The event settings and the per-status attendee counters live in single
+// documents. Every transaction that decides who holds a seat reads and
+// rewrites the counters, so Firestore serializes them and capacity is never
+// oversold.
+func (c *Client) settingsRef(ctx context.Context) *firestore.DocumentRef {
+	return c.GetCollection(ctx, "settings").Doc("event")
+}
+
+func (c *Client) statsRef(ctx context.Context) *firestore.DocumentRef {
+	return c.GetCollection(ctx, "stats").Doc("attendees")
+}
+
+// seats is the capacity state read at the start of a transaction.
+type seats struct {
+	capacity int
+	stats    store.AttendeeStats
+	// legacy lists attendees stored before statuses existed, found while
+	// building the counters for the first time; write gives them a status.
+	legacy []*firestore.DocumentRef
+}
+
+// rewriting drops id from the legacy backfill because the caller replaces or
+// deletes that attendee itself.
+func (s *seats) rewriting(id string) {
+	kept := s.legacy[:0]
+	for _, ref := range s.legacy {
+		if ref.ID != id {
+			kept = append(kept, ref)
+		}
+	}
+	s.legacy = kept
+}
+
+// readSeats loads the settings and counters. The first time, the counters
+// are built by reading every attendee; afterwards they are maintained
+// incrementally.
+func (c *Client) readSeats(ctx context.Context, tx *firestore.Transaction) (*seats, error) {
+	s := &seats{}
+	snap, err := tx.Get(c.settingsRef(ctx))
+	if err == nil {
+		var settings models.EventSettings
+		if err := snap.DataTo(&settings); err != nil {
+			return nil, err
+		}
+		s.capacity = settings.Capacity
+	} else if !isNotFound(err) {
+		return nil, err
+	}
+
+	snap, err = tx.Get(c.statsRef(ctx))
+	if err == nil {
+		return s, snap.DataTo(&s.stats)
+	}
+	if !isNotFound(err) {
+		return nil, err
+	}
+
+	iter := tx.Documents(c.GetCollection(ctx, "attendees"))
+	defer iter.Stop()
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var a models.Attendee
+		if err := doc.DataTo(&a); err != nil {
+			return nil, err
+		}
+		if a.Status == "" {
+			s.legacy = append(s.legacy, doc.Ref)
+		}
+		s.stats.Add(a.Status, 1)
+	}
+	return s, nil
+}
+
+// waitlist reads up to limit waitlisted attendees in promotion order, or all
+// of them when limit is 0. The query needs a composite index on status and
+// createdAt.
+func (c *Client) waitlist(ctx context.Context, tx *firestore.Transaction, limit int) ([]*models.Attendee, error) {
+	q := c.GetCollection(ctx, "attendees").
+		Where("status", "==", models.StatusWaitlisted).
+		OrderBy("createdAt", firestore.Asc).
+		OrderBy(firestore.DocumentID, firestore.Asc)
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	var iter *firestore.DocumentIterator
+	if tx != nil {
+		iter = tx.Documents(q)
+	} else {
+		iter = q.Documents(ctx)
+	}
+	defer iter.Stop()
+
+	var out []*models.Attendee
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		a := new(models.Attendee)
+		if err := doc.DataTo(a); err != nil {
+			return nil, err
+		}
+		a.ID = doc.Ref.ID
+		a.WaitlistPosition = len(out) + 1
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+// promotions reads the waitlisted attendees that fit in the seats open under
+// s, skipping any in exclude, which the caller is already rewriting.
+func (c *Client) promotions(ctx context.Context, tx *firestore.Transaction, s *seats, exclude map[string]bool) ([]*models.Attendee, error) {
+	n := s.stats.Promotable(s.capacity)
+	if n == 0 {
+		return nil, nil
+	}
+	candidates, err := c.waitlist(ctx, tx, n+len(exclude))
+	if err != nil {
+		return nil, err
+	}
+
+	var promoted []*models.Attendee
+	for _, a := range candidates {
+		if len(promoted) == n {
+			break
+		}
+		if !exclude[a.ID] {
+			promoted = append(promoted, a)
+		}
+	}
+	return promoted, nil
+}
+
+// writeSeats registers promoted, then stores the counters and backfills legacy
+// attendees. It must follow every read in the transaction.
+func (c *Client) writeSeats(ctx context.Context, tx *firestore.Transaction, s *seats, promoted []*models.Attendee) error {
+	col := c.GetCollection(ctx, "attendees")
+	now := time.Now().UTC()
+	for _, a := range promoted {
+		a.Status = models.StatusRegistered
+		a.WaitlistPosition = 0
+		a.PromotedAt = &now
+		if err := tx.Set(col.Doc(a.ID), a); err != nil {
+			return err
+		}
+		if err := tx.Set(c.emailIndexRef(ctx, a.Email), emailIndex{AttendeeID: a.ID}); err != nil {
+			return err
+		}
+		s.stats.Add(models.StatusWaitlisted, -1)
+		s.stats.Add(models.StatusRegistered, 1)
+	}
+	for _, ref := range s.legacy {
+		if err := tx.Update(ref, []firestore.Update{{Path: "status", Value: models.StatusRegistered}}); err != nil {
+			return err
+		}
+	}
+	return tx.Set(c.statsRef(ctx), s.stats)
+}
+
+func (c *Client) ListAttendees(ctx context.Context) ([]*models.Attendee, error) {
+	return listDocuments(ctx, c.GetCollection(ctx, "attendees"), func(a *models.Attendee, id string) { a.ID = id })
+}
+
+func (c *Client) GetAttendee(ctx context.Context, id string) (*models.Attendee, error) {
+	snap, err := c.GetCollection(ctx, "attendees").Doc(id).Get(ctx)
+	if isNotFound(err) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var a models.Attendee
+	if err := snap.DataTo(&a); err != nil {
+		return nil, err
+	}
+	a.ID = id
+	return &a, nil
+}
+
+func (c *Client) CreateAttendee(ctx context.Context, attendee *models.Attendee) error {
+	col := c.GetCollection(ctx, "attendees")
+	indexRef := c.emailIndexRef(ctx, attendee.Email)
+
+	var ref *firestore.DocumentRef
+	var position int
+	err := c.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		ref = col.NewDoc()
+
+		snap, err := tx.Get(indexRef)
+		if err == nil {
+			var idx emailIndex
+			if err := snap.DataTo(&idx); err != nil {
+				return err
+			}
+			return &store.DuplicateError{ExistingID: idx.AttendeeID}
+		}
+		if !isNotFound(err) {
+			return err
+		}
+
+		s, err := c.readSeats(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		a := *attendee
+		a.Status = models.StatusRegistered
+		a.WaitlistPosition = 0
+		position = 0
+		if !s.stats.HasSeat(s.capacity) {
+			a.Status = models.StatusWaitlisted
+			position = s.stats.Waitlisted + 1
+		}
+		s.stats.Add(a.Status, 1)
+
+		if err := tx.Create(ref, &a); err != nil {
+			return err
+		}
+		if err := tx.Create(indexRef, emailIndex{AttendeeID: ref.ID}); err != nil {
+			return err
+		}
+		return c.writeSeats(ctx, tx, s, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	attendee.ID = ref.ID
+	attendee.Status = models.StatusRegistered
+	attendee.WaitlistPosition = position
+	if position > 0 {
+		attendee.Status = models.StatusWaitlisted
+	}
+	return nil
+}
+
+func (c *Client) UpdateAttendee(ctx context.Context, attendee *models.Attendee) error {
+	col := c.GetCollection(ctx, "attendees")
+	ref := col.Doc(attendee.ID)
+
+	var updated *models.Attendee
+	err := c.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if isNotFound(err) {
+			return store.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		stored := new(models.Attendee)
+		if err := snap.DataTo(stored); err != nil {
+			return err
+		}
+		if stored.Status == models.StatusCancelled {
+			return store.ErrCancelled
+		}
+
+		oldIndex := c.emailIndexRef(ctx, stored.Email)
+		newIndex := c.emailIndexRef(ctx, attendee.Email)
+		// An unmerged legacy duplicate does not own its email; it must not
+		// release or take over the original's index entry.
+		ownsOld, oldClaimed := false, false
+		if snap, err := tx.Get(oldIndex); err == nil {
+			var idx emailIndex
+			if err := snap.DataTo(&idx); err != nil {
+				return err
+			}
+			ownsOld, oldClaimed = idx.AttendeeID == attendee.ID, true
+		} else if !isNotFound(err) {
+			return err
+		}
+
+		moving := newIndex.ID != oldIndex.ID
+		if moving {
+			snap, err := tx.Get(newIndex)
+			if err == nil {
+				var idx emailIndex
+				if err := snap.DataTo(&idx); err != nil {
+					return err
+				}
+				return &store.DuplicateError{ExistingID: idx.AttendeeID}
+			}
+			if !isNotFound(err) {
+				return err
+			}
+		}
+
+		stored.Name, stored.Email, stored.Designation = attendee.Name, attendee.Email, attendee.Designation
+		if err := tx.Set(ref, stored); err != nil {
+			return err
+		}
+		updated = stored
+		if !moving {
+			if oldClaimed {
+				return nil
+			}
+			return tx.Set(newIndex, emailIndex{AttendeeID: attendee.ID})
+		}
+		if ownsOld {
+			if err := tx.Delete(oldIndex); err != nil {
+				return err
+			}
+		}
+		return tx.Set(newIndex, emailIndex{AttendeeID: attendee.ID})
+	})
+	if err != nil {
+		return err
+	}
+
+	updated.ID = attendee.ID
+	*attendee = *updated
+	return nil
+}
+
+func (c *Client) CancelAttendee(ctx context.Context, id string) (*models.Attendee, []*models.Attendee, error) {
+	ref := c.GetCollection(ctx, "attendees").Doc(id)
+
+	var cancelled *models.Attendee
+	var promoted []*models.Attendee
+	err := c.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if isNotFound(err) {
+			return store.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		a := new(models.Attendee)
+		if err := snap.DataTo(a); err != nil {
+			return err
+		}
+		a.ID = id
+
+		// Only release the email if this attendee owns it; an unmerged
+		// legacy duplicate must not free the address of the original.
+		indexRef := c.emailIndexRef(ctx, a.Email)
+		ownsEmail := false
+		if snap, err := tx.Get(indexRef); err == nil {
+			var idx emailIndex
+			if err := snap.DataTo(&idx); err != nil {
+				return err
+			}
+			ownsEmail = idx.AttendeeID == id
+		} else if !isNotFound(err) {
+			return err
+		}
+
+		s, err := c.readSeats(ctx, tx)
+		if err != nil {
+			return err
+		}
+		s.rewriting(id)
+		wasCancelled := a.Status == models.StatusCancelled
+		if !wasCancelled {
+			s.stats.Add(a.Status, -1)
+			s.stats.Add(models.StatusCancelled, 1)
+		}
+		if promoted, err = c.promotions(ctx, tx, s, nil); err != nil {
+			return err
+		}
+
+		if !wasCancelled {
+			now := time.Now().UTC()
+			a.Status = models.StatusCancelled
+			a.CancelledAt = &now
+			if err := tx.Set(ref, a); err != nil {
+				return err
+			}
+			if ownsEmail {
+				if err := tx.Delete(indexRef); err != nil {
+					return err
+				}
+			}
+		}
+		cancelled = a
+		return c.writeSeats(ctx, tx, s, promoted)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return cancelled, promoted, nil
+}
+
+func (c *Client) CheckInAttendee(ctx context.Context, id, operator string) (*models.Attendee, error) {
+	ref := c.GetCollection(ctx, "attendees").Doc(id)
+
+	var a *models.Attendee
+	err := c.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if isNotFound(err) {
+			return store.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		a = new(models.Attendee)
+		if err := snap.DataTo(a); err != nil {
+			return err
+		}
+		a.ID = id
+		if err := store.CheckIn(a, operator, time.Now().UTC()); err != nil {
+			return err
+		}
+		return tx.Update(ref, []firestore.Update{
+			{Path: "checkedInAt", Value: *a.CheckedInAt},
+			{Path: "checkedInBy", Value: a.CheckedInBy},
+		})
+	})
+	if errors.Is(err, store.ErrNoSeat) || errors.Is(err, store.ErrCheckedIn) {
+		return a, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (c *Client) Waitlist(ctx context.Context) ([]*models.Attendee, error) {
+	return c.waitlist(ctx, nil, 0)
+}
+
+func (c *Client) AttendeeStats(ctx context.Context) (store.AttendeeStats, error) {
+	snap, err := c.statsRef(ctx).Get(ctx)
+	if err == nil {
+		var stats store.AttendeeStats
+		return stats, snap.DataTo(&stats)
+	}
+	if !isNotFound(err) {
+		return store.AttendeeStats{}, err
+	}
+
+	// Build the counters once; later calls read the document.
+	var stats store.AttendeeStats
+	err = c.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		s, err := c.readSeats(ctx, tx)
+		if err != nil {
+			return err
+		}
+		stats = s.stats
+		return c.writeSeats(ctx, tx, s, nil)
+	})
+	return stats, err
+}
+
+func (c *Client) MergeAttendees(ctx context.Context, keep *models.Attendee, duplicateIDs []string) ([]*models.Attendee, error) {
+	col := c.GetCollection(ctx, "attendees")
+
+	var promoted []*models.Attendee
+	err := c.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		refs := []*firestore.DocumentRef{col.Doc(keep.ID)}
+		for _, id := range duplicateIDs {
+			refs = append(refs, col.Doc(id))
+		}
+		snaps, err := tx.GetAll(refs)
+		if err != nil {
+			return err
+		}
+		if !snaps[0].Exists() {
+			return store.ErrNotFound
+		}
+
+		s, err := c.readSeats(ctx, tx)
+		if err != nil {
+			return err
+		}
+		exclude := map[string]bool{}
+		for _, snap := range snaps {
+			if !snap.Exists() {
+				continue
+			}
+			status, _ := snap.DataAt("status")
+			str, _ := status.(string)
+			s.stats.Add(str, -1)
+			exclude[snap.Ref.ID] = true
+			s.rewriting(snap.Ref.ID)
+		}
+		s.stats.Add(keep.Status, 1)
+		if promoted, err = c.promotions(ctx, tx, s, exclude); err != nil {
+			return err
+		}
+
+		if err := tx.Set(refs[0], keep); err != nil {
+			return err
+		}
+		if err := tx.Set(c.emailIndexRef(ctx, keep.Email), emailIndex{AttendeeID: keep.ID}); err != nil {
+			return err
+		}
+		for _, ref := range refs[1:] {
+			if err := tx.Delete(ref); err != nil {
+				return err
+			}
+		}
+		return c.writeSeats(ctx, tx, s, promoted)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promoted, nil
+}
+
+func (c *Client) EventSettings(ctx context.Context) (*models.EventSettings, error) {
+	var settings models.EventSettings
+	snap, err := c.settingsRef(ctx).Get(ctx)
+	if isNotFound(err) {
+		return &settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, snap.DataTo(&settings)
+}
+
+func (c *Client) UpdateEventSettings(ctx context.Context, settings *models.EventSettings) ([]*models.Attendee, error) {
+	var promoted []*models.Attendee
+	err := c.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		s, err := c.readSeats(ctx, tx)
+		if err != nil {
+			return err
+		}
+		s.capacity = settings.Capacity
+		if promoted, err = c.promotions(ctx, tx, s, nil); err != nil {
+			return err
+		}
+
+		if err := tx.Set(c.settingsRef(ctx), settings); err != nil {
+			return err
+		}
+		return c.writeSeats(ctx, tx, s, promoted)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promoted, nil
+}
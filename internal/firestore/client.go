@@ -11,6 +11,8 @@ import (
 type Client struct {
 	*firestore.Client
 	subcollectionID string
+	// workshopID is set on the clients returned by Scoped.
+	workshopID string
 }
 
 // NewClient creates a new Firestore client
@@ -21,13 +23,13 @@ func NewClient(ctx context.Context, projectID, databaseID, serviceAccountPath st
 	var err error
 
 	// If using default database, we can keep it simple or unify the logic.
-	// However, firebase.NewApp doesn't easily expose WithDatabase. 
+	// However, firebase.NewApp doesn't easily expose WithDatabase.
 	// So we switch to using the firestore client directly which supports it more transparently,
-	// or we use the specific constructor if available. 
+	// or we use the specific constructor if available.
 	// Given the potential dependency versions, let's try to construct it with options.
 
 	opts := []option.ClientOption{}
-	
+
 	if serviceAccountPath != "" && serviceAccountPath != "ADC" {
 		log.Printf("Using service account file: %s", serviceAccountPath)
 		opts = append(opts, option.WithCredentialsFile(serviceAccountPath))
@@ -60,12 +62,17 @@ func (c *Client) GetSubcollectionID() string {
 	return c.subcollectionID
 }
 
-// Helper function to get collection reference
+// GetCollection returns the named collection. On a client scoped to a
+// workshop it is the subcollection under workshops/{id}, except for admin
+// accounts and the workshops themselves, which every workshop shares.
 func (c *Client) GetCollection(ctx context.Context, name string) *firestore.CollectionRef {
-	return c.Collection(name)
+	if c.workshopID == "" || name == adminsCollection || name == workshopsCollection {
+		return c.Collection(name)
+	}
+	return c.GetSubcollection(ctx, c.workshopID, name)
 }
 
 // Helper function to get subcollection reference
 func (c *Client) GetSubcollection(ctx context.Context, parentDocID, subcollectionName string) *firestore.CollectionRef {
-	return c.Collection("workshops").Doc(parentDocID).Collection(subcollectionName)
+	return c.Collection(workshopsCollection).Doc(parentDocID).Collection(subcollectionName)
 }
@@ -0,0 +1,246 @@
+package firestore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+var _ store.EnrollmentStore = (*Client)(nil)
+
+// enrollmentsCollection holds one document per attendee and session, keyed
+// by both IDs so enrolling twice finds the existing document.
+const enrollmentsCollection = "enrollments"
+
+func (c *Client) enrollmentRef(ctx context.Context, sessionID, attendeeID string) *firestore.DocumentRef {
+	return c.GetCollection(ctx, enrollmentsCollection).Doc(sessionID + "_" + attendeeID)
+}
+
+// The number enrolled in each session lives in a single counters document.
+// Every transaction that enrolls or unenrolls reads and rewrites it, so
+// Firestore serializes them and no session is overbooked.
+func (c *Client) enrollmentCountsRef(ctx context.Context) *firestore.DocumentRef {
+	return c.GetCollection(ctx, "stats").Doc("enrollments")
+}
+
+type enrollmentCounts struct {
+	Counts map[string]int `firestore:"counts"`
+}
+
+func (c *Client) readEnrollmentCounts(ctx context.Context, tx *firestore.Transaction) (map[string]int, error) {
+	var snap *firestore.DocumentSnapshot
+	var err error
+	if tx != nil {
+		snap, err = tx.Get(c.enrollmentCountsRef(ctx))
+	} else {
+		snap, err = c.enrollmentCountsRef(ctx).Get(ctx)
+	}
+	if isNotFound(err) {
+		return map[string]int{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var counts enrollmentCounts
+	if err := snap.DataTo(&counts); err != nil {
+		return nil, err
+	}
+	if counts.Counts == nil {
+		counts.Counts = map[string]int{}
+	}
+	return counts.Counts, nil
+}
+
+// writeEnrollmentCounts stores the counts of the sessions in changed.
+func (c *Client) writeEnrollmentCounts(ctx context.Context, tx *firestore.Transaction, changed map[string]int) error {
+	return tx.Set(c.enrollmentCountsRef(ctx), map[string]interface{}{"counts": changed}, firestore.MergeAll)
+}
+
+// enrollmentQuery selects the enrollments matching filter.
+func (c *Client) enrollmentQuery(ctx context.Context, filter store.EnrollmentFilter) firestore.Query {
+	q := c.GetCollection(ctx, enrollmentsCollection).Query
+	if filter.SessionID != "" {
+		q = q.Where("sessionId", "==", filter.SessionID)
+	}
+	if filter.AttendeeID != "" {
+		q = q.Where("attendeeId", "==", filter.AttendeeID)
+	}
+	return q
+}
+
+// readEnrollments decodes every enrollment an iterator returns, together
+// with its document reference.
+func readEnrollments(iter *firestore.DocumentIterator) ([]*models.Enrollment, []*firestore.DocumentRef, error) {
+	defer iter.Stop()
+	var out []*models.Enrollment
+	var refs []*firestore.DocumentRef
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		e := new(models.Enrollment)
+		if err := doc.DataTo(e); err != nil {
+			return nil, nil, err
+		}
+		out = append(out, e)
+		refs = append(refs, doc.Ref)
+	}
+	return out, refs, nil
+}
+
+func (c *Client) Enroll(ctx context.Context, sessionID, attendeeID string) (*models.Enrollment, error) {
+	sessionRef := c.GetCollection(ctx, "sessions").Doc(sessionID)
+	attendeeRef := c.GetCollection(ctx, "attendees").Doc(attendeeID)
+	ref := c.enrollmentRef(ctx, sessionID, attendeeID)
+
+	var e *models.Enrollment
+	err := c.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		counts, err := c.readEnrollmentCounts(ctx, tx)
+		if err != nil {
+			return err
+		}
+		snaps, err := tx.GetAll([]*firestore.DocumentRef{sessionRef, attendeeRef, ref})
+		if err != nil {
+			return err
+		}
+		if !snaps[0].Exists() || !snaps[1].Exists() {
+			return store.ErrNotFound
+		}
+		var session models.Session
+		if err := snaps[0].DataTo(&session); err != nil {
+			return err
+		}
+		session.ID = sessionID
+		var a models.Attendee
+		if err := snaps[1].DataTo(&a); err != nil {
+			return err
+		}
+		if !a.HoldsSeat() {
+			return store.ErrNoSeat
+		}
+		if snaps[2].Exists() {
+			e = new(models.Enrollment)
+			return snaps[2].DataTo(e)
+		}
+
+		enrolled, _, err := readEnrollments(tx.Documents(c.enrollmentQuery(ctx, store.EnrollmentFilter{AttendeeID: attendeeID})))
+		if err != nil {
+			return err
+		}
+		var refs []*firestore.DocumentRef
+		for _, other := range enrolled {
+			refs = append(refs, c.GetCollection(ctx, "sessions").Doc(other.SessionID))
+		}
+		var schedule []*models.Session
+		if len(refs) > 0 {
+			docs, err := tx.GetAll(refs)
+			if err != nil {
+				return err
+			}
+			for _, doc := range docs {
+				if !doc.Exists() {
+					continue
+				}
+				other := new(models.Session)
+				if err := doc.DataTo(other); err != nil {
+					return err
+				}
+				other.ID = doc.Ref.ID
+				schedule = append(schedule, other)
+			}
+		}
+		if err := store.CheckEnrollment(&session, counts[sessionID], schedule); err != nil {
+			return err
+		}
+
+		e = &models.Enrollment{SessionID: sessionID, AttendeeID: attendeeID, CreatedAt: time.Now().UTC()}
+		if err := tx.Create(ref, e); err != nil {
+			return err
+		}
+		return c.writeEnrollmentCounts(ctx, tx, map[string]int{sessionID: counts[sessionID] + 1})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (c *Client) Unenroll(ctx context.Context, sessionID, attendeeID string) error {
+	ref := c.enrollmentRef(ctx, sessionID, attendeeID)
+	return c.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		counts, err := c.readEnrollmentCounts(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Get(ref); err != nil {
+			if isNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if err := tx.Delete(ref); err != nil {
+			return err
+		}
+		return c.writeEnrollmentCounts(ctx, tx, map[string]int{sessionID: max(counts[sessionID]-1, 0)})
+	})
+}
+
+func (c *Client) ListEnrollments(ctx context.Context, filter store.EnrollmentFilter) ([]*models.Enrollment, error) {
+	out, _, err := readEnrollments(c.enrollmentQuery(ctx, filter).Documents(ctx))
+	if err != nil {
+		return nil, err
+	}
+	// Sorted here rather than in the query, which would need a composite
+	// index for every filter.
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (c *Client) DeleteEnrollments(ctx context.Context, filter store.EnrollmentFilter) error {
+	if filter == (store.EnrollmentFilter{}) {
+		return nil
+	}
+	return c.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		counts, err := c.readEnrollmentCounts(ctx, tx)
+		if err != nil {
+			return err
+		}
+		enrollments, refs, err := readEnrollments(tx.Documents(c.enrollmentQuery(ctx, filter)))
+		if err != nil || len(refs) == 0 {
+			return err
+		}
+
+		changed := map[string]int{}
+		for i, e := range enrollments {
+			if err := tx.Delete(refs[i]); err != nil {
+				return err
+			}
+			counts[e.SessionID] = max(counts[e.SessionID]-1, 0)
+			changed[e.SessionID] = counts[e.SessionID]
+		}
+		return c.writeEnrollmentCounts(ctx, tx, changed)
+	})
+}
+
+func (c *Client) EnrollmentCounts(ctx context.Context) (map[string]int, error) {
+	counts, err := c.readEnrollmentCounts(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	for id, n := range counts {
+		if n == 0 {
+			delete(counts, id)
+		}
+	}
+	return counts, nil
+}
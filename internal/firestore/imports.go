@@ -0,0 +1,145 @@
+package firestore
+
+import (
+	"context"
+	"fmt"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+
+	"cloud.google.com/go/firestore"
+)
+
+var _ store.ImportStore = (*Client)(nil)
+
+// ImportAttendees registers the attendees in transactions that each read
+// the capacity counters and the email index entries of their attendees, then
+// write every attendee with its index entry and the updated counters. A
+// transaction holds as many attendees as those writes allow within
+// maxBatchWrites. Registrations stored before the email index existed are
+// not looked up; the caller checks the imported emails against the stored
+// attendees.
+func (c *Client) ImportAttendees(ctx context.Context, attendees []*models.Attendee) (int, error) {
+	col := c.GetCollection(ctx, "attendees")
+	done := 0
+	for done < len(attendees) {
+		var batch []*models.Attendee
+		err := c.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			s, err := c.readSeats(ctx, tx)
+			if err != nil {
+				return err
+			}
+			// Each attendee takes two writes; the counters and the
+			// backfill of legacy attendees take the rest.
+			n := max((maxBatchWrites-1-len(s.legacy))/2, 1)
+			batch = attendees[done:min(done+n, len(attendees))]
+
+			refs := make([]*firestore.DocumentRef, len(batch))
+			for i, a := range batch {
+				refs[i] = c.emailIndexRef(ctx, a.Email)
+			}
+			snaps, err := tx.GetAll(refs)
+			if err != nil {
+				return err
+			}
+			claimed := map[string]string{}
+			for i, snap := range snaps {
+				if snap.Exists() {
+					var idx emailIndex
+					if err := snap.DataTo(&idx); err != nil {
+						return err
+					}
+					return &store.DuplicateError{ExistingID: idx.AttendeeID}
+				}
+				if id, ok := claimed[refs[i].ID]; ok {
+					return &store.DuplicateError{ExistingID: id}
+				}
+				claimed[refs[i].ID] = batch[i].ID
+			}
+
+			for i, attendee := range batch {
+				a := *attendee
+				a.Status = models.StatusRegistered
+				a.WaitlistPosition = 0
+				attendee.WaitlistPosition = 0
+				if !s.stats.HasSeat(s.capacity) {
+					a.Status = models.StatusWaitlisted
+					attendee.WaitlistPosition = s.stats.Waitlisted + 1
+				}
+				attendee.Status = a.Status
+				s.stats.Add(a.Status, 1)
+
+				if err := tx.Create(col.Doc(a.ID), &a); err != nil {
+					return err
+				}
+				if err := tx.Create(refs[i], emailIndex{AttendeeID: a.ID}); err != nil {
+					return err
+				}
+			}
+			return c.writeSeats(ctx, tx, s, nil)
+		})
+		if err != nil {
+			return done, err
+		}
+		done += len(batch)
+	}
+	return done, nil
+}
+
+func (c *Client) ImportSpeakers(ctx context.Context, speakers []*models.Speaker) (int, error) {
+	col := c.GetCollection(ctx, "speakers")
+	return c.importBatches(ctx, len(speakers), func(batch *firestore.WriteBatch, i int) {
+		batch.Create(col.Doc(speakers[i].ID), speakers[i])
+	})
+}
+
+// ImportSessions writes sessions in plain batches, which cannot read, so
+// speakers and rooms are not checked again: the caller checks them.
+func (c *Client) ImportSessions(ctx context.Context, sessions []*models.Session) (int, error) {
+	col := c.GetCollection(ctx, "sessions")
+	return c.importBatches(ctx, len(sessions), func(batch *firestore.WriteBatch, i int) {
+		sessions[i].SyncSpeakers()
+		batch.Create(col.Doc(sessions[i].ID), sessions[i])
+	})
+}
+
+func (c *Client) IDsInUse(ctx context.Context, collection string, ids []string) (map[string]bool, error) {
+	if collection != "speakers" && collection != "sessions" {
+		return nil, fmt.Errorf("firestore: cannot look up IDs of %s", collection)
+	}
+	out := map[string]bool{}
+	if len(ids) == 0 {
+		return out, nil
+	}
+	refs := make([]*firestore.DocumentRef, len(ids))
+	for i, id := range ids {
+		refs[i] = c.GetCollection(ctx, collection).Doc(id)
+	}
+	snaps, err := c.GetAll(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+	for _, snap := range snaps {
+		out[snap.Ref.ID] = snap.Exists()
+	}
+	return out, nil
+}
+
+// importBatches commits the n documents of an import in batches of
+// maxBatchWrites, calling add to queue document i, and returns how many
+// documents were committed.
+func (c *Client) importBatches(ctx context.Context, n int, add func(batch *firestore.WriteBatch, i int)) (int, error) {
+	done := 0
+	for done < n {
+		to := min(done+maxBatchWrites, n)
+		batch := c.Batch()
+		for i := done; i < to; i++ {
+			add(batch, i)
+		}
+		if _, err := batch.Commit(ctx); err != nil {
+			return done, err
+		}
+		done = to
+	}
+	return done, nil
+}
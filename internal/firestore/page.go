@@ -0,0 +1,122 @@
+package firestore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// pageDocuments runs a Firestore query ordered by the OrderBy field and then
+// the document ID, resuming from the page token with StartAfter so no
+// documents are skipped over server-side. Combining filters with an ordering
+// on another field needs a composite index.
+func pageDocuments[T any](ctx context.Context, col *firestore.CollectionRef, opts store.ListOptions, fields map[string]store.FieldKind, setID func(*T, string)) (*store.Page[T], error) {
+	cursor, err := store.DecodeCursor(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	q := col.Query
+	names := make([]string, 0, len(opts.Filters))
+	for name := range opts.Filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if kind, ok := fields[name]; !ok || kind != store.StringField {
+			return nil, fmt.Errorf("cannot filter %s by %q", col.ID, name)
+		}
+		q = q.Where(name, "==", opts.Filters[name])
+	}
+
+	dir := firestore.Asc
+	if opts.Descending {
+		dir = firestore.Desc
+	}
+	kind, ordered := fields[opts.OrderBy]
+	if opts.OrderBy != "" {
+		if !ordered {
+			return nil, fmt.Errorf("cannot order %s by %q", col.ID, opts.OrderBy)
+		}
+		q = q.OrderBy(opts.OrderBy, dir)
+	}
+	q = q.OrderBy(firestore.DocumentID, dir)
+
+	if cursor != nil {
+		if opts.OrderBy == "" {
+			q = q.StartAfter(cursor.ID)
+		} else {
+			var value interface{} = cursor.Value
+			if kind == store.TimeField {
+				t, err := store.ParseTime(cursor.Value)
+				if err != nil {
+					return nil, store.ErrInvalidPageToken
+				}
+				value = t
+			}
+			q = q.StartAfter(value, cursor.ID)
+		}
+	}
+
+	// Fetch one extra document to learn whether another page follows.
+	iter := q.Limit(opts.Limit + 1).Documents(ctx)
+	defer iter.Stop()
+
+	out := &store.Page[T]{Items: []*T{}}
+	var last *firestore.DocumentSnapshot
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(out.Items) == opts.Limit {
+			next := store.Cursor{OrderBy: opts.OrderBy, Descending: opts.Descending, ID: last.Ref.ID}
+			if opts.OrderBy != "" {
+				value, err := last.DataAt(opts.OrderBy)
+				if err != nil {
+					return nil, err
+				}
+				switch v := value.(type) {
+				case time.Time:
+					next.Value = store.FormatTime(v)
+				case string:
+					next.Value = v
+				}
+			}
+			out.NextPageToken = store.EncodeCursor(next)
+			break
+		}
+
+		v := new(T)
+		if err := doc.DataTo(v); err != nil {
+			return nil, err
+		}
+		setID(v, doc.Ref.ID)
+		out.Items = append(out.Items, v)
+		last = doc
+	}
+	return out, nil
+}
+
+func (c *Client) PageAttendees(ctx context.Context, opts store.ListOptions) (*store.Page[models.Attendee], error) {
+	return pageDocuments(ctx, c.GetCollection(ctx, "attendees"), opts, store.AttendeeFields, func(a *models.Attendee, id string) { a.ID = id })
+}
+
+func (c *Client) PageSpeakers(ctx context.Context, opts store.ListOptions) (*store.Page[models.Speaker], error) {
+	return pageDocuments(ctx, c.GetCollection(ctx, "speakers"), opts, store.SpeakerFields, func(s *models.Speaker, id string) { s.ID = id })
+}
+
+func (c *Client) PageSessions(ctx context.Context, opts store.ListOptions) (*store.Page[models.Session], error) {
+	return pageDocuments(ctx, c.GetCollection(ctx, "sessions"), opts, store.SessionFields, func(s *models.Session, id string) { s.ID = id })
+}
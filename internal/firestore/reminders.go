@@ -0,0 +1,61 @@
+package firestore
+
+import (
+	"context"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+
+	"cloud.google.com/go/firestore"
+)
+
+var _ store.ReminderStore = (*Client)(nil)
+
+const remindersCollection = "reminders"
+
+func (c *Client) ListReminders(ctx context.Context) ([]*models.Reminder, error) {
+	return listDocuments(ctx, c.GetCollection(ctx, remindersCollection), func(r *models.Reminder, id string) { r.ID = id })
+}
+
+func (c *Client) GetReminder(ctx context.Context, id string) (*models.Reminder, error) {
+	snap, err := c.GetCollection(ctx, remindersCollection).Doc(id).Get(ctx)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	var r models.Reminder
+	if err := snap.DataTo(&r); err != nil {
+		return nil, err
+	}
+	r.ID = id
+	return &r, nil
+}
+
+func (c *Client) UpdateReminder(ctx context.Context, id string, update func(*models.Reminder) error) (*models.Reminder, error) {
+	ref := c.GetCollection(ctx, remindersCollection).Doc(id)
+	var r *models.Reminder
+	err := c.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		r = &models.Reminder{}
+		snap, err := tx.Get(ref)
+		if err != nil && !isNotFound(err) {
+			return err
+		}
+		if err == nil {
+			if err := snap.DataTo(r); err != nil {
+				return err
+			}
+		}
+		r.ID = id
+		if err := update(r); err != nil {
+			return err
+		}
+		return tx.Set(ref, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
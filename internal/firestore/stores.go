@@ -0,0 +1,286 @@
+package firestore
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	_ store.AttendeeStore = (*Client)(nil)
+	_ store.SpeakerStore  = (*Client)(nil)
+	_ store.SessionStore  = (*Client)(nil)
+)
+
+// listDocuments decodes every document in a collection into a T, passing the
+// document ID to setID since IDs are not stored as fields.
+func listDocuments[T any](ctx context.Context, col *firestore.CollectionRef, setID func(*T, string)) ([]*T, error) {
+	var out []*T
+	iter := col.Documents(ctx)
+	defer iter.Stop()
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		v := new(T)
+		if err := doc.DataTo(v); err != nil {
+			return nil, err
+		}
+		setID(v, doc.Ref.ID)
+		out = append(out, v)
+	}
+
+	return out, nil
+}
+
+func (c *Client) addDocument(ctx context.Context, name string, data interface{}) (string, error) {
+	docRef, _, err := c.GetCollection(ctx, name).Add(ctx, data)
+	if err != nil {
+		return "", err
+	}
+	return docRef.ID, nil
+}
+
+// replaceDocument overwrites an existing document, returning store.ErrNotFound
+// instead of creating it when the ID is unknown.
+func (c *Client) replaceDocument(ctx context.Context, name, id string, data interface{}) error {
+	ref := c.GetCollection(ctx, name).Doc(id)
+	return c.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if _, err := tx.Get(ref); err != nil {
+			if isNotFound(err) {
+				return store.ErrNotFound
+			}
+			return err
+		}
+		return tx.Set(ref, data)
+	})
+}
+
+func isNotFound(err error) bool {
+	return status.Code(err) == codes.NotFound
+}
+
+func (c *Client) deleteDocument(ctx context.Context, name, id string) error {
+	_, err := c.GetCollection(ctx, name).Doc(id).Delete(ctx)
+	return err
+}
+
+// Speakers
+
+func (c *Client) ListSpeakers(ctx context.Context) ([]*models.Speaker, error) {
+	return listDocuments(ctx, c.GetCollection(ctx, "speakers"), func(s *models.Speaker, id string) { s.ID = id })
+}
+
+// GetSpeakers reads every speaker in a single GetAll round trip.
+func (c *Client) GetSpeakers(ctx context.Context, ids []string) (map[string]*models.Speaker, error) {
+	out := make(map[string]*models.Speaker, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	refs := make([]*firestore.DocumentRef, len(ids))
+	for i, id := range ids {
+		refs[i] = c.GetCollection(ctx, "speakers").Doc(id)
+	}
+	snaps, err := c.GetAll(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+	for _, snap := range snaps {
+		if !snap.Exists() {
+			continue
+		}
+		sp := new(models.Speaker)
+		if err := snap.DataTo(sp); err != nil {
+			return nil, err
+		}
+		sp.ID = snap.Ref.ID
+		out[sp.ID] = sp
+	}
+	return out, nil
+}
+
+func (c *Client) CreateSpeaker(ctx context.Context, speaker *models.Speaker) error {
+	id, err := c.addDocument(ctx, "speakers", speaker)
+	if err != nil {
+		return err
+	}
+	speaker.ID = id
+	return nil
+}
+
+func (c *Client) UpdateSpeaker(ctx context.Context, speaker *models.Speaker) error {
+	return c.replaceDocument(ctx, "speakers", speaker.ID, speaker)
+}
+
+// DeleteSpeaker finds the sessions naming the speaker inside the
+// transaction that deletes it, so a session saved concurrently cannot end up
+// pointing at a deleted speaker. Sessions stored before speakerIds existed
+// are matched on speakerId.
+func (c *Client) DeleteSpeaker(ctx context.Context, id string, cascade bool) ([]string, error) {
+	sessions := c.GetCollection(ctx, "sessions")
+	var sessionIDs []string
+	err := c.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		sessionIDs = nil
+		byID := map[string]*models.Session{}
+		for _, q := range []firestore.Query{
+			sessions.Where("speakerIds", "array-contains", id),
+			sessions.Where("speakerId", "==", id),
+		} {
+			docs, err := tx.Documents(q).GetAll()
+			if err != nil {
+				return err
+			}
+			for _, doc := range docs {
+				if _, ok := byID[doc.Ref.ID]; ok {
+					continue
+				}
+				se := new(models.Session)
+				if err := doc.DataTo(se); err != nil {
+					return err
+				}
+				se.ID = doc.Ref.ID
+				byID[se.ID] = se
+				sessionIDs = append(sessionIDs, se.ID)
+			}
+		}
+		sort.Strings(sessionIDs)
+		if len(sessionIDs) > 0 && !cascade {
+			return &store.SpeakerInUseError{SessionIDs: sessionIDs}
+		}
+
+		for _, sid := range sessionIDs {
+			se := byID[sid]
+			se.RemoveSpeaker(id)
+			if err := tx.Set(sessions.Doc(sid), se); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(c.GetCollection(ctx, "speakers").Doc(id))
+	})
+	if err != nil {
+		var inUse *store.SpeakerInUseError
+		if errors.As(err, &inUse) {
+			return sessionIDs, err
+		}
+		return nil, err
+	}
+	return sessionIDs, nil
+}
+
+// missingSpeakers returns the IDs of the session's speakers that have no
+// document. It reads inside tx, so it must come before any write.
+func (c *Client) missingSpeakers(ctx context.Context, tx *firestore.Transaction, session *models.Session) ([]string, error) {
+	if len(session.SpeakerIDs) == 0 {
+		return nil, nil
+	}
+	refs := make([]*firestore.DocumentRef, len(session.SpeakerIDs))
+	for i, id := range session.SpeakerIDs {
+		refs[i] = c.GetCollection(ctx, "speakers").Doc(id)
+	}
+	snaps, err := tx.GetAll(refs)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for i, snap := range snaps {
+		if !snap.Exists() {
+			missing = append(missing, session.SpeakerIDs[i])
+		}
+	}
+	return missing, nil
+}
+
+// checkRoom returns a *store.RoomConflictError if another session is booked
+// into the session's room at the same time. Rooms match case-insensitively,
+// which a Firestore equality filter cannot do, so every session is read; the
+// agenda of one workshop is small.
+func (c *Client) checkRoom(ctx context.Context, tx *firestore.Transaction, session *models.Session) error {
+	if session.Room == "" {
+		return nil
+	}
+	docs, err := tx.Documents(c.GetCollection(ctx, "sessions")).GetAll()
+	if err != nil {
+		return err
+	}
+	others := make([]*models.Session, 0, len(docs))
+	for _, doc := range docs {
+		other := new(models.Session)
+		if err := doc.DataTo(other); err != nil {
+			return err
+		}
+		other.ID = doc.Ref.ID
+		others = append(others, other)
+	}
+	return store.CheckRoom(session, others)
+}
+
+// Sessions
+
+func (c *Client) ListSessions(ctx context.Context) ([]*models.Session, error) {
+	return listDocuments(ctx, c.GetCollection(ctx, "sessions"), func(s *models.Session, id string) { s.ID = id })
+}
+
+func (c *Client) CreateSession(ctx context.Context, session *models.Session) error {
+	session.SyncSpeakers()
+	ref := c.GetCollection(ctx, "sessions").NewDoc()
+	err := c.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		missing, err := c.missingSpeakers(ctx, tx, session)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return &store.MissingSpeakersError{IDs: missing}
+		}
+		if err := c.checkRoom(ctx, tx, session); err != nil {
+			return err
+		}
+		return tx.Create(ref, session)
+	})
+	if err != nil {
+		return err
+	}
+	session.ID = ref.ID
+	return nil
+}
+
+func (c *Client) UpdateSession(ctx context.Context, session *models.Session) error {
+	session.SyncSpeakers()
+	ref := c.GetCollection(ctx, "sessions").Doc(session.ID)
+	return c.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if _, err := tx.Get(ref); err != nil {
+			if isNotFound(err) {
+				return store.ErrNotFound
+			}
+			return err
+		}
+		missing, err := c.missingSpeakers(ctx, tx, session)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return &store.MissingSpeakersError{IDs: missing}
+		}
+		if err := c.checkRoom(ctx, tx, session); err != nil {
+			return err
+		}
+		return tx.Set(ref, session)
+	})
+}
+
+func (c *Client) DeleteSession(ctx context.Context, id string) error {
+	return c.deleteDocument(ctx, "sessions", id)
+}
@@ -0,0 +1,204 @@
+package firestore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ store.WorkshopStore = (*Client)(nil)
+
+// workshopsCollection holds a document per workshop. The data of each
+// workshop lives in subcollections of its document, named like the
+// top-level collections the default workshop keeps using.
+const workshopsCollection = "workshops"
+
+// workshopCollections are the subcollections DeleteWorkshop empties.
+var workshopCollections = []string{
+	"attendees", attendeeEmailsCollection, "speakers", "sessions",
+	enrollmentsCollection, remindersCollection, "settings", "stats",
+}
+
+func (c *Client) ListWorkshops(ctx context.Context) ([]*models.Workshop, error) {
+	return listDocuments(ctx, c.Collection(workshopsCollection), func(w *models.Workshop, id string) { w.ID = id })
+}
+
+func (c *Client) GetWorkshop(ctx context.Context, id string) (*models.Workshop, error) {
+	snap, err := c.Collection(workshopsCollection).Doc(id).Get(ctx)
+	if isNotFound(err) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var w models.Workshop
+	if err := snap.DataTo(&w); err != nil {
+		return nil, err
+	}
+	w.ID = id
+	return &w, nil
+}
+
+func (c *Client) CreateWorkshop(ctx context.Context, workshop *models.Workshop) error {
+	now := time.Now().UTC()
+	workshop.CreatedAt, workshop.UpdatedAt = now, now
+	_, err := c.Collection(workshopsCollection).Doc(workshop.ID).Create(ctx, workshop)
+	if status.Code(err) == codes.AlreadyExists {
+		return &store.DuplicateError{ExistingID: workshop.ID}
+	}
+	return err
+}
+
+// maxBatchWrites is the most writes Firestore accepts in one batch or
+// transaction.
+const maxBatchWrites = store.ImportBatchSize
+
+// CloneWorkshop checks the sessions against the speakers and each other,
+// then writes the workshop document, speakers and sessions in batches of up
+// to maxBatchWrites. The first batch creates the workshop document, so a
+// taken ID fails before anything is written; if a later batch fails the
+// partly written workshop is deleted again.
+func (c *Client) CloneWorkshop(ctx context.Context, workshop *models.Workshop, speakers []*models.Speaker, sessions []*models.Session) error {
+	known := make(map[string]bool, len(speakers))
+	for _, sp := range speakers {
+		known[sp.ID] = true
+	}
+	checked := make([]*models.Session, 0, len(sessions))
+	for _, se := range sessions {
+		se.SyncSpeakers()
+		var missing []string
+		for _, id := range se.SpeakerIDs {
+			if !known[id] {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > 0 {
+			return &store.MissingSpeakersError{IDs: missing}
+		}
+		if se.Room != "" {
+			if err := store.CheckRoom(se, checked); err != nil {
+				return err
+			}
+		}
+		checked = append(checked, se)
+	}
+
+	now := time.Now().UTC()
+	workshop.CreatedAt, workshop.UpdatedAt = now, now
+	batch, writes := c.Batch(), 1
+	batch.Create(c.Collection(workshopsCollection).Doc(workshop.ID), workshop)
+	first := true
+	flush := func() error {
+		_, err := batch.Commit(ctx)
+		if first && status.Code(err) == codes.AlreadyExists {
+			return &store.DuplicateError{ExistingID: workshop.ID}
+		}
+		if err != nil && !first {
+			if cleanupErr := c.DeleteWorkshop(ctx, workshop.ID); cleanupErr != nil {
+				return fmt.Errorf("%w (removing the partial workshop: %v)", err, cleanupErr)
+			}
+		}
+		first = false
+		batch, writes = c.Batch(), 0
+		return err
+	}
+	add := func(collection, id string, data interface{}) error {
+		if writes == maxBatchWrites {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		batch.Create(c.GetSubcollection(ctx, workshop.ID, collection).Doc(id), data)
+		writes++
+		return nil
+	}
+
+	for _, sp := range speakers {
+		if err := add("speakers", sp.ID, sp); err != nil {
+			return err
+		}
+	}
+	for _, se := range sessions {
+		if err := add("sessions", se.ID, se); err != nil {
+			return err
+		}
+	}
+	return flush()
+}
+
+func (c *Client) UpdateWorkshop(ctx context.Context, workshop *models.Workshop) error {
+	ref := c.Collection(workshopsCollection).Doc(workshop.ID)
+	return c.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if isNotFound(err) {
+			return store.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		var stored models.Workshop
+		if err := snap.DataTo(&stored); err != nil {
+			return err
+		}
+		workshop.CreatedAt, workshop.UpdatedAt = stored.CreatedAt, time.Now().UTC()
+		return tx.Set(ref, workshop)
+	})
+}
+
+// DeleteWorkshop empties the workshop's subcollections before deleting its
+// document, since Firestore keeps subcollections of a deleted document.
+func (c *Client) DeleteWorkshop(ctx context.Context, id string) error {
+	bw := c.BulkWriter(ctx)
+	var jobs []*firestore.BulkWriterJob
+	for _, name := range workshopCollections {
+		iter := c.GetSubcollection(ctx, id, name).DocumentRefs(ctx)
+		for {
+			ref, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				bw.End()
+				return err
+			}
+			job, err := bw.Delete(ref)
+			if err != nil {
+				bw.End()
+				return err
+			}
+			jobs = append(jobs, job)
+		}
+	}
+	bw.End()
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return err
+		}
+	}
+
+	_, err := c.Collection(workshopsCollection).Doc(id).Delete(ctx)
+	return err
+}
+
+// Scoped returns a client whose collections are those of the workshop.
+func (c *Client) Scoped(id string) store.Stores {
+	scoped := &Client{Client: c.Client, subcollectionID: c.subcollectionID, workshopID: id}
+	return store.Stores{
+		Attendees:   scoped,
+		Speakers:    scoped,
+		Sessions:    scoped,
+		Admins:      c,
+		Reminders:   scoped,
+		Enrollments: scoped,
+		Workshops:   c,
+		Imports:     scoped,
+	}
+}
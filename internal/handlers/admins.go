@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"appdirect-workshop/internal/admins"
+	"appdirect-workshop/internal/auth"
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+
+	"github.com/gorilla/mux"
+)
+
+// respondAdminError maps admin service errors to responses.
+func respondAdminError(w http.ResponseWriter, err error) {
+	var errs models.ValidationErrors
+	var dup *store.DuplicateError
+	switch {
+	case errors.As(err, &errs):
+		respondValidation(w, errs)
+	case errors.As(err, &dup):
+		respondError(w, http.StatusConflict, "An admin with this username already exists")
+	case errors.Is(err, store.ErrNotFound):
+		respondError(w, http.StatusNotFound, "Admin not found")
+	case errors.Is(err, admins.ErrLastOwner):
+		respondError(w, http.StatusConflict, "At least one enabled owner account is required")
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// AdminMe returns the caller's account, role and permissions so the
+// dashboard can hide controls the caller cannot use.
+func (h *Handlers) AdminMe(w http.ResponseWriter, r *http.Request) {
+	admin, ok := currentAdmin(r)
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"username":    admin.Username,
+		"role":        admin.Role,
+		"permissions": auth.Permissions(admin.Role),
+	})
+}
+
+// ListAdmins returns every admin account. Password hashes are never included.
+func (h *Handlers) ListAdmins(w http.ResponseWriter, r *http.Request) {
+	list, err := h.admins.List(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if list == nil {
+		list = []*models.Admin{}
+	}
+
+	respondJSON(w, http.StatusOK, list)
+}
+
+// CreateAdmin adds an admin account from {"username", "password", "role"}.
+func (h *Handlers) CreateAdmin(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username string      `json:"username"`
+		Password string      `json:"password"`
+		Role     models.Role `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	admin, err := h.admins.Create(r.Context(), strings.TrimSpace(req.Username), req.Password, req.Role)
+	if err != nil {
+		respondAdminError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, admin)
+}
+
+// DisableAdmin blocks an account from logging in and invalidates its tokens.
+// Admins cannot disable their own account so at least one stays usable.
+func (h *Handlers) DisableAdmin(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+	if claims, ok := auth.ClaimsFromContext(r.Context()); ok && claims.Subject == username {
+		respondError(w, http.StatusBadRequest, "You cannot disable your own account")
+		return
+	}
+	h.setDisabled(w, r.Context(), username, true)
+}
+
+// EnableAdmin re-enables a disabled account.
+func (h *Handlers) EnableAdmin(w http.ResponseWriter, r *http.Request) {
+	h.setDisabled(w, r.Context(), mux.Vars(r)["username"], false)
+}
+
+func (h *Handlers) setDisabled(w http.ResponseWriter, ctx context.Context, username string, disabled bool) {
+	admin, err := h.admins.SetDisabled(ctx, username, disabled)
+	if err != nil {
+		respondAdminError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, admin)
+}
+
+// SetAdminRole changes an account's role from {"role"}. Admins cannot change
+// their own role.
+func (h *Handlers) SetAdminRole(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+	if claims, ok := auth.ClaimsFromContext(r.Context()); ok && claims.Subject == username {
+		respondError(w, http.StatusBadRequest, "You cannot change your own role")
+		return
+	}
+
+	var req struct {
+		Role models.Role `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	admin, err := h.admins.SetRole(r.Context(), username, req.Role)
+	if err != nil {
+		respondAdminError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, admin)
+}
+
+// RotateAdminPassword sets a new password from {"password"}. Every admin may
+// change their own password; changing someone else's needs PermManageAdmins.
+// Tokens issued before the change, including the caller's own, stop working.
+func (h *Handlers) RotateAdminPassword(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+	if caller, ok := currentAdmin(r); !ok || (caller.Username != username && !auth.Allowed(caller.Role, auth.PermManageAdmins)) {
+		respondError(w, http.StatusForbidden, "Your role does not allow this action")
+		return
+	}
+
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	admin, err := h.admins.RotatePassword(r.Context(), username, req.Password)
+	if err != nil {
+		respondAdminError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, admin)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+// agenda is the schedule grouped by day in the event time zone, then by
+// track.
+type agenda struct {
+	Timezone string      `json:"timezone"`
+	Days     []agendaDay `json:"days"`
+}
+
+type agendaDay struct {
+	Date   string        `json:"date"`
+	Tracks []agendaTrack `json:"tracks"`
+}
+
+// agendaTrack lists the sessions of one track on one day by start time.
+// Sessions without a track are grouped under an empty name, listed last.
+type agendaTrack struct {
+	Track    string                 `json:"track"`
+	Sessions []*sessionWithSpeakers `json:"sessions"`
+}
+
+// syncSessions fills in the fields of sessions that are derived on read:
+// speakerIds for sessions stored with a single speakerId, and the date and
+// time of each session in the event time zone.
+func (h *Handlers) syncSessions(sessions ...*models.Session) {
+	for _, s := range sessions {
+		s.SyncSpeakers()
+		s.SyncSchedule(h.location)
+	}
+}
+
+// GetAgenda returns every scheduled session grouped by day and track, with
+// speakers and enrollment counts embedded.
+func (h *Handlers) GetAgenda(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	sessions, err := h.sessions.ListSessions(ctx)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.syncSessions(sessions...)
+	scheduled := sessions[:0]
+	for _, s := range sessions {
+		if !s.StartsAt.IsZero() {
+			scheduled = append(scheduled, s)
+		}
+	}
+	sortSessions(scheduled)
+	if err := h.withEnrollment(ctx, scheduled...); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	items, err := h.withSpeakers(ctx, scheduled)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	out := agenda{Timezone: h.location.String(), Days: []agendaDay{}}
+	for _, item := range items {
+		if n := len(out.Days); n == 0 || out.Days[n-1].Date != item.Date {
+			out.Days = append(out.Days, agendaDay{Date: item.Date})
+		}
+		out.Days[len(out.Days)-1].add(item)
+	}
+	for _, day := range out.Days {
+		sort.SliceStable(day.Tracks, func(i, j int) bool { return trackBefore(day.Tracks[i].Track, day.Tracks[j].Track) })
+	}
+
+	respondJSON(w, http.StatusOK, out)
+}
+
+// add appends a session to its track, keeping the sessions' order.
+func (d *agendaDay) add(item *sessionWithSpeakers) {
+	for i := range d.Tracks {
+		if d.Tracks[i].Track == item.Track {
+			d.Tracks[i].Sessions = append(d.Tracks[i].Sessions, item)
+			return
+		}
+	}
+	d.Tracks = append(d.Tracks, agendaTrack{Track: item.Track, Sessions: []*sessionWithSpeakers{item}})
+}
+
+// trackBefore orders tracks by name, with the unnamed track last.
+func trackBefore(a, b string) bool {
+	if a == "" || b == "" {
+		return b == "" && a != ""
+	}
+	return a < b
+}
+
+// MigrateSessions gives start and end times to sessions stored with only a
+// date and time, reading them in the event time zone. Sessions that already
+// have them are left alone, so it runs at every start. A session naming a
+// speaker deleted before deletions were checked loses that speaker.
+func (h *Handlers) MigrateSessions(ctx context.Context) (int, error) {
+	sessions, err := h.sessions.ListSessions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, s := range sessions {
+		if !s.StartsAt.IsZero() {
+			continue
+		}
+		s.SyncSpeakers()
+		s.SyncSchedule(h.location)
+		if s.StartsAt.IsZero() {
+			log.Printf("Session %s has no valid date and time; leaving it unscheduled", s.ID)
+			continue
+		}
+
+		err := h.sessions.UpdateSession(ctx, s)
+		var missing *store.MissingSpeakersError
+		if errors.As(err, &missing) {
+			for _, id := range missing.IDs {
+				s.RemoveSpeaker(id)
+			}
+			err = h.sessions.UpdateSession(ctx, s)
+		}
+		if err != nil {
+			return migrated, fmt.Errorf("session %s: %w", s.ID, err)
+		}
+		migrated++
+	}
+	return migrated, nil
+}
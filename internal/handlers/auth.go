@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"appdirect-workshop/internal/auth"
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+// defaultTokenTTL is how long an admin session token stays valid unless it is
+// refreshed.
+const defaultTokenTTL = time.Hour
+
+// tokenSecret returns ADMIN_TOKEN_SECRET. Without it a random key is
+// generated, which means tokens do not survive a restart and are not shared
+// between instances.
+func tokenSecret() []byte {
+	secret := []byte(os.Getenv("ADMIN_TOKEN_SECRET"))
+	if len(secret) == 0 {
+		log.Println("⚠ ADMIN_TOKEN_SECRET not set; generating a random signing key")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatalf("Failed to generate token signing key: %v", err)
+		}
+	}
+	return secret
+}
+
+// newTokenManager configures admin session tokens signed with secret and
+// valid for ADMIN_TOKEN_TTL.
+func newTokenManager(secret []byte) *auth.Manager {
+	ttl := defaultTokenTTL
+	if v := os.Getenv("ADMIN_TOKEN_TTL"); v != "" {
+		parsed, err := time.ParseDuration(v)
+		if err != nil || parsed <= 0 {
+			log.Printf("Ignoring invalid ADMIN_TOKEN_TTL %q", v)
+		} else {
+			ttl = parsed
+		}
+	}
+
+	return auth.NewManager(secret, ttl, auth.NewMemoryRevocationList())
+}
+
+// linkSecret keys attendee management links and tickets with
+// ATTENDEE_LINK_SECRET, falling back to the admin token secret. Links must
+// outlive restarts, so a deployment relying on a generated secret breaks
+// every link and ticket it sent.
+func linkSecret(fallback []byte) []byte {
+	if secret := os.Getenv("ATTENDEE_LINK_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	return fallback
+}
+
+// Login throttling defaults. Each client IP gets a few free attempts before
+// exponentially growing lockouts; the global limiter slows down attacks
+// spread across many addresses while capping how long real admins wait.
+var (
+	defaultIPLoginPolicy = auth.Policy{
+		FreeAttempts: 5,
+		BaseLockout:  30 * time.Second,
+		MaxLockout:   15 * time.Minute,
+		Window:       15 * time.Minute,
+	}
+	defaultGlobalLoginPolicy = auth.Policy{
+		FreeAttempts: 50,
+		BaseLockout:  time.Second,
+		MaxLockout:   time.Minute,
+		Window:       5 * time.Minute,
+	}
+)
+
+// globalLoginKey is the limiter key shared by every login attempt.
+const globalLoginKey = "global"
+
+// newLoginLimiters configures in-process login throttling, reading the free
+// attempt counts from LOGIN_MAX_ATTEMPTS and LOGIN_GLOBAL_MAX_ATTEMPTS.
+func newLoginLimiters() (perIP, global auth.Limiter) {
+	ipPolicy, globalPolicy := defaultIPLoginPolicy, defaultGlobalLoginPolicy
+	ipPolicy.FreeAttempts = envInt("LOGIN_MAX_ATTEMPTS", ipPolicy.FreeAttempts)
+	globalPolicy.FreeAttempts = envInt("LOGIN_GLOBAL_MAX_ATTEMPTS", globalPolicy.FreeAttempts)
+	return auth.NewMemoryLimiter(ipPolicy), auth.NewMemoryLimiter(globalPolicy)
+}
+
+func envInt(name string, fallback int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		log.Printf("Ignoring invalid %s %q", name, v)
+		return fallback
+	}
+	return n
+}
+
+// trustProxyHeaders reports whether X-Forwarded-For can be trusted to carry
+// the client address: on Cloud Run, or when TRUST_PROXY is "true".
+func trustProxyHeaders() bool {
+	return os.Getenv("TRUST_PROXY") == "true" || os.Getenv("K_SERVICE") != ""
+}
+
+// clientIP returns the caller's address. Behind a trusted proxy it is the
+// last X-Forwarded-For entry, the one the proxy appended itself; earlier
+// entries are client-controlled.
+func (h *Handlers) clientIP(r *http.Request) string {
+	if h.trustProxy {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			parts := strings.Split(fwd, ",")
+			if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// loginAttempt counts a login attempt against both limiters before the
+// password is checked, and returns how long the caller must wait instead
+// when either has locked it out. A blocked attempt is not counted.
+func (h *Handlers) loginAttempt(ctx context.Context, ip string) (time.Duration, error) {
+	if wait, err := h.loginPerIP.Attempt(ctx, ip); err != nil || wait > 0 {
+		return wait, err
+	}
+	wait, err := h.loginGlobal.Attempt(ctx, globalLoginKey)
+	if err == nil && wait > 0 {
+		err = h.loginPerIP.Forgive(ctx, ip)
+	}
+	return wait, err
+}
+
+// forgiveLogin takes back the attempt counted for a successful login. Other
+// failures from the same address stay counted, so signing in to one account
+// does not clear guesses at another.
+func (h *Handlers) forgiveLogin(ctx context.Context, ip string) error {
+	if err := h.loginPerIP.Forgive(ctx, ip); err != nil {
+		return err
+	}
+	return h.loginGlobal.Forgive(ctx, globalLoginKey)
+}
+
+// respondTooManyAttempts writes a 429 with Retry-After rounded up to whole
+// seconds.
+func respondTooManyAttempts(w http.ResponseWriter, wait time.Duration) {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	respondError(w, http.StatusTooManyRequests, "Too many login attempts. Try again later.")
+}
+
+// respondToken issues a session token for admin and writes it with message.
+func (h *Handlers) respondToken(w http.ResponseWriter, message string, admin *models.Admin) {
+	token, claims, err := h.tokens.Issue(admin.Username, tokenVersion(admin))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to issue token")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":   message,
+		"token":     token,
+		"expiresAt": claims.ExpiresAt.Time,
+	})
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+// RequireAdmin is middleware that rejects requests without a valid admin
+// session token with 401 and stores the verified claims and the caller's
+// account in the context. Tokens stop working once their account is disabled,
+// removed or has its password rotated.
+func (h *Handlers) RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			respondError(w, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+
+		claims, err := h.tokens.Verify(token)
+		var admin *models.Admin
+		if err == nil {
+			admin, err = h.checkAccount(r.Context(), claims)
+		}
+		if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, store.ErrNotFound) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin", error="invalid_token"`)
+			respondError(w, http.StatusUnauthorized, "Invalid or expired token")
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx := auth.WithClaims(r.Context(), claims)
+		ctx = context.WithValue(ctx, adminKey{}, admin)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+type adminKey struct{}
+
+// currentAdmin returns the account stored by RequireAdmin.
+func currentAdmin(r *http.Request) (*models.Admin, bool) {
+	admin, ok := r.Context().Value(adminKey{}).(*models.Admin)
+	return admin, ok
+}
+
+// Require returns middleware that rejects callers whose role does not grant
+// perm with 403. It must run after RequireAdmin.
+func (h *Handlers) Require(perm auth.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			admin, ok := currentAdmin(r)
+			if !ok {
+				respondError(w, http.StatusUnauthorized, "Authentication required")
+				return
+			}
+			if !auth.Allowed(admin.Role, perm) {
+				respondError(w, http.StatusForbidden, "Your role does not allow this action")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// tokenVersion is the version of admin's tokens, which changes with every
+// password change. Microseconds survive every backend's timestamps, and
+// unlike the token's issue time they tell apart changes within a second.
+func tokenVersion(admin *models.Admin) int64 {
+	return admin.PasswordChangedAt.UnixMicro()
+}
+
+// checkAccount returns the token's account, rejecting tokens whose account
+// is disabled or whose password changed since the token was issued.
+func (h *Handlers) checkAccount(ctx context.Context, claims *auth.Claims) (*models.Admin, error) {
+	admin, err := h.admins.Get(ctx, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if admin.Disabled || claims.Version != tokenVersion(admin) {
+		return nil, auth.ErrInvalidToken
+	}
+	return admin, nil
+}
+
+// AdminLogout revokes the caller's token.
+func (h *Handlers) AdminLogout(w http.ResponseWriter, r *http.Request) {
+	if claims, ok := auth.ClaimsFromContext(r.Context()); ok {
+		h.tokens.Revoke(claims)
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Logged out"})
+}
+
+// AdminRefresh exchanges the caller's token for a new one with a fresh
+// expiry and revokes the old token.
+func (h *Handlers) AdminRefresh(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	admin, known := currentAdmin(r)
+	if !ok || !known {
+		respondError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+
+	token, refreshed, err := h.tokens.Issue(admin.Username, tokenVersion(admin))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to issue token")
+		return
+	}
+	h.tokens.Revoke(claims)
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":   "Token refreshed",
+		"token":     token,
+		"expiresAt": refreshed.ExpiresAt.Time,
+	})
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ttlCache holds a single value loaded on demand and reused until it is ttl
+// old. Concurrent callers wait for one load rather than each hitting the
+// backend, which keeps hot public endpoints cheap under load.
+type ttlCache[T any] struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	value   T
+	expires time.Time
+}
+
+func newTTLCache[T any](ttl time.Duration) *ttlCache[T] {
+	return &ttlCache[T]{ttl: ttl, now: time.Now}
+}
+
+// get returns the cached value, calling load when it is missing or stale.
+// Errors are not cached.
+func (c *ttlCache[T]) get(ctx context.Context, load func(context.Context) (T, error)) (T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.now().Before(c.expires) {
+		return c.value, nil
+	}
+
+	v, err := load(ctx)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	c.value = v
+	c.expires = c.now().Add(c.ttl)
+	return v, nil
+}
+
+// invalidate forces the next get to load a fresh value, so writes made
+// through this instance show up immediately.
+func (c *ttlCache[T]) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expires = time.Time{}
+}
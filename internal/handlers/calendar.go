@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"appdirect-workshop/internal/ical"
+	"appdirect-workshop/internal/mailer"
+	"appdirect-workshop/internal/models"
+)
+
+// sessionUID identifies a session in calendar files. It depends only on the
+// session ID, so rescheduling a session moves the entry attendees already
+// imported instead of adding a second one.
+func sessionUID(id string) string {
+	return id + "@appdirect-workshop"
+}
+
+// calendar builds an iCalendar file from synced sessions, leaving out those
+// without a start time.
+func (h *Handlers) calendar(ctx context.Context, name string, sessions []*models.Session) (*ical.Calendar, error) {
+	scheduled := make([]*models.Session, 0, len(sessions))
+	for _, s := range sessions {
+		if !s.StartsAt.IsZero() {
+			scheduled = append(scheduled, s)
+		}
+	}
+	items, err := h.withSpeakers(ctx, scheduled)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &ical.Calendar{Name: name}
+	for _, item := range items {
+		description := item.Description
+		if len(item.Speakers) > 0 {
+			names := make([]string, len(item.Speakers))
+			for i, sp := range item.Speakers {
+				names[i] = sp.Name
+			}
+			description = strings.TrimSpace(description + "\n\nSpeakers: " + strings.Join(names, ", "))
+		}
+		event := ical.Event{
+			UID:         sessionUID(item.ID),
+			Start:       item.StartsAt,
+			End:         item.EndsAt,
+			Summary:     item.Title,
+			Description: description,
+			Location:    item.Room,
+		}
+		if item.Track != "" {
+			event.Categories = []string{item.Track}
+		}
+		cal.Events = append(cal.Events, event)
+	}
+	return cal, nil
+}
+
+// agendaCalendar returns every scheduled session as a calendar.
+func (h *Handlers) agendaCalendar(ctx context.Context) (*ical.Calendar, error) {
+	sessions, err := h.sessions.ListSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.syncSessions(sessions...)
+	sortSessions(sessions)
+	return h.calendar(ctx, h.eventName, sessions)
+}
+
+func respondCalendar(w http.ResponseWriter, filename string, cal *ical.Calendar) {
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Header().Set("Content-Disposition", `inline; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	w.Write(cal.Encode(time.Now()))
+}
+
+// GetAgendaICS serves the whole agenda as an iCalendar file that calendar
+// apps can import or subscribe to.
+func (h *Handlers) GetAgendaICS(w http.ResponseWriter, r *http.Request) {
+	cal, err := h.agendaCalendar(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondCalendar(w, "agenda.ics", cal)
+}
+
+// GetRegistrationSchedule serves the sessions a registrant is enrolled in as
+// an iCalendar file.
+func (h *Handlers) GetRegistrationSchedule(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.registrationFromToken(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	sessions, err := h.schedule(ctx, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	cal, err := h.calendar(ctx, h.eventName, sessions)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondCalendar(w, "schedule.ics", cal)
+}
+
+// eventAttachment returns the agenda as an email attachment, or nil when no
+// session is scheduled yet. A failure is only logged so the email still
+// goes out.
+func (h *Handlers) eventAttachment(ctx context.Context) []mailer.Attachment {
+	cal, err := h.agendaCalendar(ctx)
+	if err != nil {
+		log.Printf("mail: building event calendar: %v", err)
+		return nil
+	}
+	if len(cal.Events) == 0 {
+		return nil
+	}
+	return []mailer.Attachment{{
+		Filename:    "event.ics",
+		ContentType: ical.ContentType + "; method=PUBLISH",
+		Data:        cal.Encode(time.Now()),
+	}}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+// duplicateGroup is a set of registrations sharing a normalized email. Keep
+// is the registration that survives a merge; the rest are removed.
+type duplicateGroup struct {
+	Email      string             `json:"email"`
+	Keep       *models.Attendee   `json:"keep"`
+	Duplicates []*models.Attendee `json:"duplicates"`
+}
+
+// findDuplicates groups attendees by normalized email, keeping the earliest
+// registration of each group. Cancelled registrations are not duplicates,
+// since the address is free to register again. Groups are ordered by email.
+func findDuplicates(attendees []*models.Attendee) []duplicateGroup {
+	byEmail := map[string][]*models.Attendee{}
+	for _, a := range attendees {
+		if a.Status == models.StatusCancelled {
+			continue
+		}
+		email := models.NormalizeEmail(a.Email)
+		byEmail[email] = append(byEmail[email], a)
+	}
+
+	var groups []duplicateGroup
+	for email, members := range byEmail {
+		if email == "" || len(members) < 2 {
+			continue
+		}
+		sort.SliceStable(members, func(i, j int) bool {
+			return members[i].CreatedAt.Before(members[j].CreatedAt)
+		})
+		groups = append(groups, duplicateGroup{Email: email, Keep: members[0], Duplicates: members[1:]})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Email < groups[j].Email })
+	return groups
+}
+
+// merged returns the keeper with any blank fields filled from its duplicates,
+// preferring the most recent value. The keeper holds a seat if any member
+// did, so merging never demotes a registered attendee to the waitlist.
+func (g duplicateGroup) merged() *models.Attendee {
+	keep := *g.Keep
+	for i := len(g.Duplicates) - 1; i >= 0; i-- {
+		d := g.Duplicates[i]
+		if d.HoldsSeat() && !keep.HoldsSeat() {
+			keep.Status = d.Status
+			keep.PromotedAt = d.PromotedAt
+		}
+		if keep.Name == "" {
+			keep.Name = d.Name
+		}
+		if keep.Designation == "" {
+			keep.Designation = d.Designation
+		}
+	}
+	return &keep
+}
+
+// GetDuplicateAttendees lists registrations that share a normalized email.
+func (h *Handlers) GetDuplicateAttendees(w http.ResponseWriter, r *http.Request) {
+	attendees, err := h.attendees.ListAttendees(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	groups := findDuplicates(attendees)
+	if groups == nil {
+		groups = []duplicateGroup{}
+	}
+	respondJSON(w, http.StatusOK, groups)
+}
+
+// MergeDuplicateAttendees collapses every duplicate group into its earliest
+// registration. An optional {"email": "..."} body limits the merge to one
+// group.
+func (h *Handlers) MergeDuplicateAttendees(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req struct {
+		Email string `json:"email"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	attendees, err := h.attendees.ListAttendees(ctx)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	filter := models.NormalizeEmail(req.Email)
+	merged := []duplicateGroup{}
+	promoted := []*models.Attendee{}
+	removed := 0
+	for _, g := range findDuplicates(attendees) {
+		if filter != "" && g.Email != filter {
+			continue
+		}
+
+		ids := make([]string, len(g.Duplicates))
+		for i, d := range g.Duplicates {
+			ids[i] = d.ID
+		}
+		keep := g.merged()
+		p, err := h.attendees.MergeAttendees(ctx, keep, ids)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		h.attendeeCount.invalidate()
+		for _, id := range ids {
+			h.releaseEnrollments(ctx, store.EnrollmentFilter{AttendeeID: id})
+		}
+		h.sendPromotions(r, p)
+		promoted = append(promoted, p...)
+
+		g.Keep = keep
+		merged = append(merged, g)
+		removed += len(ids)
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"merged":   len(merged),
+		"removed":  removed,
+		"groups":   merged,
+		"promoted": promoted,
+	})
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+
+	"github.com/gorilla/mux"
+)
+
+// withEnrollment sets Enrolled on each session from the stored counts.
+func (h *Handlers) withEnrollment(ctx context.Context, sessions ...*models.Session) error {
+	counts, err := h.enrollments.EnrollmentCounts(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range sessions {
+		s.Enrolled = counts[s.ID]
+	}
+	return nil
+}
+
+// releaseEnrollments frees the session places held by a cancelled or merged
+// registration, or by a deleted session. A failure is only logged: the
+// change that triggered it has already been saved.
+func (h *Handlers) releaseEnrollments(ctx context.Context, filter store.EnrollmentFilter) {
+	if err := h.enrollments.DeleteEnrollments(ctx, filter); err != nil {
+		log.Printf("Releasing enrollments %+v: %v", filter, err)
+	}
+}
+
+// schedule returns the sessions an attendee is enrolled in, by start time.
+func (h *Handlers) schedule(ctx context.Context, attendeeID string) ([]*models.Session, error) {
+	enrollments, err := h.enrollments.ListEnrollments(ctx, store.EnrollmentFilter{AttendeeID: attendeeID})
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := h.sessions.ListSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	enrolled := map[string]bool{}
+	for _, e := range enrollments {
+		enrolled[e.SessionID] = true
+	}
+	out := []*models.Session{}
+	for _, s := range sessions {
+		if enrolled[s.ID] {
+			out = append(out, s)
+		}
+	}
+	h.syncSessions(out...)
+	sortSessions(out)
+	return out, h.withEnrollment(ctx, out...)
+}
+
+// sortSessions orders synced sessions by start time, then room and title.
+func sortSessions(sessions []*models.Session) {
+	sort.SliceStable(sessions, func(i, j int) bool {
+		a, b := sessions[i], sessions[j]
+		switch {
+		case !a.StartsAt.Equal(b.StartsAt):
+			return a.StartsAt.Before(b.StartsAt)
+		case a.Room != b.Room:
+			return a.Room < b.Room
+		}
+		return a.Title < b.Title
+	})
+}
+
+func respondEnrollmentError(w http.ResponseWriter, err error) {
+	var conflict *store.ConflictError
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		respondError(w, http.StatusNotFound, "Session not found")
+	case errors.Is(err, store.ErrNoSeat):
+		respondError(w, http.StatusConflict, "Only registered attendees can enroll in sessions")
+	case errors.Is(err, store.ErrSessionFull):
+		respondError(w, http.StatusConflict, "This session is full")
+	case errors.As(err, &conflict):
+		respondJSON(w, http.StatusConflict, map[string]string{
+			"error":     "This session overlaps another session on the schedule",
+			"sessionId": conflict.SessionID,
+		})
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// GetRegistrationSessions lists the sessions a registrant is enrolled in.
+func (h *Handlers) GetRegistrationSessions(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.registrationFromToken(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.schedule(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, sessions)
+}
+
+// EnrollRegistration enrolls a registrant in a session. Enrolling twice is
+// not an error.
+func (h *Handlers) EnrollRegistration(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.registrationFromToken(w, r)
+	if !ok {
+		return
+	}
+	h.enroll(w, r, mux.Vars(r)["sessionId"], id)
+}
+
+// UnenrollRegistration removes a registrant from a session.
+func (h *Handlers) UnenrollRegistration(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.registrationFromToken(w, r)
+	if !ok {
+		return
+	}
+	h.unenroll(w, r, mux.Vars(r)["sessionId"], id)
+}
+
+// EnrollAttendee lets an organizer enroll an attendee on their behalf, with
+// the same capacity and overlap checks.
+func (h *Handlers) EnrollAttendee(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	h.enroll(w, r, vars["id"], vars["attendeeId"])
+}
+
+// UnenrollAttendee removes an attendee from a session.
+func (h *Handlers) UnenrollAttendee(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	h.unenroll(w, r, vars["id"], vars["attendeeId"])
+}
+
+func (h *Handlers) enroll(w http.ResponseWriter, r *http.Request, sessionID, attendeeID string) {
+	ctx := r.Context()
+	if _, err := h.attendees.GetAttendee(ctx, attendeeID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			respondError(w, http.StatusNotFound, "Registration not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	enrollment, err := h.enrollments.Enroll(ctx, sessionID, attendeeID)
+	if err != nil {
+		respondEnrollmentError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, enrollment)
+}
+
+func (h *Handlers) unenroll(w http.ResponseWriter, r *http.Request, sessionID, attendeeID string) {
+	if err := h.enrollments.Unenroll(r.Context(), sessionID, attendeeID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Enrollment removed"})
+}
+
+// enrollee is an attendee listed under a session, with when they enrolled.
+type enrollee struct {
+	*models.Attendee
+	EnrolledAt time.Time `json:"enrolledAt"`
+}
+
+// GetSessionEnrollees lists the attendees enrolled in a session in the order
+// they enrolled.
+func (h *Handlers) GetSessionEnrollees(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	enrollments, err := h.enrollments.ListEnrollments(ctx, store.EnrollmentFilter{SessionID: mux.Vars(r)["id"]})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	attendees, err := h.attendees.ListAttendees(ctx)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	byID := make(map[string]*models.Attendee, len(attendees))
+	for _, a := range attendees {
+		byID[a.ID] = a
+	}
+	out := []enrollee{}
+	for _, e := range enrollments {
+		if a, ok := byID[e.AttendeeID]; ok {
+			out = append(out, enrollee{Attendee: a, EnrolledAt: e.CreatedAt})
+		}
+	}
+
+	respondJSON(w, http.StatusOK, out)
+}
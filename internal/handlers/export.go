@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"appdirect-workshop/internal/audit"
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+	"appdirect-workshop/internal/xlsx"
+)
+
+// exportColumn is a column of the attendee export.
+type exportColumn struct {
+	name   string
+	header string
+	value  func(a *models.Attendee, loc *time.Location) string
+}
+
+// exportColumns lists every column in the default order. Attendees are
+// documents whose optional fields may be missing, so each row is built from
+// this list rather than from the fields a document happens to have: every
+// row has the same cells in the same order.
+var exportColumns = []exportColumn{
+	{"id", "ID", func(a *models.Attendee, _ *time.Location) string { return a.ID }},
+	{"name", "Name", func(a *models.Attendee, _ *time.Location) string { return a.Name }},
+	{"email", "Email", func(a *models.Attendee, _ *time.Location) string { return a.Email }},
+	{"designation", "Designation", func(a *models.Attendee, _ *time.Location) string { return a.Designation }},
+	{"status", "Status", func(a *models.Attendee, _ *time.Location) string {
+		if a.Status == "" {
+			return models.StatusRegistered
+		}
+		return a.Status
+	}},
+	{"createdAt", "Registered At", func(a *models.Attendee, loc *time.Location) string { return exportTime(&a.CreatedAt, loc) }},
+	{"promotedAt", "Promoted At", func(a *models.Attendee, loc *time.Location) string { return exportTime(a.PromotedAt, loc) }},
+	{"cancelledAt", "Cancelled At", func(a *models.Attendee, loc *time.Location) string { return exportTime(a.CancelledAt, loc) }},
+	{"checkedInAt", "Checked In At", func(a *models.Attendee, loc *time.Location) string { return exportTime(a.CheckedInAt, loc) }},
+	{"checkedInBy", "Checked In By", func(a *models.Attendee, _ *time.Location) string { return a.CheckedInBy }},
+}
+
+// exportTime formats t in the event time zone, or returns "" when unset.
+func exportTime(t *time.Time, loc *time.Location) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.In(loc).Format("2006-01-02 15:04:05")
+}
+
+// exportSelection returns the columns named in a comma-separated list, in
+// the order given, or every column when the list is empty.
+func exportSelection(list string) ([]exportColumn, error) {
+	if strings.TrimSpace(list) == "" {
+		return exportColumns, nil
+	}
+	byName := make(map[string]exportColumn, len(exportColumns))
+	names := make([]string, len(exportColumns))
+	for i, c := range exportColumns {
+		byName[c.name], names[i] = c, c.name
+	}
+
+	var out []exportColumn
+	seen := map[string]bool{}
+	var errs models.ValidationErrors
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		c, ok := byName[name]
+		switch {
+		case !ok:
+			errs.Add("columns", name+" is not one of "+strings.Join(names, ", "))
+		case seen[name]:
+			errs.Add("columns", name+" is listed twice")
+		default:
+			seen[name] = true
+			out = append(out, c)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return out, nil
+}
+
+// rowWriter is the part of the CSV and XLSX writers the export uses.
+type rowWriter interface {
+	WriteRow(cells []string) error
+	// Flush sends buffered rows to the client.
+	Flush() error
+	Close() error
+}
+
+// csvRows writes RFC 4180 CSV: CRLF line endings, and fields holding a
+// comma, quote or line break quoted with quotes doubled. Cells a spreadsheet
+// would read as a formula are prefixed with a quote, since attendees choose
+// their own names and designations.
+type csvRows struct {
+	w *csv.Writer
+}
+
+func newCSVRows(w http.ResponseWriter, header []string) (*csvRows, error) {
+	c := &csvRows{w: csv.NewWriter(w)}
+	c.w.UseCRLF = true
+	return c, c.WriteRow(header)
+}
+
+func (c *csvRows) WriteRow(cells []string) error {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = escapeFormula(cell)
+	}
+	return c.w.Write(escaped)
+}
+
+// escapeFormula prefixes cells starting with a character Excel or Sheets
+// treats as the start of a formula with "'", which makes them plain text.
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+func (c *csvRows) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvRows) Close() error { return c.Flush() }
+
+// exportPageSize is how many attendees the export reads from the store at a
+// time; only one page is held in memory.
+const exportPageSize = maxPageSize
+
+// exportTimeout replaces the server's write timeout, which is meant for JSON
+// responses, while an export streams.
+const exportTimeout = 5 * time.Minute
+
+// ExportAttendees streams attendees as a spreadsheet, ?format=csv (the
+// default) or xlsx. ?columns= picks and orders the columns; orderBy and the
+// filters of GET /api/attendees apply, with attendees in registration order
+// by default. Rows are read from the store a page at a time and written as
+// they arrive.
+func (h *Handlers) ExportAttendees(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	var errs models.ValidationErrors
+	if format != "csv" && format != "xlsx" {
+		errs.Add("format", "must be csv or xlsx")
+	}
+	columns, err := exportSelection(q.Get("columns"))
+	if err != nil {
+		errs = append(errs, err.(models.ValidationErrors)...)
+	}
+	opts, err := listOptions(r, store.AttendeeFields)
+	if err != nil {
+		respondListError(w, err)
+		return
+	}
+	if len(errs) > 0 {
+		respondValidation(w, errs)
+		return
+	}
+	if opts.OrderBy == "" {
+		opts.OrderBy = "createdAt"
+	}
+	opts.Limit, opts.PageToken = exportPageSize, ""
+
+	// Read the first page before answering so a failing store still gets a
+	// proper error response.
+	ctx := r.Context()
+	page, err := h.attendees.PageAttendees(ctx, opts)
+	if err != nil {
+		respondListError(w, err)
+		return
+	}
+
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.header
+	}
+	// Recorders in tests do not support deadlines; the export still works.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(exportTimeout))
+	filename := "attendees." + format
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+	var rows rowWriter
+	if format == "xlsx" {
+		w.Header().Set("Content-Type", xlsx.ContentType)
+		rows, err = xlsx.NewWriter(w, "Attendees", header)
+	} else {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8; header=present")
+		rows, err = newCSVRows(w, header)
+	}
+	if err != nil {
+		log.Printf("Exporting attendees: %v", err)
+		return
+	}
+
+	n, err := h.writeAttendeeRows(ctx, rows, columns, opts, page)
+	if err == nil {
+		err = rows.Close()
+	}
+	if err != nil {
+		// The status line is sent; all that can be done is cut the file
+		// short, which clients see as a failed download.
+		log.Printf("Exporting attendees after %d rows: %v", n, err)
+		return
+	}
+	entry := audit.Entry{Action: audit.AttendeesExported, IP: h.clientIP(r), Detail: fmt.Sprintf("%d rows as %s", n, format)}
+	if admin, ok := currentAdmin(r); ok {
+		entry.Actor = admin.Username
+	}
+	h.audit.Record(ctx, entry)
+}
+
+// writeAttendeeRows writes page and the pages after it, returning the number
+// of attendees written.
+func (h *Handlers) writeAttendeeRows(ctx context.Context, rows rowWriter, columns []exportColumn, opts store.ListOptions, page *store.Page[models.Attendee]) (int, error) {
+	n := 0
+	cells := make([]string, len(columns))
+	for {
+		for _, a := range page.Items {
+			for i, c := range columns {
+				cells[i] = c.value(a, h.location)
+			}
+			if err := rows.WriteRow(cells); err != nil {
+				return n, err
+			}
+			n++
+		}
+		if err := rows.Flush(); err != nil {
+			return n, err
+		}
+		if page.NextPageToken == "" {
+			return n, nil
+		}
+		opts.PageToken = page.NextPageToken
+		var err error
+		if page, err = h.attendees.PageAttendees(ctx, opts); err != nil {
+			return n, err
+		}
+	}
+}
@@ -2,33 +2,92 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
-	"os"
+	"strings"
 	"time"
 
-	"appdirect-workshop/internal/firestore"
+	"appdirect-workshop/internal/admins"
+	"appdirect-workshop/internal/audit"
+	"appdirect-workshop/internal/auth"
+	"appdirect-workshop/internal/mailer"
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/reminders"
+	"appdirect-workshop/internal/store"
 
 	"github.com/gorilla/mux"
-	"google.golang.org/api/iterator"
 )
 
 type Handlers struct {
-	fsClient        *firestore.Client
-	subcollectionID string
-	adminPassword   string
+	attendees       store.AttendeeStore
+	speakers        store.SpeakerStore
+	sessions        store.SessionStore
+	enrollments     store.EnrollmentStore
+	imports         store.ImportStore
+	workshops       store.WorkshopStore
+	defaultWorkshop string
+	workshop        string
+	workshopUpdated time.Time
+	scopes          *workshopScopes
+	admins          *admins.Service
+	tokens          *auth.Manager
+	links           *auth.LinkSigner
+	tickets         *auth.LinkSigner
+	loginPerIP      auth.Limiter
+	loginGlobal     auth.Limiter
+	trustProxy      bool
+	audit           audit.Logger
+	attendeeCount   *ttlCache[attendeeCount]
+	mail            *mailer.Queue
+	eventName       string
+	publicURL       string
+	reminders       *reminders.Scheduler
+	stopReminders   func()
+	speakerDelete   string
+	location        *time.Location
 }
 
-func NewHandlers(fsClient *firestore.Client, subcollectionID string) *Handlers {
-	adminPassword := os.Getenv("ADMIN_PASSWORD")
-	if adminPassword == "" {
-		adminPassword = "admin123" // Default for development only
-	}
+// attendeeCountTTL is how long the public attendee counts are served from
+// memory before the backend is asked again.
+const attendeeCountTTL = 5 * time.Second
+
+// NewHandlers returns the handlers of defaultWorkshop, whose data the stores
+// hold outside of any workshop.
+func NewHandlers(stores store.Stores, defaultWorkshop string) *Handlers {
+	return newHandlers(stores, defaultWorkshop, mailer.NewQueue(mailer.FromEnv(), mailer.DefaultWorkers))
+}
 
-	return &Handlers{
-		fsClient:        fsClient,
-		subcollectionID: subcollectionID,
-		adminPassword:   adminPassword,
+// newHandlers is NewHandlers sending email, reminders included, through mail.
+func newHandlers(stores store.Stores, defaultWorkshop string, mail *mailer.Queue) *Handlers {
+	loginPerIP, loginGlobal := newLoginLimiters()
+	secret := tokenSecret()
+	h := &Handlers{
+		attendees:       stores.Attendees,
+		speakers:        stores.Speakers,
+		sessions:        stores.Sessions,
+		enrollments:     stores.Enrollments,
+		imports:         stores.Imports,
+		workshops:       stores.Workshops,
+		defaultWorkshop: defaultWorkshop,
+		workshop:        defaultWorkshop,
+		scopes:          &workshopScopes{handlers: make(map[string]*Handlers)},
+		admins:          admins.NewService(stores.Admins),
+		tokens:          newTokenManager(secret),
+		links:           auth.NewLinkSigner(linkSecret(secret)),
+		tickets:         auth.NewTicketSigner(linkSecret(secret)),
+		loginPerIP:      loginPerIP,
+		loginGlobal:     loginGlobal,
+		trustProxy:      trustProxyHeaders(),
+		audit:           audit.StdLogger{},
+		attendeeCount:   newTTLCache[attendeeCount](attendeeCountTTL),
+		mail:            mail,
+		eventName:       eventName(),
+		publicURL:       publicURL(),
+		speakerDelete:   speakerDeleteMode(),
+		location:        EventLocation(),
 	}
+	h.reminders = reminders.NewScheduler(stores, h.mail, h.reminderConfig())
+	return h
 }
 
 // Response helpers
@@ -42,113 +101,137 @@ func respondError(w http.ResponseWriter, status int, message string) {
 	respondJSON(w, status, map[string]string{"error": message})
 }
 
-// Attendee handlers
-func (h *Handlers) GetAttendees(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	collection := h.fsClient.GetCollection(ctx, "attendees")
+// respondValidation writes a 422 listing every rejected field. The "error"
+// summary keeps existing clients that only read that key working.
+func respondValidation(w http.ResponseWriter, errs models.ValidationErrors) {
+	respondJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+		"error":  errs.Error(),
+		"fields": errs,
+	})
+}
 
-	var attendees []map[string]interface{}
-	iter := collection.Documents(ctx)
-	defer iter.Stop()
+// validatable is implemented by the request models in internal/models.
+type validatable interface {
+	Normalize()
+	Validate() error
+}
 
-	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			break
-		}
-		if err != nil {
-			respondError(w, http.StatusInternalServerError, err.Error())
-			return
+// decodeValid decodes the request body into v, rejecting fields the model
+// does not define, then normalizes and validates it. On failure it writes the
+// error response and returns false.
+func decodeValid(w http.ResponseWriter, r *http.Request, v validatable) bool {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &typeErr) && typeErr.Field != "":
+			respondValidation(w, models.ValidationErrors{{Field: typeErr.Field, Message: "must be a " + typeErr.Type.String()}})
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+			respondValidation(w, models.ValidationErrors{{Field: field, Message: "is not allowed"}})
+		default:
+			respondError(w, http.StatusBadRequest, "Invalid request body")
 		}
-
-		data := doc.Data()
-		data["id"] = doc.Ref.ID
-		attendees = append(attendees, data)
+		return false
 	}
 
-	respondJSON(w, http.StatusOK, attendees)
+	v.Normalize()
+	if err := v.Validate(); err != nil {
+		var errs models.ValidationErrors
+		if errors.As(err, &errs) {
+			respondValidation(w, errs)
+			return false
+		}
+		respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return false
+	}
+	return true
 }
 
-func (h *Handlers) RegisterAttendee(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	var attendee map[string]interface{}
-
-	if err := json.NewDecoder(r.Body).Decode(&attendee); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+// Attendee handlers
+func (h *Handlers) GetAttendees(w http.ResponseWriter, r *http.Request) {
+	opts, err := listOptions(r, store.AttendeeFields)
+	if err != nil {
+		respondListError(w, err)
 		return
 	}
 
-	// Add timestamp
-	attendee["createdAt"] = time.Now()
-
-	collection := h.fsClient.GetCollection(ctx, "attendees")
-	docRef, _, err := collection.Add(ctx, attendee)
+	page, err := h.attendees.PageAttendees(r.Context(), opts)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondListError(w, err)
 		return
 	}
 
-	attendee["id"] = docRef.ID
-	respondJSON(w, http.StatusCreated, attendee)
+	respondJSON(w, http.StatusOK, page)
 }
 
-func (h *Handlers) GetAttendeeCount(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) RegisterAttendee(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	collection := h.fsClient.GetCollection(ctx, "attendees")
+	var attendee models.Attendee
 
-	docs, err := collection.Documents(ctx).GetAll()
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+	if !decodeValid(w, r, &attendee) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]int{"count": len(docs)})
+	// Add timestamp; the store decides the status.
+	attendee.CreatedAt = time.Now()
+	attendee.PromotedAt, attendee.CancelledAt = nil, nil
+	attendee.CheckedInAt, attendee.CheckedInBy = nil, ""
+
+	if err := h.attendees.CreateAttendee(ctx, &attendee); err != nil {
+		var dup *store.DuplicateError
+		if errors.As(err, &dup) {
+			respondJSON(w, http.StatusConflict, map[string]string{
+				"error": "This email is already registered",
+				"id":    dup.ExistingID,
+			})
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.attendeeCount.invalidate()
+	h.sendConfirmation(r, &attendee, false)
+
+	respondJSON(w, http.StatusCreated, registration{
+		Attendee:    &attendee,
+		ManageToken: h.links.Sign(attendee.ID),
+		Ticket:      h.ticket(&attendee),
+	})
 }
 
 // Speaker handlers
 func (h *Handlers) GetSpeakers(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	collection := h.fsClient.GetCollection(ctx, "speakers")
-
-	var speakers []map[string]interface{}
-	iter := collection.Documents(ctx)
-	defer iter.Stop()
-
-	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			break
-		}
-		if err != nil {
-			respondError(w, http.StatusInternalServerError, err.Error())
-			return
-		}
+	opts, err := listOptions(r, store.SpeakerFields)
+	if err != nil {
+		respondListError(w, err)
+		return
+	}
 
-		data := doc.Data()
-		data["id"] = doc.Ref.ID
-		speakers = append(speakers, data)
+	page, err := h.speakers.PageSpeakers(r.Context(), opts)
+	if err != nil {
+		respondListError(w, err)
+		return
 	}
 
-	respondJSON(w, http.StatusOK, speakers)
+	respondJSON(w, http.StatusOK, page)
 }
 
 func (h *Handlers) CreateSpeaker(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	var speaker map[string]interface{}
+	var speaker models.Speaker
 
-	if err := json.NewDecoder(r.Body).Decode(&speaker); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeValid(w, r, &speaker) {
 		return
 	}
 
-	collection := h.fsClient.GetCollection(ctx, "speakers")
-	docRef, _, err := collection.Add(ctx, speaker)
-	if err != nil {
+	if err := h.speakers.CreateSpeaker(ctx, &speaker); err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	speaker["id"] = docRef.ID
 	respondJSON(w, http.StatusCreated, speaker)
 }
 
@@ -157,82 +240,119 @@ func (h *Handlers) UpdateSpeaker(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	var speaker map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&speaker); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	var speaker models.Speaker
+	if !decodeValid(w, r, &speaker) {
 		return
 	}
+	speaker.ID = id
 
-	docRef := h.fsClient.GetCollection(ctx, "speakers").Doc(id)
-	_, err := docRef.Set(ctx, speaker)
-	if err != nil {
+	if err := h.speakers.UpdateSpeaker(ctx, &speaker); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			respondError(w, http.StatusNotFound, "Speaker not found")
+			return
+		}
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	speaker["id"] = id
 	respondJSON(w, http.StatusOK, speaker)
 }
 
+// DeleteSpeaker deletes a speaker still assigned to sessions only in cascade
+// mode, which removes them from those sessions. The mode defaults to
+// SPEAKER_DELETE_MODE and can be chosen per request with ?mode=.
 func (h *Handlers) DeleteSpeaker(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	docRef := h.fsClient.GetCollection(ctx, "speakers").Doc(id)
-	_, err := docRef.Delete(ctx)
+	mode := h.speakerDelete
+	if m := r.URL.Query().Get("mode"); m != "" {
+		if m != speakerDeleteBlock && m != speakerDeleteCascade {
+			respondValidation(w, models.ValidationErrors{{Field: "mode", Message: "must be block or cascade"}})
+			return
+		}
+		mode = m
+	}
+
+	sessionIDs, err := h.speakers.DeleteSpeaker(ctx, id, mode == speakerDeleteCascade)
 	if err != nil {
+		var inUse *store.SpeakerInUseError
+		if errors.As(err, &inUse) {
+			respondJSON(w, http.StatusConflict, map[string]interface{}{
+				"error":    "Speaker is assigned to sessions",
+				"sessions": inUse.SessionIDs,
+			})
+			return
+		}
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if sessionIDs == nil {
+		sessionIDs = []string{}
+	}
 
-	respondJSON(w, http.StatusOK, map[string]string{"message": "Speaker deleted"})
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":  "Speaker deleted",
+		"mode":     mode,
+		"sessions": sessionIDs,
+	})
 }
 
 // Session handlers
+// GetSessions lists sessions. With ?expand=speakers each session carries
+// its speakers' profiles, so the agenda needs a single request.
 func (h *Handlers) GetSessions(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	collection := h.fsClient.GetCollection(ctx, "sessions")
+	opts, err := listOptions(r, store.SessionFields)
+	if err != nil {
+		respondListError(w, err)
+		return
+	}
+	expand, err := expandSpeakersParam(r)
+	if err != nil {
+		respondListError(w, err)
+		return
+	}
 
-	var sessions []map[string]interface{}
-	iter := collection.Documents(ctx)
-	defer iter.Stop()
+	page, err := h.sessions.PageSessions(r.Context(), opts)
+	if err != nil {
+		respondListError(w, err)
+		return
+	}
+	if err := h.withEnrollment(r.Context(), page.Items...); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.syncSessions(page.Items...)
 
-	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			break
-		}
+	if expand {
+		expanded, err := h.expandSpeakers(r.Context(), page)
 		if err != nil {
 			respondError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
-
-		data := doc.Data()
-		data["id"] = doc.Ref.ID
-		sessions = append(sessions, data)
+		respondJSON(w, http.StatusOK, expanded)
+		return
 	}
-
-	respondJSON(w, http.StatusOK, sessions)
+	respondJSON(w, http.StatusOK, page)
 }
 
 func (h *Handlers) CreateSession(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	var session map[string]interface{}
+	var session models.Session
 
-	if err := json.NewDecoder(r.Body).Decode(&session); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeValid(w, r, &session) {
 		return
 	}
+	session.SyncSchedule(h.location)
 
-	collection := h.fsClient.GetCollection(ctx, "sessions")
-	docRef, _, err := collection.Add(ctx, session)
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+	session.Enrolled = 0
+	if err := h.sessions.CreateSession(ctx, &session); err != nil {
+		respondSessionError(w, err)
 		return
 	}
+	h.scheduleReminders(ctx, &session)
 
-	session["id"] = docRef.ID
 	respondJSON(w, http.StatusCreated, session)
 }
 
@@ -241,20 +361,23 @@ func (h *Handlers) UpdateSession(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	var session map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&session); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	var session models.Session
+	if !decodeValid(w, r, &session) {
 		return
 	}
+	session.SyncSchedule(h.location)
+	session.ID, session.Enrolled = id, 0
 
-	docRef := h.fsClient.GetCollection(ctx, "sessions").Doc(id)
-	_, err := docRef.Set(ctx, session)
-	if err != nil {
+	if err := h.sessions.UpdateSession(ctx, &session); err != nil {
+		respondSessionError(w, err)
+		return
+	}
+	h.scheduleReminders(ctx, &session)
+	if err := h.withEnrollment(ctx, &session); err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	session["id"] = id
 	respondJSON(w, http.StatusOK, session)
 }
 
@@ -263,19 +386,39 @@ func (h *Handlers) DeleteSession(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	docRef := h.fsClient.GetCollection(ctx, "sessions").Doc(id)
-	_, err := docRef.Delete(ctx)
-	if err != nil {
+	if err := h.sessions.DeleteSession(ctx, id); err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	h.unscheduleReminders(ctx, id)
+	h.releaseEnrollments(ctx, store.EnrollmentFilter{SessionID: id})
 
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Session deleted"})
 }
 
 // Admin handler
+
+// AdminLogin exchanges a username and password for a session token. Requests
+// without a username log in as the default account so older clients that
+// only send a password keep working. Repeated failures are throttled per
+// client IP and globally, answering 429 with Retry-After while locked out.
 func (h *Handlers) AdminLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ip := h.clientIP(r)
+
+	wait, err := h.loginAttempt(ctx, ip)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if wait > 0 {
+		h.audit.Record(ctx, audit.Entry{Action: audit.LoginBlocked, IP: ip, Detail: "retry after " + wait.Round(time.Second).String()})
+		respondTooManyAttempts(w, wait)
+		return
+	}
+
 	var req struct {
+		Username string `json:"username"`
 		Password string `json:"password"`
 	}
 
@@ -284,11 +427,26 @@ func (h *Handlers) AdminLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Password != h.adminPassword {
-		respondError(w, http.StatusUnauthorized, "Invalid password")
+	username := strings.TrimSpace(req.Username)
+	if username == "" {
+		username = admins.DefaultUsername
+	}
+
+	admin, err := h.admins.Authenticate(ctx, username, req.Password)
+	if errors.Is(err, admins.ErrInvalidCredentials) {
+		h.audit.Record(ctx, audit.Entry{Action: audit.LoginFailed, Actor: username, IP: ip})
+		respondError(w, http.StatusUnauthorized, "Invalid username or password")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]string{"message": "Login successful"})
+	if err := h.forgiveLogin(ctx, ip); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.audit.Record(ctx, audit.Entry{Action: audit.LoginSucceeded, Actor: admin.Username, IP: ip})
+	h.respondToken(w, "Login successful", admin)
 }
-
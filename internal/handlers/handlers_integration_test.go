@@ -1,3 +1,4 @@
+//go:build integration
 // +build integration
 
 package handlers
@@ -9,9 +10,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"appdirect-workshop/internal/firestore"
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -36,7 +40,16 @@ func setupIntegrationTest(t *testing.T) (*Handlers, func()) {
 	fsClient, err := firestore.NewClient(ctx, projectID, databaseID, serviceAccountPath)
 	require.NoError(t, err)
 
-	handler := NewHandlers(fsClient, "test_collection")
+	handler := NewHandlers(store.Stores{
+		Attendees:   fsClient,
+		Speakers:    fsClient,
+		Sessions:    fsClient,
+		Admins:      fsClient,
+		Reminders:   fsClient,
+		Enrollments: fsClient,
+		Workshops:   fsClient,
+		Imports:     fsClient,
+	}, "test_collection")
 
 	cleanup := func() {
 		fsClient.Close()
@@ -55,11 +68,13 @@ func TestIntegrationGetAttendees(t *testing.T) {
 	handler.GetAttendees(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
-	
-	var attendees []map[string]interface{}
-	err := json.Unmarshal(w.Body.Bytes(), &attendees)
+
+	var page struct {
+		Items []map[string]interface{} `json:"items"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &page)
 	assert.NoError(t, err)
-	assert.IsType(t, []map[string]interface{}{}, attendees)
+	assert.NotNil(t, page.Items)
 }
 
 func TestIntegrationRegisterAttendee(t *testing.T) {
@@ -80,7 +95,7 @@ func TestIntegrationRegisterAttendee(t *testing.T) {
 	handler.RegisterAttendee(w, req)
 
 	assert.Equal(t, http.StatusCreated, w.Code)
-	
+
 	var response map[string]interface{}
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
@@ -98,10 +113,87 @@ func TestIntegrationGetAttendeeCount(t *testing.T) {
 	handler.GetAttendeeCount(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
-	
+
 	var response map[string]int
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
 	assert.Contains(t, response, "count")
 	assert.GreaterOrEqual(t, response["count"], 0)
 }
+
+func TestIntegrationImportSpeakersAndSessions(t *testing.T) {
+	handler, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	post := func(handle http.HandlerFunc, query, body string) (int, map[string]interface{}) {
+		req := httptest.NewRequest("POST", "/api/admin/import"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/csv")
+		w := httptest.NewRecorder()
+		handle(w, req)
+		var report map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report), w.Body.String())
+		return w.Code, report
+	}
+
+	speaker := "it-" + store.NewID()
+	session := "it-" + store.NewID()
+	stored := func() *models.Session {
+		list, err := handler.sessions.ListSessions(ctx)
+		require.NoError(t, err)
+		for _, se := range list {
+			if se.ID == session {
+				return se
+			}
+		}
+		return nil
+	}
+	defer func() {
+		handler.sessions.DeleteSession(ctx, session)
+		handler.unscheduleReminders(ctx, session)
+		handler.speakers.DeleteSpeaker(ctx, speaker, true)
+	}()
+
+	code, report := post(handler.ImportSpeakers, "", "id,name,bio\n"+speaker+",Integration Speaker,\n")
+	require.Equal(t, http.StatusCreated, code, report)
+	assert.EqualValues(t, 1, report["imported"])
+
+	sessions := "id,title,date,time,duration,speakerIds,room\n" + session + ",Integration Session,2099-01-01,09:00,30," + speaker + "," + session + "\n"
+	code, report = post(handler.ImportSessions, "?dryRun=true", sessions)
+	require.Equal(t, http.StatusOK, code, report)
+	assert.EqualValues(t, 0, report["imported"])
+	assert.Nil(t, stored(), "a dry run stores nothing")
+
+	code, report = post(handler.ImportSessions, "", sessions)
+	require.Equal(t, http.StatusCreated, code, report)
+	require.NotNil(t, stored())
+	assert.Equal(t, []string{speaker}, stored().SpeakerIDs)
+
+	code, report = post(handler.ImportSpeakers, "", "id,name\n"+speaker+",Again\n")
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Len(t, report["errors"], 1)
+}
+
+func TestIntegrationBackfillEmailIndex(t *testing.T) {
+	handler, cleanup := setupIntegrationTest(t)
+	defer cleanup()
+	ctx := context.Background()
+	fsClient := handler.attendees.(*firestore.Client)
+
+	// A registration stored before the email index existed.
+	legacy := fsClient.GetCollection(ctx, "attendees").NewDoc()
+	email := "Legacy." + legacy.ID + "@Example.com"
+	_, err := legacy.Create(ctx, &models.Attendee{
+		Name: "Legacy User", Email: email, Designation: "Engineer", Status: models.StatusRegistered,
+	})
+	require.NoError(t, err)
+	defer legacy.Delete(ctx)
+
+	_, err = fsClient.BackfillEmailIndex(ctx)
+	require.NoError(t, err)
+
+	err = fsClient.CreateAttendee(ctx, &models.Attendee{Name: "Legacy User", Email: strings.ToLower(email), Designation: "Engineer"})
+	var dup *store.DuplicateError
+	require.ErrorAs(t, err, &dup)
+	assert.Equal(t, legacy.ID, dup.ExistingID)
+}
@@ -2,73 +2,103 @@ package handlers
 
 import (
 	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"appdirect-workshop/internal/admins"
+	"appdirect-workshop/internal/audit"
+	"appdirect-workshop/internal/auth"
+	"appdirect-workshop/internal/importer"
+	"appdirect-workshop/internal/mailer"
+	"appdirect-workshop/internal/memory"
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+	"appdirect-workshop/internal/xlsx"
+
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAdminLogin(t *testing.T) {
-	os.Setenv("ADMIN_PASSWORD", "testpass")
-	defer os.Unsetenv("ADMIN_PASSWORD")
-
-	handler := &Handlers{
-		adminPassword: "testpass",
-	}
+	handler := newTestHandlers(memory.New())
+	_, err := handler.admins.Create(context.Background(), "alice", "correct horse", models.RoleViewer)
+	require.NoError(t, err)
+	_, err = handler.admins.Create(context.Background(), "bob", "battery staple", models.RoleViewer)
+	require.NoError(t, err)
+	_, err = handler.admins.SetDisabled(context.Background(), "bob", true)
+	require.NoError(t, err)
 
 	tests := []struct {
 		name           string
-		password       string
+		body           string
 		expectedStatus int
 	}{
 		{
-			name:           "Valid password",
-			password:       "testpass",
+			name:           "Valid credentials",
+			body:           `{"username":"alice","password":"correct horse"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Password only logs in as the default admin",
+			body:           `{"password":"admin123"}`,
 			expectedStatus: http.StatusOK,
 		},
 		{
 			name:           "Invalid password",
-			password:       "wrongpass",
+			body:           `{"username":"alice","password":"wrongpass"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Unknown username",
+			body:           `{"username":"mallory","password":"correct horse"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Disabled account",
+			body:           `{"username":"bob","password":"battery staple"}`,
 			expectedStatus: http.StatusUnauthorized,
 		},
 		{
 			name:           "Empty password",
-			password:       "",
+			body:           `{"username":"alice","password":""}`,
 			expectedStatus: http.StatusUnauthorized,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			body := map[string]string{"password": tt.password}
-			jsonBody, _ := json.Marshal(body)
-			req := httptest.NewRequest("POST", "/api/admin/login", bytes.NewBuffer(jsonBody))
+			req := httptest.NewRequest("POST", "/api/admin/login", bytes.NewBufferString(tt.body))
 			req.Header.Set("Content-Type", "application/json")
 			w := httptest.NewRecorder()
 
 			handler.AdminLogin(w, req)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
+			var response map[string]string
+			json.Unmarshal(w.Body.Bytes(), &response)
 			if tt.expectedStatus == http.StatusOK {
-				var response map[string]string
-				json.Unmarshal(w.Body.Bytes(), &response)
 				assert.Equal(t, "Login successful", response["message"])
+				assert.NotEmpty(t, response["token"])
 			} else {
-				var response map[string]string
-				json.Unmarshal(w.Body.Bytes(), &response)
-				assert.Equal(t, "Invalid password", response["error"])
+				assert.Equal(t, "Invalid username or password", response["error"])
 			}
 		})
 	}
 }
 
 func TestAdminLoginInvalidJSON(t *testing.T) {
-	handler := &Handlers{
-		adminPassword: "testpass",
-	}
+	handler := newTestHandlers(memory.New())
 
 	req := httptest.NewRequest("POST", "/api/admin/login", bytes.NewBufferString("invalid json"))
 	req.Header.Set("Content-Type", "application/json")
@@ -77,26 +107,1676 @@ func TestAdminLoginInvalidJSON(t *testing.T) {
 	handler.AdminLogin(w, req)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	
+
 	var response map[string]string
 	json.Unmarshal(w.Body.Bytes(), &response)
 	assert.Equal(t, "Invalid request body", response["error"])
 }
 
 func TestNewHandlers(t *testing.T) {
-	os.Setenv("ADMIN_PASSWORD", "envpassword")
-	defer os.Unsetenv("ADMIN_PASSWORD")
+	handler := NewHandlers(store.Stores{}, "test_collection")
+	assert.Equal(t, "test_collection", handler.defaultWorkshop)
+	assert.NotNil(t, handler.admins)
+	assert.NotNil(t, handler.tokens)
+}
+
+// failingStore wraps the in-memory store and fails every speaker listing.
+type failingStore struct {
+	*memory.Store
+}
+
+func (f failingStore) PageSpeakers(ctx context.Context, opts store.ListOptions) (*store.Page[models.Speaker], error) {
+	return nil, errors.New("backend unavailable")
+}
+
+// newTestHandlers returns handlers backed by mem with the default admin
+// account bootstrapped using the development password.
+func newTestHandlers(mem *memory.Store) *Handlers {
+	// Keep test emails in memory rather than the development log outbox.
+	return newMailingTestHandlers(mem, &mailer.Outbox{})
+}
+
+// newMailingTestHandlers is newTestHandlers delivering every email, reminders
+// included, to outbox.
+func newMailingTestHandlers(mem *memory.Store, outbox *mailer.Outbox) *Handlers {
+	h := newHandlers(store.Stores{Attendees: mem, Speakers: mem, Sessions: mem, Admins: mem, Reminders: mem, Enrollments: mem, Workshops: mem, Imports: mem}, "test_collection", mailer.NewQueue(outbox, 1))
+	if err := h.admins.Bootstrap(context.Background(), admins.DefaultPassword, false); err != nil {
+		panic(err)
+	}
+	return h
+}
+
+func TestRegisterAndCountAttendees(t *testing.T) {
+	handler := newTestHandlers(memory.New())
+
+	body := `{"name":"Test User","email":"test@example.com","designation":"Engineer"}`
+	req := httptest.NewRequest("POST", "/api/attendees", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	handler.RegisterAttendee(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	assert.Equal(t, "Test User", created["name"])
+	assert.NotEmpty(t, created["id"])
+	assert.NotEmpty(t, created["createdAt"])
+
+	req = httptest.NewRequest("GET", "/api/attendees/count", nil)
+	w = httptest.NewRecorder()
+	handler.GetAttendeeCount(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var count map[string]int
+	json.Unmarshal(w.Body.Bytes(), &count)
+	assert.Equal(t, 1, count["count"])
+
+	req = httptest.NewRequest("GET", "/api/attendees", nil)
+	w = httptest.NewRecorder()
+	handler.GetAttendees(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var page struct {
+		Items []map[string]interface{} `json:"items"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &page)
+	assert.Len(t, page.Items, 1)
+	assert.Equal(t, created["id"], page.Items[0]["id"])
+}
+
+func TestSpeakerCRUD(t *testing.T) {
+	mem := memory.New()
+	router := mux.NewRouter()
+	handler := newTestHandlers(mem)
+	router.HandleFunc("/api/speakers", handler.CreateSpeaker).Methods("POST")
+	router.HandleFunc("/api/speakers/{id}", handler.UpdateSpeaker).Methods("PUT")
+	router.HandleFunc("/api/speakers/{id}", handler.DeleteSpeaker).Methods("DELETE")
+
+	req := httptest.NewRequest("POST", "/api/speakers", bytes.NewBufferString(`{"name":"Ada"}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	id := created["id"].(string)
+
+	req = httptest.NewRequest("PUT", "/api/speakers/"+id, bytes.NewBufferString(`{"name":"Ada Lovelace"}`))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	speakers, _ := mem.ListSpeakers(context.Background())
+	assert.Equal(t, "Ada Lovelace", speakers[0].Name)
+
+	req = httptest.NewRequest("DELETE", "/api/speakers/"+id, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	speakers, _ = mem.ListSpeakers(context.Background())
+	assert.Empty(t, speakers)
+}
+
+func TestSessionSpeakers(t *testing.T) {
+	mem := memory.New()
+	router := mux.NewRouter()
+	handler := newTestHandlers(mem)
+	router.HandleFunc("/api/sessions", handler.CreateSession).Methods("POST")
+	router.HandleFunc("/api/sessions/{id}", handler.UpdateSession).Methods("PUT")
+	router.HandleFunc("/api/speakers/{id}", handler.DeleteSpeaker).Methods("DELETE")
+
+	ctx := context.Background()
+	ada := &models.Speaker{Name: "Ada"}
+	grace := &models.Speaker{Name: "Grace"}
+	require.NoError(t, mem.CreateSpeaker(ctx, ada))
+	require.NoError(t, mem.CreateSpeaker(ctx, grace))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		return w
+	}
+
+	w := do("POST", "/api/sessions", `{"title":"Ghost","date":"2025-03-01","time":"09:00","speakerId":"nobody"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"speakerIds"`)
+
+	// A client that only knows speakerId gets speakerIds filled in.
+	w = do("POST", "/api/sessions", `{"title":"Talk","date":"2025-03-01","time":"10:00","speakerId":"`+grace.ID+`"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var talk models.Session
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &talk))
+	assert.Equal(t, []string{grace.ID}, talk.SpeakerIDs)
+
+	w = do("POST", "/api/sessions", `{"title":"Panel","date":"2025-03-01","time":"11:00","speakerIds":["`+ada.ID+`","`+grace.ID+`"]}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var panel models.Session
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &panel))
+	assert.Equal(t, ada.ID, panel.SpeakerID)
+
+	w = do("PUT", "/api/sessions/"+talk.ID, `{"title":"Talk","date":"2025-03-01","time":"10:00","speakerIds":["`+grace.ID+`","nobody"]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	w = do("DELETE", "/api/speakers/"+grace.ID, "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var blocked struct {
+		Sessions []string `json:"sessions"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &blocked))
+	assert.ElementsMatch(t, []string{talk.ID, panel.ID}, blocked.Sessions)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, do("DELETE", "/api/speakers/"+grace.ID+"?mode=purge", "").Code)
+
+	w = do("DELETE", "/api/speakers/"+grace.ID+"?mode=cascade", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"mode":"cascade"`)
+
+	sessions, err := mem.ListSessions(ctx)
+	require.NoError(t, err)
+	for _, s := range sessions {
+		assert.False(t, s.HasSpeaker(grace.ID), s.Title)
+	}
+}
+
+func TestGetSessionsExpandSpeakers(t *testing.T) {
+	mem := memory.New()
+	handler := newTestHandlers(mem)
+	ctx := context.Background()
+
+	ada := &models.Speaker{Name: "Ada", Bio: "Analyst"}
+	grace := &models.Speaker{Name: "Grace"}
+	require.NoError(t, mem.CreateSpeaker(ctx, ada))
+	require.NoError(t, mem.CreateSpeaker(ctx, grace))
+	require.NoError(t, mem.CreateSession(ctx, &models.Session{Title: "Panel", SpeakerIDs: []string{grace.ID, ada.ID}}))
+	require.NoError(t, mem.CreateSession(ctx, &models.Session{Title: "Break"}))
+
+	w := httptest.NewRecorder()
+	handler.GetSessions(w, httptest.NewRequest("GET", "/api/sessions?expand=speakers&orderBy=title", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var page struct {
+		Items []struct {
+			Title    string           `json:"title"`
+			Speakers []models.Speaker `json:"speakers"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, "Break", page.Items[0].Title)
+	assert.NotNil(t, page.Items[0].Speakers)
+	assert.Empty(t, page.Items[0].Speakers)
+	require.Len(t, page.Items[1].Speakers, 2)
+	assert.Equal(t, "Grace", page.Items[1].Speakers[0].Name, "speakers keep the session's order")
+	assert.Equal(t, "Analyst", page.Items[1].Speakers[1].Bio)
+
+	w = httptest.NewRecorder()
+	handler.GetSessions(w, httptest.NewRequest("GET", "/api/sessions", nil))
+	assert.NotContains(t, w.Body.String(), `"speakers"`)
+
+	w = httptest.NewRecorder()
+	handler.GetSessions(w, httptest.NewRequest("GET", "/api/sessions?expand=rooms", nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestAgenda(t *testing.T) {
+	mem := memory.New()
+	router := mux.NewRouter()
+	handler := newTestHandlers(mem)
+	handler.location = time.FixedZone("IST", 5*3600+1800)
+	router.HandleFunc("/api/agenda", handler.GetAgenda).Methods("GET")
+	router.HandleFunc("/api/sessions", handler.CreateSession).Methods("POST")
+	router.HandleFunc("/api/sessions/{id}", handler.UpdateSession).Methods("PUT")
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		return w
+	}
+	create := func(body string) models.Session {
+		w := do("POST", "/api/sessions", body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var s models.Session
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &s))
+		return s
+	}
+
+	// Older clients send a date and time in the event time zone.
+	keynote := create(`{"title":"Keynote","date":"2025-03-01","time":"09:00","duration":90,"room":"Hall A"}`)
+	assert.Equal(t, time.Date(2025, 3, 1, 3, 30, 0, 0, time.UTC), keynote.StartsAt)
+	assert.Equal(t, time.Date(2025, 3, 1, 5, 0, 0, 0, time.UTC), keynote.EndsAt)
+
+	create(`{"title":"Agents","startsAt":"2025-03-01T05:00:00Z","endsAt":"2025-03-01T06:00:00Z","room":"Hall A","track":"AI"}`)
+	create(`{"title":"Evals","startsAt":"2025-03-01T05:00:00Z","endsAt":"2025-03-01T05:45:00Z","room":"Room 2","track":"AI"}`)
+	create(`{"title":"Tooling","startsAt":"2025-03-01T04:00:00Z","room":"Room 3","track":"Dev"}`)
+	wrapUp := create(`{"title":"Wrap-up","startsAt":"2025-03-02T11:00:00Z","room":"Hall A"}`)
+	assert.Equal(t, "2025-03-02", wrapUp.Date)
+	assert.Equal(t, "16:30", wrapUp.Time)
+	assert.Equal(t, 60, wrapUp.Duration)
+
+	// Hall A is taken by the keynote until 10:30 local time.
+	w := do("POST", "/api/sessions", `{"title":"Clash","date":"2025-03-01","time":"09:30","room":"hall a"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), keynote.ID)
+	w = do("PUT", "/api/sessions/"+wrapUp.ID, `{"title":"Wrap-up","startsAt":"2025-03-01T04:30:00Z","room":"Hall A"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = do("PUT", "/api/sessions/"+keynote.ID, `{"title":"Keynote","date":"2025-03-01","time":"09:00","duration":60,"room":"Hall A"}`)
+	assert.Equal(t, http.StatusOK, w.Code, "a session does not clash with itself")
+	w = do("POST", "/api/sessions", `{"title":"Backwards","startsAt":"2025-03-01T05:00:00Z","endsAt":"2025-03-01T04:00:00Z"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	w = do("GET", "/api/agenda", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var got struct {
+		Timezone string `json:"timezone"`
+		Days     []struct {
+			Date   string `json:"date"`
+			Tracks []struct {
+				Track    string `json:"track"`
+				Sessions []struct {
+					Title    string        `json:"title"`
+					Speakers []interface{} `json:"speakers"`
+				} `json:"sessions"`
+			} `json:"tracks"`
+		} `json:"days"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, "IST", got.Timezone)
+	require.Len(t, got.Days, 2)
+	assert.Equal(t, "2025-03-01", got.Days[0].Date)
+	require.Len(t, got.Days[0].Tracks, 3)
+	assert.Equal(t, "AI", got.Days[0].Tracks[0].Track)
+	assert.Equal(t, "Dev", got.Days[0].Tracks[1].Track)
+	assert.Equal(t, "", got.Days[0].Tracks[2].Track, "sessions without a track come last")
+	ai := got.Days[0].Tracks[0].Sessions
+	require.Len(t, ai, 2)
+	assert.Equal(t, "Agents", ai[0].Title, "same start time orders by room")
+	assert.Equal(t, "Evals", ai[1].Title)
+	assert.NotNil(t, ai[0].Speakers)
+	assert.Equal(t, "2025-03-02", got.Days[1].Date)
+}
+
+func TestCalendars(t *testing.T) {
+	mem := memory.New()
+	outbox := &mailer.Outbox{}
+	handler := newMailingTestHandlers(mem, outbox)
+	handler.location = time.FixedZone("IST", 5*3600+1800)
+	router := mux.NewRouter()
+	router.HandleFunc("/api/attendees", handler.RegisterAttendee).Methods("POST")
+	router.HandleFunc("/api/speakers", handler.CreateSpeaker).Methods("POST")
+	router.HandleFunc("/api/sessions", handler.CreateSession).Methods("POST")
+	router.HandleFunc("/api/sessions/{id}", handler.UpdateSession).Methods("PUT")
+	router.HandleFunc("/api/agenda.ics", handler.GetAgendaICS).Methods("GET")
+	router.HandleFunc("/api/registrations/{token}/schedule.ics", handler.GetRegistrationSchedule).Methods("GET")
+	router.HandleFunc("/api/registrations/{token}/sessions/{sessionId}", handler.EnrollRegistration).Methods("PUT")
+	do := func(method, path, body string, out interface{}) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		if out != nil {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), out), w.Body.String())
+		}
+		return w
+	}
+
+	var speaker models.Speaker
+	require.Equal(t, http.StatusCreated, do("POST", "/api/speakers", `{"name":"Grace Hopper","bio":"Compilers"}`, &speaker).Code)
+	var keynote, talk models.Session
+	require.Equal(t, http.StatusCreated, do("POST", "/api/sessions",
+		`{"title":"Keynote","description":"Opening, day one","date":"2030-05-10","time":"09:00","duration":60,"room":"Hall A","track":"AI","speakerIds":["`+speaker.ID+`"]}`, &keynote).Code)
+	require.Equal(t, http.StatusCreated, do("POST", "/api/sessions", `{"title":"Talk","date":"2030-05-10","time":"11:00"}`, &talk).Code)
+
+	w := do("GET", "/api/agenda.ics", "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+	agenda := w.Body.String()
+	assert.Equal(t, 2, strings.Count(agenda, "BEGIN:VEVENT"))
+	assert.Contains(t, agenda, "UID:"+keynote.ID+"@appdirect-workshop\r\n")
+	assert.Contains(t, agenda, "DTSTART:20300510T033000Z\r\n")
+	assert.Contains(t, agenda, "DESCRIPTION:Opening\\, day one\\n\\nSpeakers: Grace Hopper\r\n")
+	assert.Contains(t, agenda, "LOCATION:Hall A\r\n")
+	assert.Contains(t, agenda, "CATEGORIES:AI\r\n")
+
+	// Rescheduling keeps the UID so calendar apps move the entry.
+	require.Equal(t, http.StatusOK, do("PUT", "/api/sessions/"+keynote.ID,
+		`{"title":"Keynote","date":"2030-05-10","time":"10:00","duration":60,"room":"Hall A","speakerIds":["`+speaker.ID+`"]}`, nil).Code)
+	agenda = do("GET", "/api/agenda.ics", "", nil).Body.String()
+	assert.Contains(t, agenda, "UID:"+keynote.ID+"@appdirect-workshop\r\n")
+	assert.Contains(t, agenda, "DTSTART:20300510T043000Z\r\n")
+	assert.NotContains(t, agenda, "DTSTART:20300510T033000Z")
+
+	var ada registration
+	require.Equal(t, http.StatusCreated, do("POST", "/api/attendees", `{"name":"Ada","email":"ada@example.com","designation":"Engineer"}`, &ada).Code)
+	require.Equal(t, http.StatusOK, do("PUT", "/api/registrations/"+ada.ManageToken+"/sessions/"+talk.ID, "", nil).Code)
+	w = do("GET", "/api/registrations/"+ada.ManageToken+"/schedule.ics", "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, strings.Count(w.Body.String(), "BEGIN:VEVENT"))
+	assert.Contains(t, w.Body.String(), "UID:"+talk.ID+"@appdirect-workshop\r\n")
+	assert.Equal(t, http.StatusNotFound, do("GET", "/api/registrations/bogus/schedule.ics", "", nil).Code)
+
+	require.NoError(t, handler.Close(context.Background()))
+	sent := outbox.Sent()
+	require.Len(t, sent, 1)
+	require.Len(t, sent[0].Attachments, 1)
+	assert.Equal(t, "event.ics", sent[0].Attachments[0].Filename)
+	assert.Contains(t, string(sent[0].Attachments[0].Data), "UID:"+keynote.ID+"@appdirect-workshop\r\n")
+}
+
+func TestMigrateSessions(t *testing.T) {
+	mem := memory.New()
+	handler := newTestHandlers(mem)
+	handler.location = time.FixedZone("IST", 5*3600+1800)
+	ctx := context.Background()
+
+	// Sessions saved before timestamps only had a date and time.
+	legacy := &models.Session{Title: "Keynote", Date: "2025-03-01", Time: "09:00"}
+	require.NoError(t, mem.CreateSession(ctx, legacy))
+	require.NoError(t, mem.CreateSession(ctx, &models.Session{Title: "Talk", Date: "2025-03-01", Time: "11:00", Duration: 30}))
+	require.NoError(t, mem.CreateSession(ctx, &models.Session{Title: "Someday"}))
+
+	n, err := handler.MigrateSessions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	n, err = handler.MigrateSessions(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n, "migrated sessions are left alone")
+
+	sessions, err := mem.ListSessions(ctx)
+	require.NoError(t, err)
+	for _, s := range sessions {
+		assert.Equal(t, s.Title == "Someday", s.StartsAt.IsZero(), s.Title)
+		if s.ID == legacy.ID {
+			assert.Equal(t, time.Date(2025, 3, 1, 3, 30, 0, 0, time.UTC), s.StartsAt)
+			assert.Equal(t, 60, s.Duration)
+		}
+	}
+}
+
+func TestSessionCRUD(t *testing.T) {
+	mem := memory.New()
+	router := mux.NewRouter()
+	handler := newTestHandlers(mem)
+	router.HandleFunc("/api/sessions", handler.GetSessions).Methods("GET")
+	router.HandleFunc("/api/sessions", handler.CreateSession).Methods("POST")
+	router.HandleFunc("/api/sessions/{id}", handler.UpdateSession).Methods("PUT")
+	router.HandleFunc("/api/sessions/{id}", handler.DeleteSession).Methods("DELETE")
+
+	req := httptest.NewRequest("POST", "/api/sessions", bytes.NewBufferString(`{"title":"Keynote","date":"2025-03-01","time":"09:30"}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	id := created["id"].(string)
+
+	req = httptest.NewRequest("PUT", "/api/sessions/"+id, bytes.NewBufferString(`{"title":"Opening Keynote","date":"2025-03-01","time":"09:00"}`))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest("GET", "/api/sessions", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var listed struct {
+		Items []map[string]interface{} `json:"items"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &listed)
+	assert.Len(t, listed.Items, 1)
+	assert.Equal(t, "Opening Keynote", listed.Items[0]["title"])
+	assert.Equal(t, "09:00", listed.Items[0]["time"])
+
+	req = httptest.NewRequest("DELETE", "/api/sessions/"+id, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	sessions, _ := mem.ListSessions(context.Background())
+	assert.Empty(t, sessions)
+}
+
+func TestStoreErrors(t *testing.T) {
+	mem := failingStore{memory.New()}
+	handler := NewHandlers(store.Stores{Attendees: mem, Speakers: mem, Sessions: mem, Admins: mem, Reminders: mem, Enrollments: mem, Workshops: mem, Imports: mem}, "test_collection")
+
+	req := httptest.NewRequest("GET", "/api/speakers", nil)
+	w := httptest.NewRecorder()
+	handler.GetSpeakers(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	var response map[string]string
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "backend unavailable", response["error"])
+}
+
+func TestValidationErrors(t *testing.T) {
+	handler := newTestHandlers(memory.New())
+
+	tests := []struct {
+		name        string
+		handler     http.HandlerFunc
+		body        string
+		wantFields  []string
+		wantMessage string
+	}{
+		{
+			name:       "Attendee missing email",
+			handler:    handler.RegisterAttendee,
+			body:       `{"name":"Test User","designation":"Engineer"}`,
+			wantFields: []string{"email"},
+		},
+		{
+			name:        "Attendee invalid email",
+			handler:     handler.RegisterAttendee,
+			body:        `{"name":"Test User","email":"not-an-email","designation":"Engineer"}`,
+			wantFields:  []string{"email"},
+			wantMessage: "email must be a valid email address",
+		},
+		{
+			name:        "Attendee unknown field",
+			handler:     handler.RegisterAttendee,
+			body:        `{"name":"Test User","email":"test@example.com","designation":"Engineer","isAdmin":true}`,
+			wantFields:  []string{"isAdmin"},
+			wantMessage: "isAdmin is not allowed",
+		},
+		{
+			name:       "Attendee wrong type",
+			handler:    handler.RegisterAttendee,
+			body:       `{"name":42,"email":"test@example.com","designation":"Engineer"}`,
+			wantFields: []string{"name"},
+		},
+		{
+			name:       "Speaker blank name",
+			handler:    handler.CreateSpeaker,
+			body:       `{"name":"   ","bio":"Bio"}`,
+			wantFields: []string{"name"},
+		},
+		{
+			name:       "Session bad date and time",
+			handler:    handler.CreateSession,
+			body:       `{"title":"Keynote","date":"01/03/2025","time":"9am"}`,
+			wantFields: []string{"date", "time"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			tt.handler(w, req)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+			var response struct {
+				Error  string              `json:"error"`
+				Fields []models.FieldError `json:"fields"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &response)
+
+			var fields []string
+			for _, f := range response.Fields {
+				fields = append(fields, f.Field)
+			}
+			assert.Equal(t, tt.wantFields, fields)
+			if tt.wantMessage != "" {
+				assert.Equal(t, tt.wantMessage, response.Error)
+			}
+		})
+	}
+}
+
+func TestUpdateMissingSpeaker(t *testing.T) {
+	router := mux.NewRouter()
+	handler := newTestHandlers(memory.New())
+	router.HandleFunc("/api/speakers/{id}", handler.UpdateSpeaker).Methods("PUT")
+
+	req := httptest.NewRequest("PUT", "/api/speakers/missing", bytes.NewBufferString(`{"name":"Ada"}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRegisterDuplicateEmail(t *testing.T) {
+	handler := newTestHandlers(memory.New())
+
+	register := func(email string) *httptest.ResponseRecorder {
+		body := `{"name":"Test User","email":"` + email + `","designation":"Engineer"}`
+		req := httptest.NewRequest("POST", "/api/attendees", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		handler.RegisterAttendee(w, req)
+		return w
+	}
+
+	w := register("test@example.com")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	w = register("  Test@Example.COM ")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var response map[string]string
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, created["id"], response["id"])
+}
+
+func TestMergeDuplicateAttendees(t *testing.T) {
+	// Registrations made before uniqueness was enforced can only be seeded
+	// through a snapshot.
+	snapshot := `{"attendees": {
+		"a1": {"name": "Grace", "email": "grace@example.com", "designation": "", "createdAt": "2025-01-01T10:00:00Z"},
+		"a2": {"name": "Grace H", "email": "GRACE@example.com", "designation": "Admiral", "createdAt": "2025-01-02T10:00:00Z"},
+		"a3": {"name": "Alan", "email": "alan@example.com", "designation": "Engineer", "createdAt": "2025-01-03T10:00:00Z"}
+	}}`
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	require.NoError(t, os.WriteFile(path, []byte(snapshot), 0o600))
+	mem := memory.New()
+	require.NoError(t, mem.Load(path))
+	handler := newTestHandlers(mem)
+
+	req := httptest.NewRequest("GET", "/api/admin/attendees/duplicates", nil)
+	w := httptest.NewRecorder()
+	handler.GetDuplicateAttendees(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var groups []duplicateGroup
+	json.Unmarshal(w.Body.Bytes(), &groups)
+	require.Len(t, groups, 1)
+	assert.Equal(t, "grace@example.com", groups[0].Email)
+	assert.Equal(t, "a1", groups[0].Keep.ID)
+	assert.Equal(t, "a2", groups[0].Duplicates[0].ID)
+
+	req = httptest.NewRequest("POST", "/api/admin/attendees/duplicates/merge", nil)
+	w = httptest.NewRecorder()
+	handler.MergeDuplicateAttendees(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var result struct {
+		Merged  int `json:"merged"`
+		Removed int `json:"removed"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &result)
+	assert.Equal(t, 1, result.Merged)
+	assert.Equal(t, 1, result.Removed)
+
+	attendees, err := mem.ListAttendees(context.Background())
+	require.NoError(t, err)
+	require.Len(t, attendees, 2)
+	assert.Equal(t, "a1", attendees[0].ID)
+	assert.Equal(t, "Grace", attendees[0].Name)
+	assert.Equal(t, "Admiral", attendees[0].Designation, "blank fields are filled from duplicates")
+}
+
+func TestRequireAdmin(t *testing.T) {
+	handler := newTestHandlers(memory.New())
+	router := mux.NewRouter()
+	router.HandleFunc("/api/admin/login", handler.AdminLogin).Methods("POST")
+	admin := router.NewRoute().Subrouter()
+	admin.Use(handler.RequireAdmin)
+	admin.HandleFunc("/api/attendees", handler.GetAttendees).Methods("GET")
+	admin.HandleFunc("/api/admin/logout", handler.AdminLogout).Methods("POST")
+	admin.HandleFunc("/api/admin/refresh", handler.AdminRefresh).Methods("POST")
+
+	do := func(method, path, token string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	tokenFrom := func(w *httptest.ResponseRecorder) string {
+		var response map[string]string
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response["token"]
+	}
+
+	w := do("GET", "/api/attendees", "", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+
+	w = do("GET", "/api/attendees", "forged.token.value", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	token := tokenFrom(do("POST", "/api/admin/login", "", `{"password":"admin123"}`))
+	require.NotEmpty(t, token)
+	assert.Equal(t, http.StatusOK, do("GET", "/api/attendees", token, "").Code)
+
+	// Refreshing replaces the token and revokes the old one
+	w = do("POST", "/api/admin/refresh", token, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	refreshed := tokenFrom(w)
+	assert.Equal(t, http.StatusUnauthorized, do("GET", "/api/attendees", token, "").Code)
+	assert.Equal(t, http.StatusOK, do("GET", "/api/attendees", refreshed, "").Code)
+
+	// Logging out revokes the current token
+	assert.Equal(t, http.StatusOK, do("POST", "/api/admin/logout", refreshed, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, do("GET", "/api/attendees", refreshed, "").Code)
+}
+
+func TestAdminAccounts(t *testing.T) {
+	mem := memory.New()
+	handler := newTestHandlers(mem)
+	router := mux.NewRouter()
+	router.HandleFunc("/api/admin/login", handler.AdminLogin).Methods("POST")
+	admin := router.NewRoute().Subrouter()
+	admin.Use(handler.RequireAdmin)
+	admin.HandleFunc("/api/attendees", handler.GetAttendees).Methods("GET")
+	manage := handler.Require(auth.PermManageAdmins)
+	admin.Handle("/api/admin/users", manage(http.HandlerFunc(handler.ListAdmins))).Methods("GET")
+	admin.Handle("/api/admin/users", manage(http.HandlerFunc(handler.CreateAdmin))).Methods("POST")
+	admin.Handle("/api/admin/users/{username}/disable", manage(http.HandlerFunc(handler.DisableAdmin))).Methods("POST")
+	admin.Handle("/api/admin/users/{username}/enable", manage(http.HandlerFunc(handler.EnableAdmin))).Methods("POST")
+	admin.Handle("/api/admin/users/{username}/role", manage(http.HandlerFunc(handler.SetAdminRole))).Methods("PUT")
+	admin.HandleFunc("/api/admin/users/{username}/password", handler.RotateAdminPassword).Methods("PUT")
+
+	do := func(method, path, token string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	login := func(username, password string) string {
+		w := do("POST", "/api/admin/login", "", `{"username":"`+username+`","password":"`+password+`"}`)
+		var response map[string]string
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response["token"]
+	}
+
+	root := login("admin", "admin123")
+	require.NotEmpty(t, root)
+
+	w := do("POST", "/api/admin/users", root, `{"username":"alice","password":"short","role":"owner"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	w = do("POST", "/api/admin/users", root, `{"username":"alice","password":"correct horse","role":"root"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	w = do("POST", "/api/admin/users", root, `{"username":"alice","password":"correct horse","role":"owner"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.NotContains(t, w.Body.String(), "asswordHash")
+	assert.Equal(t, http.StatusConflict, do("POST", "/api/admin/users", root, `{"username":"alice","password":"correct horse","role":"owner"}`).Code)
+
+	w = do("GET", "/api/admin/users", root, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list []models.Admin
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list, 2)
+	assert.Equal(t, "admin", list[0].Username)
+	assert.Equal(t, "alice", list[1].Username)
+
+	alice := login("alice", "correct horse")
+	require.NotEmpty(t, alice)
+
+	// Admins cannot lock themselves out
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/api/admin/users/alice/disable", alice, "").Code)
+	assert.Equal(t, http.StatusBadRequest, do("PUT", "/api/admin/users/alice/role", alice, `{"role":"viewer"}`).Code)
+
+	// Disabling an account invalidates its existing tokens
+	assert.Equal(t, http.StatusOK, do("POST", "/api/admin/users/alice/disable", root, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, do("GET", "/api/attendees", alice, "").Code)
+	assert.Empty(t, login("alice", "correct horse"))
+	assert.Equal(t, http.StatusOK, do("POST", "/api/admin/users/alice/enable", root, "").Code)
+	assert.Equal(t, http.StatusNotFound, do("POST", "/api/admin/users/nobody/enable", root, "").Code)
+
+	// Rotating a password invalidates tokens issued before the change, even
+	// within the same second
+	alice = login("alice", "correct horse")
+	require.NotEmpty(t, alice)
+	assert.Equal(t, http.StatusUnprocessableEntity, do("PUT", "/api/admin/users/alice/password", root, `{"password":"short"}`).Code)
+	assert.Equal(t, http.StatusOK, do("PUT", "/api/admin/users/alice/password", root, `{"password":"new password 1"}`).Code)
+	assert.Equal(t, http.StatusUnauthorized, do("GET", "/api/attendees", alice, "").Code)
+	assert.Empty(t, login("alice", "correct horse"))
+	alice = login("alice", "new password 1")
+	assert.Equal(t, http.StatusOK, do("GET", "/api/attendees", alice, "").Code)
+	assert.Equal(t, http.StatusOK, do("PUT", "/api/admin/users/alice/password", alice, `{"password":"new password 2"}`).Code)
+	assert.Equal(t, http.StatusUnauthorized, do("GET", "/api/attendees", alice, "").Code)
+}
+
+func TestRolePermissions(t *testing.T) {
+	mem := memory.New()
+	handler := newTestHandlers(mem)
+	for username, role := range map[string]models.Role{
+		"viewer1": models.RoleViewer,
+		"coord1":  models.RoleCoordinator,
+		"door1":   models.RoleCheckin,
+	} {
+		_, err := handler.admins.Create(context.Background(), username, "password123", role)
+		require.NoError(t, err)
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/admin/login", handler.AdminLogin).Methods("POST")
+	admin := router.NewRoute().Subrouter()
+	admin.Use(handler.RequireAdmin)
+	can := func(perm auth.Permission, f http.HandlerFunc) http.Handler { return handler.Require(perm)(f) }
+	admin.HandleFunc("/api/admin/me", handler.AdminMe).Methods("GET")
+	admin.Handle("/api/attendees", can(auth.PermViewAttendees, handler.GetAttendees)).Methods("GET")
+	admin.Handle("/api/speakers", can(auth.PermManageSpeakers, handler.CreateSpeaker)).Methods("POST")
+	admin.Handle("/api/sessions", can(auth.PermManageSessions, handler.CreateSession)).Methods("POST")
+	admin.Handle("/api/admin/users/{username}/role", can(auth.PermManageAdmins, handler.SetAdminRole)).Methods("PUT")
+	admin.HandleFunc("/api/admin/users/{username}/password", handler.RotateAdminPassword).Methods("PUT")
+
+	do := func(method, path, token string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	login := func(username string) string {
+		w := do("POST", "/api/admin/login", "", `{"username":"`+username+`","password":"password123"}`)
+		var response map[string]string
+		json.Unmarshal(w.Body.Bytes(), &response)
+		require.NotEmpty(t, response["token"], username)
+		return response["token"]
+	}
+	speaker := `{"name":"Ada","bio":""}`
+	session := `{"title":"Intro","date":"2025-06-01","time":"09:00"}`
+
+	viewer := login("viewer1")
+	w := do("GET", "/api/admin/me", viewer, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var me struct {
+		Username    string   `json:"username"`
+		Role        string   `json:"role"`
+		Permissions []string `json:"permissions"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &me))
+	assert.Equal(t, "viewer1", me.Username)
+	assert.Equal(t, "viewer", me.Role)
+	assert.Equal(t, []string{"attendees:read"}, me.Permissions)
+
+	assert.Equal(t, http.StatusOK, do("GET", "/api/attendees", viewer, "").Code)
+	assert.Equal(t, http.StatusForbidden, do("POST", "/api/speakers", viewer, speaker).Code)
+	assert.Equal(t, http.StatusForbidden, do("PUT", "/api/admin/users/coord1/password", viewer, `{"password":"new password 1"}`).Code)
+	assert.Equal(t, http.StatusOK, do("PUT", "/api/admin/users/viewer1/password", viewer, `{"password":"password123"}`).Code, "admins can change their own password")
+
+	coordinator := login("coord1")
+	assert.Equal(t, http.StatusForbidden, do("GET", "/api/attendees", coordinator, "").Code)
+	assert.Equal(t, http.StatusCreated, do("POST", "/api/speakers", coordinator, speaker).Code)
+	assert.Equal(t, http.StatusForbidden, do("POST", "/api/sessions", coordinator, session).Code)
+
+	door := login("door1")
+	assert.Equal(t, http.StatusOK, do("GET", "/api/attendees", door, "").Code)
+	assert.Equal(t, http.StatusForbidden, do("POST", "/api/sessions", door, session).Code)
+
+	// Role changes apply to existing tokens on their next request
+	owner := do("POST", "/api/admin/login", "", `{"username":"admin","password":"admin123"}`)
+	var ownerResponse map[string]string
+	json.Unmarshal(owner.Body.Bytes(), &ownerResponse)
+	assert.Equal(t, http.StatusOK, do("PUT", "/api/admin/users/door1/role", ownerResponse["token"], `{"role":"organizer"}`).Code)
+	assert.Equal(t, http.StatusCreated, do("POST", "/api/sessions", door, session).Code)
+	assert.Equal(t, http.StatusForbidden, do("PUT", "/api/admin/users/coord1/role", door, `{"role":"viewer"}`).Code)
+}
+
+func TestLoginThrottling(t *testing.T) {
+	handler := newTestHandlers(memory.New())
+	handler.loginPerIP = auth.NewMemoryLimiter(auth.Policy{FreeAttempts: 2, BaseLockout: time.Minute, MaxLockout: time.Hour, Window: time.Hour})
+	handler.loginGlobal = auth.NewMemoryLimiter(auth.Policy{FreeAttempts: 4, BaseLockout: time.Second, MaxLockout: time.Minute, Window: time.Hour})
+	auditLog := &audit.Memory{}
+	handler.audit = auditLog
+
+	_, err := handler.admins.Create(context.Background(), "alice", "password123", models.RoleViewer)
+	require.NoError(t, err)
+
+	login := func(ip, username, password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/admin/login", bytes.NewBufferString(`{"username":"`+username+`","password":"`+password+`"}`))
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		handler.AdminLogin(w, req)
+		return w
+	}
+
+	// Signing in to another account does not clear the caller's failures
+	assert.Equal(t, http.StatusUnauthorized, login("192.0.2.1", "admin", "wrong").Code)
+	assert.Equal(t, http.StatusOK, login("192.0.2.1", "alice", "password123").Code)
+	assert.Equal(t, http.StatusUnauthorized, login("192.0.2.1", "admin", "wrong").Code)
+	assert.Equal(t, http.StatusUnauthorized, login("192.0.2.1", "admin", "wrong").Code)
+
+	// Locked out, even with the right password
+	w := login("192.0.2.1", "admin", "admin123")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	// Other addresses are only limited by the global counter
+	assert.Equal(t, http.StatusOK, login("192.0.2.2", "admin", "admin123").Code)
+	assert.Equal(t, http.StatusUnauthorized, login("192.0.2.3", "admin", "wrong").Code)
+	assert.Equal(t, http.StatusUnauthorized, login("192.0.2.3", "admin", "wrong").Code)
+	w = login("192.0.2.4", "admin", "admin123")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	var actions []string
+	for _, e := range auditLog.Entries() {
+		actions = append(actions, e.Action)
+	}
+	assert.Equal(t, []string{
+		audit.LoginFailed, audit.LoginSucceeded, audit.LoginFailed, audit.LoginFailed, audit.LoginBlocked,
+		audit.LoginSucceeded, audit.LoginFailed, audit.LoginFailed, audit.LoginBlocked,
+	}, actions)
+	assert.Equal(t, "192.0.2.1", auditLog.Entries()[0].IP)
+}
+
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest("POST", "/api/admin/login", nil)
+	req.RemoteAddr = "10.0.0.1:5555"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9, 198.51.100.7")
+
+	assert.Equal(t, "10.0.0.1", (&Handlers{}).clientIP(req))
+	assert.Equal(t, "198.51.100.7", (&Handlers{trustProxy: true}).clientIP(req), "only the proxy-appended entry is trusted")
+}
+
+func TestListPagination(t *testing.T) {
+	mem := memory.New()
+	handler := newTestHandlers(mem)
+	base := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i, a := range []models.Attendee{
+		{Name: "Carol", Email: "carol@example.com", Designation: "Engineer"},
+		{Name: "Alice", Email: "alice@example.com", Designation: "Manager"},
+		{Name: "Bob", Email: "bob@example.com", Designation: "Engineer"},
+		{Name: "Dave", Email: "dave@example.com", Designation: "Engineer"},
+	} {
+		a.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, mem.CreateAttendee(context.Background(), &a))
+	}
+
+	type page struct {
+		Items         []models.Attendee `json:"items"`
+		NextPageToken string            `json:"nextPageToken"`
+	}
+	get := func(query string) (int, page) {
+		req := httptest.NewRequest("GET", "/api/attendees?"+query, nil)
+		w := httptest.NewRecorder()
+		handler.GetAttendees(w, req)
+		var p page
+		json.Unmarshal(w.Body.Bytes(), &p)
+		return w.Code, p
+	}
+	names := func(p page) []string {
+		var out []string
+		for _, a := range p.Items {
+			out = append(out, a.Name)
+		}
+		return out
+	}
+
+	code, p := get("limit=2&orderBy=name")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"Alice", "Bob"}, names(p))
+	require.NotEmpty(t, p.NextPageToken)
+
+	_, p = get("limit=2&orderBy=name&pageToken=" + p.NextPageToken)
+	assert.Equal(t, []string{"Carol", "Dave"}, names(p))
+	assert.Empty(t, p.NextPageToken)
+
+	_, p = get("orderBy=createdAt%20desc&designation=Engineer&limit=2")
+	assert.Equal(t, []string{"Dave", "Bob"}, names(p))
+	_, p = get("orderBy=createdAt%20desc&designation=Engineer&limit=2&pageToken=" + p.NextPageToken)
+	assert.Equal(t, []string{"Carol"}, names(p))
+
+	code, first := get("limit=1&orderBy=name")
+	require.Equal(t, http.StatusOK, code)
+	code, _ = get("limit=1&orderBy=email&pageToken=" + first.NextPageToken)
+	assert.Equal(t, http.StatusBadRequest, code, "tokens only continue the ordering they came from")
+
+	code, _ = get("limit=0")
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	code, _ = get("orderBy=password")
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	code, _ = get("pageToken=garbage")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+// countingStore counts calls to AttendeeStats.
+type countingStore struct {
+	*memory.Store
+	calls int
+}
+
+func (c *countingStore) AttendeeStats(ctx context.Context) (store.AttendeeStats, error) {
+	c.calls++
+	return c.Store.AttendeeStats(ctx)
+}
+
+func TestAttendeeCountIsCached(t *testing.T) {
+	mem := &countingStore{Store: memory.New()}
+	handler := NewHandlers(store.Stores{Attendees: mem, Speakers: mem, Sessions: mem, Admins: mem, Reminders: mem, Enrollments: mem, Workshops: mem, Imports: mem}, "test_collection")
 
-	// This test requires a real firestore client, so we'll just test the password logic
-	// In a real scenario, you'd inject a mock
-	handler := NewHandlers(nil, "test_collection")
-	assert.Equal(t, "envpassword", handler.adminPassword)
-	assert.Equal(t, "test_collection", handler.subcollectionID)
+	count := func() int {
+		w := httptest.NewRecorder()
+		handler.GetAttendeeCount(w, httptest.NewRequest("GET", "/api/attendees/count", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		var response map[string]int
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response["count"]
+	}
+
+	assert.Equal(t, 0, count())
+	assert.Equal(t, 0, count())
+	assert.Equal(t, 1, mem.calls, "repeat requests are served from the cache")
+
+	// Registering through this instance refreshes the count immediately
+	w := httptest.NewRecorder()
+	handler.RegisterAttendee(w, httptest.NewRequest("POST", "/api/attendees",
+		bytes.NewBufferString(`{"name":"Ada","email":"ada@example.com","designation":"Engineer"}`)))
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 1, count())
+	assert.Equal(t, 2, mem.calls)
+
+	// Otherwise the cached value is reused until it expires
+	require.NoError(t, mem.CreateAttendee(context.Background(), &models.Attendee{Name: "Bob", Email: "bob@example.com"}))
+	assert.Equal(t, 1, count())
+	handler.attendeeCount.now = func() time.Time { return time.Now().Add(attendeeCountTTL) }
+	assert.Equal(t, 2, count())
+}
+
+func TestCapacityAndWaitlist(t *testing.T) {
+	mem := memory.New()
+	handler := newTestHandlers(mem)
+	router := mux.NewRouter()
+	router.HandleFunc("/api/attendees", handler.RegisterAttendee).Methods("POST")
+	router.HandleFunc("/api/attendees", handler.GetAttendees).Methods("GET")
+	router.HandleFunc("/api/attendees/count", handler.GetAttendeeCount).Methods("GET")
+	router.HandleFunc("/api/admin/attendees/{id}/cancel", handler.CancelAttendee).Methods("POST")
+	router.HandleFunc("/api/admin/waitlist", handler.GetWaitlist).Methods("GET")
+	router.HandleFunc("/api/admin/settings", handler.UpdateEventSettings).Methods("PUT")
+
+	do := func(method, path, body string, out interface{}) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		if out != nil {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), out), w.Body.String())
+		}
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnprocessableEntity, do("PUT", "/api/admin/settings", `{"capacity":-1}`, nil))
+	require.Equal(t, http.StatusOK, do("PUT", "/api/admin/settings", `{"capacity":1}`, nil))
+
+	var first, second models.Attendee
+	require.Equal(t, http.StatusCreated, do("POST", "/api/attendees", `{"name":"Ada","email":"ada@example.com","designation":"Engineer"}`, &first))
+	assert.Equal(t, models.StatusRegistered, first.Status)
+	require.Equal(t, http.StatusCreated, do("POST", "/api/attendees", `{"name":"Bob","email":"bob@example.com","designation":"Engineer","status":"registered"}`, &second))
+	assert.Equal(t, models.StatusWaitlisted, second.Status, "clients cannot choose their status")
+	assert.Equal(t, 1, second.WaitlistPosition)
+
+	var count attendeeCount
+	require.Equal(t, http.StatusOK, do("GET", "/api/attendees/count", "", &count))
+	assert.Equal(t, 1, count.Registered)
+	assert.Equal(t, 1, count.Waitlisted)
+	require.NotNil(t, count.Remaining)
+	assert.Equal(t, 0, *count.Remaining)
+
+	var waitlist []models.Attendee
+	require.Equal(t, http.StatusOK, do("GET", "/api/admin/waitlist", "", &waitlist))
+	require.Len(t, waitlist, 1)
+	assert.Equal(t, second.ID, waitlist[0].ID)
+
+	for status, want := range map[string]string{models.StatusRegistered: first.ID, models.StatusWaitlisted: second.ID} {
+		var list store.Page[models.Attendee]
+		require.Equal(t, http.StatusOK, do("GET", "/api/attendees?status="+status, "", &list))
+		require.Len(t, list.Items, 1, status)
+		assert.Equal(t, want, list.Items[0].ID)
+	}
+
+	var cancelled struct {
+		Attendee models.Attendee   `json:"attendee"`
+		Promoted []models.Attendee `json:"promoted"`
+	}
+	require.Equal(t, http.StatusOK, do("POST", "/api/admin/attendees/"+first.ID+"/cancel", "", &cancelled))
+	assert.Equal(t, models.StatusCancelled, cancelled.Attendee.Status)
+	require.Len(t, cancelled.Promoted, 1)
+	assert.Equal(t, second.ID, cancelled.Promoted[0].ID)
+	assert.Equal(t, http.StatusNotFound, do("POST", "/api/admin/attendees/missing/cancel", "", nil))
+
+	count = attendeeCount{}
+	require.Equal(t, http.StatusOK, do("GET", "/api/attendees/count", "", &count))
+	assert.Equal(t, 1, count.Count)
+	assert.Equal(t, 0, count.Waitlisted)
+
+	require.Equal(t, http.StatusOK, do("PUT", "/api/admin/settings", `{"capacity":0}`, nil))
+	count = attendeeCount{}
+	require.Equal(t, http.StatusOK, do("GET", "/api/attendees/count", "", &count))
+	assert.Nil(t, count.Capacity, "unlimited events report no capacity")
+	assert.Nil(t, count.Remaining)
+}
+
+func TestExportAttendees(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	handler := newTestHandlers(mem)
+	handler.location = time.UTC
+
+	createdAt := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	require.NoError(t, mem.CreateAttendee(ctx, &models.Attendee{Name: `Doe, "JD"`, Email: "jd@example.com", Designation: "Head of\nSales", CreatedAt: createdAt}))
+	for i := 0; i < exportPageSize; i++ {
+		require.NoError(t, mem.CreateAttendee(ctx, &models.Attendee{
+			Name: fmt.Sprintf("Attendee %03d", i), Email: fmt.Sprintf("a%03d@example.com", i), Designation: "Engineer",
+			CreatedAt: createdAt.Add(time.Duration(i+1) * time.Minute),
+		}))
+	}
+
+	export := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ExportAttendees(w, httptest.NewRequest("GET", "/api/attendees/export"+query, nil))
+		return w
+	}
+
+	w := export("")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "text/csv; charset=utf-8; header=present", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="attendees.csv"`, w.Header().Get("Content-Disposition"))
+	body := w.Body.String()
+	assert.True(t, strings.HasPrefix(body, "ID,Name,Email,Designation,Status,Registered At,Promoted At,Cancelled At,Checked In At,Checked In By\r\n"))
+	assert.Contains(t, body, `,"Doe, ""JD""",jd@example.com,"Head of`+"\r\n"+`Sales",registered,2026-03-01 09:30:00,,,,`+"\r\n")
+
+	records, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, exportPageSize+2, "every attendee across pages, plus the header")
+	assert.Equal(t, `Doe, "JD"`, records[1][1])
+	assert.Equal(t, "Head of\nSales", records[1][3])
+	assert.Equal(t, "Attendee 499", records[len(records)-1][1])
+	for _, rec := range records {
+		assert.Len(t, rec, len(exportColumns))
+	}
+
+	w = export("?columns=email,%20name&designation=Engineer&orderBy=name%20desc")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	records, err = csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, exportPageSize+1)
+	assert.Equal(t, []string{"Email", "Name"}, records[0])
+	assert.Equal(t, []string{"a499@example.com", "Attendee 499"}, records[1])
+
+	w = export("?format=xlsx&columns=name")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, xlsx.ContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, "PK", w.Body.String()[:2])
+
+	assert.Equal(t, http.StatusUnprocessableEntity, export("?format=pdf").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, export("?columns=name,password").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, export("?columns=name,name").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, export("?orderBy=password").Code)
+}
+
+func TestExportEscapesFormulas(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	handler := newTestHandlers(mem)
+	for i, name := range []string{`=HYPERLINK("https://attacker.example","x")`, "+1", "-1", "@SUM(A1)", "\tTab", "\rReturn", "Ada = fine"} {
+		require.NoError(t, mem.CreateAttendee(ctx, &models.Attendee{
+			Name: name, Email: fmt.Sprintf("a%d@example.com", i), Designation: "Engineer",
+			CreatedAt: time.Date(2026, 3, 1, 9, i, 0, 0, time.UTC),
+		}))
+	}
+
+	w := httptest.NewRecorder()
+	handler.ExportAttendees(w, httptest.NewRequest("GET", "/api/attendees/export?columns=name", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	records, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	var names []string
+	for _, rec := range records[1:] {
+		names = append(names, rec[0])
+	}
+	assert.Equal(t, []string{`'=HYPERLINK("https://attacker.example","x")`, "'+1", "'-1", "'@SUM(A1)", "'\tTab", "'Return", "Ada = fine"}, names, "CRLF output drops a lone CR")
 }
 
-func TestNewHandlersDefaultPassword(t *testing.T) {
-	os.Unsetenv("ADMIN_PASSWORD")
-	
-	handler := NewHandlers(nil, "test_collection")
-	assert.Equal(t, "admin123", handler.adminPassword)
+func TestManageRegistrationWithToken(t *testing.T) {
+	handler := newTestHandlers(memory.New())
+	router := mux.NewRouter()
+	router.HandleFunc("/api/attendees", handler.RegisterAttendee).Methods("POST")
+	router.HandleFunc("/api/attendees/count", handler.GetAttendeeCount).Methods("GET")
+	router.HandleFunc("/api/registrations/{token}", handler.GetRegistration).Methods("GET")
+	router.HandleFunc("/api/registrations/{token}", handler.UpdateRegistration).Methods("PUT")
+	router.HandleFunc("/api/registrations/{token}", handler.CancelRegistration).Methods("DELETE")
+
+	do := func(method, path, body string, out interface{}) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		if out != nil {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), out), w.Body.String())
+		}
+		return w.Code
+	}
+
+	var ada, bob registration
+	require.Equal(t, http.StatusCreated, do("POST", "/api/attendees", `{"name":"Ada","email":"ada@example.com","designation":"Engineer"}`, &ada))
+	require.Equal(t, http.StatusCreated, do("POST", "/api/attendees", `{"name":"Bob","email":"bob@example.com","designation":"Engineer"}`, &bob))
+	require.NotEmpty(t, ada.ManageToken)
+	path := "/api/registrations/" + ada.ManageToken
+
+	var got models.Attendee
+	require.Equal(t, http.StatusOK, do("GET", path, "", &got))
+	assert.Equal(t, ada.ID, got.ID)
+
+	assert.Equal(t, http.StatusNotFound, do("GET", "/api/registrations/"+ada.ID+".forged", "", nil))
+	assert.Equal(t, http.StatusNotFound, do("GET", "/api/registrations/"+handler.links.Sign("missing"), "", nil))
+
+	require.Equal(t, http.StatusOK, do("PUT", path, `{"name":"Ada Lovelace","email":"ada@example.org","designation":"Researcher"}`, &got))
+	assert.Equal(t, "Ada Lovelace", got.Name)
+	assert.Equal(t, "ada@example.org", got.Email)
+	assert.Equal(t, models.StatusRegistered, got.Status)
+	assert.Equal(t, http.StatusConflict, do("PUT", path, `{"name":"Ada","email":"bob@example.com","designation":"Engineer"}`, nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, do("PUT", path, `{"name":"","email":"ada@example.org","designation":"Engineer"}`, nil))
+
+	require.Equal(t, http.StatusOK, do("DELETE", path, "", &got))
+	assert.Equal(t, models.StatusCancelled, got.Status)
+	assert.NotNil(t, got.CancelledAt)
+
+	// The registration is kept but can no longer be changed, and its email is
+	// free to register again.
+	require.Equal(t, http.StatusOK, do("GET", path, "", &got))
+	assert.Equal(t, models.StatusCancelled, got.Status)
+	assert.Equal(t, http.StatusConflict, do("PUT", path, `{"name":"Ada","email":"ada@example.org","designation":"Engineer"}`, nil))
+	assert.Equal(t, http.StatusCreated, do("POST", "/api/attendees", `{"name":"Ada","email":"ada@example.org","designation":"Engineer"}`, nil))
+
+	var count attendeeCount
+	require.Equal(t, http.StatusOK, do("GET", "/api/attendees/count", "", &count))
+	assert.Equal(t, 2, count.Registered)
+}
+
+func TestRegistrationEmails(t *testing.T) {
+	mem := memory.New()
+	_, err := mem.UpdateEventSettings(context.Background(), &models.EventSettings{Capacity: 1})
+	require.NoError(t, err)
+	outbox := &mailer.Outbox{}
+	handler := newMailingTestHandlers(mem, outbox)
+	handler.publicURL = "https://workshop.example.com"
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/attendees", handler.RegisterAttendee).Methods("POST")
+	router.HandleFunc("/api/registrations/{token}", handler.CancelRegistration).Methods("DELETE")
+	// Links never follow the Host header, which the client controls.
+	do := func(method, path, body string, out interface{}) int {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Host = "attacker.example"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if out != nil {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), out), w.Body.String())
+		}
+		return w.Code
+	}
+
+	var ada registration
+	require.Equal(t, http.StatusCreated, do("POST", "/api/attendees", `{"name":"Ada","email":"ada@example.com","designation":"Engineer"}`, &ada))
+	require.Equal(t, http.StatusCreated, do("POST", "/api/attendees", `{"name":"Bob","email":"bob@example.com","designation":"Engineer"}`, nil))
+
+	// Cancelling twice only sends one cancellation email.
+	require.Equal(t, http.StatusOK, do("DELETE", "/api/registrations/"+ada.ManageToken, "", nil))
+	require.Equal(t, http.StatusOK, do("DELETE", "/api/registrations/"+ada.ManageToken, "", nil))
+	require.NoError(t, handler.Close(context.Background()))
+
+	sent := outbox.Sent()
+	require.Len(t, sent, 4)
+	got := map[string]string{}
+	for _, msg := range sent {
+		got[msg.To+" "+msg.Subject] = msg.Text
+	}
+	assert.Contains(t, got, "ada@example.com You're registered for "+defaultEventName)
+	assert.Contains(t, got, "bob@example.com You're on the waitlist for "+defaultEventName)
+	assert.Contains(t, got, "ada@example.com Your registration for "+defaultEventName+" is cancelled")
+	assert.Contains(t, got["ada@example.com You're registered for "+defaultEventName], "https://workshop.example.com/registration/"+ada.ManageToken)
+
+	var promoted int
+	for _, msg := range sent {
+		assert.NotContains(t, msg.Text+msg.HTML, "attacker.example")
+		if msg.To == "bob@example.com" && strings.Contains(msg.Subject, "A seat opened up") {
+			promoted++
+			assert.Contains(t, msg.Text, "https://workshop.example.com/registration/")
+		}
+	}
+	assert.Equal(t, 1, promoted)
+
+	// Without PUBLIC_URL the links are left out.
+	outbox = &mailer.Outbox{}
+	handler = newMailingTestHandlers(mem, outbox)
+	router = mux.NewRouter()
+	router.HandleFunc("/api/attendees", handler.RegisterAttendee).Methods("POST")
+	require.Equal(t, http.StatusCreated, do("POST", "/api/attendees", `{"name":"Cy","email":"cy@example.com","designation":"Engineer"}`, nil))
+	require.NoError(t, handler.Close(context.Background()))
+	require.Len(t, outbox.Sent(), 1)
+	assert.NotContains(t, outbox.Sent()[0].Text, "/registration")
+	assert.NotContains(t, outbox.Sent()[0].HTML, "attacker.example")
+}
+
+func TestWorkshops(t *testing.T) {
+	outbox := &mailer.Outbox{}
+	handler := newMailingTestHandlers(memory.New(), outbox)
+	require.NoError(t, handler.EnsureDefaultWorkshop(context.Background()))
+	handler.publicURL = "https://workshop.example.com"
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/workshops", handler.ListWorkshops).Methods("GET")
+	router.HandleFunc("/api/workshops", handler.CreateWorkshop).Methods("POST")
+	router.HandleFunc("/api/workshops/{workshopId}", handler.GetWorkshop).Methods("GET")
+	router.HandleFunc("/api/workshops/{workshopId}", handler.UpdateWorkshop).Methods("PUT")
+	router.HandleFunc("/api/workshops/{workshopId}", handler.DeleteWorkshop).Methods("DELETE")
+	for _, prefix := range []string{"/api", "/api/workshops/{workshopId}"} {
+		router.HandleFunc(prefix+"/attendees", handler.InWorkshop((*Handlers).RegisterAttendee)).Methods("POST")
+		router.HandleFunc(prefix+"/attendees/count", handler.InWorkshop((*Handlers).GetAttendeeCount)).Methods("GET")
+		router.HandleFunc(prefix+"/registrations/{token}", handler.InWorkshop((*Handlers).GetRegistration)).Methods("GET")
+	}
+	do := func(method, path, body string, out interface{}) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		if out != nil {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), out), w.Body.String())
+		}
+		return w.Code
+	}
+	registered := func(prefix string) int {
+		var count attendeeCount
+		require.Equal(t, http.StatusOK, do("GET", prefix+"/attendees/count", "", &count))
+		return count.Registered
+	}
+
+	var created workshopResponse
+	require.Equal(t, http.StatusCreated, do("POST", "/api/workshops", `{"id":"spring-2026","name":"Spring Workshop"}`, &created))
+	assert.False(t, created.Default)
+	assert.Equal(t, http.StatusConflict, do("POST", "/api/workshops", `{"id":"spring-2026","name":"Again"}`, nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, do("POST", "/api/workshops", `{"id":"Spring 2026","name":"Spring"}`, nil))
+	require.Equal(t, http.StatusCreated, do("POST", "/api/workshops", `{"name":"Unnamed"}`, &created))
+	assert.NotEmpty(t, created.ID)
+
+	var list []workshopResponse
+	require.Equal(t, http.StatusOK, do("GET", "/api/workshops", "", &list))
+	require.Len(t, list, 3)
+	defaults := map[string]bool{}
+	for _, ws := range list {
+		defaults[ws.ID] = ws.Default
+	}
+	assert.Equal(t, map[string]bool{"spring-2026": false, "test_collection": true, created.ID: false}, defaults)
+
+	// Registrations are kept per workshop; the legacy routes and the default
+	// workshop's ID serve the same data.
+	var ada registration
+	require.Equal(t, http.StatusCreated, do("POST", "/api/workshops/spring-2026/attendees", `{"name":"Ada","email":"ada@example.com","designation":"Engineer"}`, &ada))
+	require.Equal(t, http.StatusCreated, do("POST", "/api/attendees", `{"name":"Ada","email":"ada@example.com","designation":"Engineer"}`, nil))
+	require.Equal(t, http.StatusCreated, do("POST", "/api/workshops/test_collection/attendees", `{"name":"Bob","email":"bob@example.com","designation":"Engineer"}`, nil))
+	assert.Equal(t, 1, registered("/api/workshops/spring-2026"))
+	assert.Equal(t, 2, registered("/api"))
+	assert.Equal(t, 2, registered("/api/workshops/test_collection"))
+	assert.Equal(t, http.StatusNotFound, do("GET", "/api/workshops/missing/attendees/count", "", nil))
+
+	require.Equal(t, http.StatusOK, do("GET", "/api/workshops/spring-2026/registrations/"+ada.ManageToken, "", nil))
+	assert.Equal(t, http.StatusNotFound, do("GET", "/api/registrations/"+ada.ManageToken, "", nil))
+
+	// Emails name the workshop and link to its pages.
+	require.Equal(t, http.StatusOK, do("PUT", "/api/workshops/spring-2026", `{"name":"Spring Workshop 2026"}`, nil))
+	require.Equal(t, http.StatusCreated, do("POST", "/api/workshops/spring-2026/attendees", `{"name":"Cy","email":"cy@example.com","designation":"Engineer"}`, nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, do("PUT", "/api/workshops/spring-2026", `{"id":"other","name":"Spring"}`, nil))
+	assert.Equal(t, http.StatusNotFound, do("PUT", "/api/workshops/missing", `{"name":"Missing"}`, nil))
+	require.NoError(t, handler.Close(context.Background()))
+	var confirmation *mailer.Message
+	for _, msg := range outbox.Sent() {
+		if msg.To == "cy@example.com" {
+			confirmation = msg
+		}
+	}
+	require.NotNil(t, confirmation)
+	assert.Equal(t, "You're registered for Spring Workshop 2026", confirmation.Subject)
+	assert.Contains(t, confirmation.Text, "https://workshop.example.com/workshops/spring-2026/registration/")
+
+	assert.Equal(t, http.StatusConflict, do("DELETE", "/api/workshops/test_collection", "", nil))
+	require.Equal(t, http.StatusOK, do("DELETE", "/api/workshops/spring-2026", "", nil))
+	assert.Equal(t, http.StatusNotFound, do("GET", "/api/workshops/spring-2026", "", nil))
+	assert.Equal(t, http.StatusNotFound, do("GET", "/api/workshops/spring-2026/attendees/count", "", nil))
+	assert.Equal(t, 2, registered("/api"))
+}
+
+func TestCloneWorkshop(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	handler := newTestHandlers(mem)
+	require.NoError(t, handler.EnsureDefaultWorkshop(ctx))
+
+	ada := &models.Speaker{Name: "Ada", Bio: "Engineer"}
+	require.NoError(t, mem.CreateSpeaker(ctx, ada))
+	startsAt := time.Date(2027, 3, 28, 4, 30, 0, 0, time.UTC) // 10:00 in Kolkata
+	keynote := &models.Session{Title: "Keynote", SpeakerIDs: []string{ada.ID}, StartsAt: startsAt, EndsAt: startsAt.Add(time.Hour), Room: "Hall"}
+	require.NoError(t, mem.CreateSession(ctx, keynote))
+	require.NoError(t, mem.CreateSession(ctx, &models.Session{Title: "To be scheduled"}))
+	require.NoError(t, mem.CreateAttendee(ctx, &models.Attendee{Name: "Grace", Email: "grace@example.com"}))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/workshops/{workshopId}/clone", handler.CloneWorkshop).Methods("POST")
+	do := func(path, body string, out interface{}) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", path, bytes.NewBufferString(body)))
+		if out != nil {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), out), w.Body.String())
+		}
+		return w.Code
+	}
+
+	var cloned cloneResponse
+	require.Equal(t, http.StatusCreated, do("/api/workshops/test_collection/clone", `{"id":"spring","name":"Spring","offsetDays":7}`, &cloned))
+	assert.Equal(t, "spring", cloned.Workshop.ID)
+	assert.Equal(t, 1, cloned.Speakers)
+	assert.Equal(t, 2, cloned.Sessions)
+
+	spring := mem.Scoped("spring")
+	speakers, err := spring.Speakers.ListSpeakers(ctx)
+	require.NoError(t, err)
+	require.Len(t, speakers, 1)
+	assert.NotEqual(t, ada.ID, speakers[0].ID)
+	assert.Equal(t, "Engineer", speakers[0].Bio)
+
+	sessions, err := spring.Sessions.ListSessions(ctx)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	byTitle := map[string]*models.Session{}
+	for _, se := range sessions {
+		assert.NotEqual(t, keynote.ID, se.ID)
+		byTitle[se.Title] = se
+	}
+	moved := byTitle["Keynote"]
+	assert.True(t, startsAt.AddDate(0, 0, 7).Equal(moved.StartsAt))
+	assert.Equal(t, "2027-04-04", moved.Date)
+	assert.Equal(t, "10:00", moved.Time)
+	assert.Equal(t, []string{speakers[0].ID}, moved.SpeakerIDs)
+	assert.Equal(t, "Hall", moved.Room)
+	assert.True(t, byTitle["To be scheduled"].StartsAt.IsZero())
+
+	stats, err := spring.Attendees.AttendeeStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, store.AttendeeStats{}, stats)
+	jobs, err := spring.Reminders.ListReminders(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, jobs)
+
+	// A clone of the clone needs no offset, and the source is unchanged.
+	require.Equal(t, http.StatusCreated, do("/api/workshops/spring/clone", `{"name":"Summer"}`, &cloned))
+	assert.NotEmpty(t, cloned.Workshop.ID)
+	assert.Equal(t, 2, cloned.Sessions)
+	sessions, err = mem.ListSessions(ctx)
+	require.NoError(t, err)
+	for _, se := range sessions {
+		if se.ID == keynote.ID {
+			assert.True(t, startsAt.Equal(se.StartsAt))
+		}
+	}
+
+	assert.Equal(t, http.StatusConflict, do("/api/workshops/test_collection/clone", `{"id":"spring","name":"Again"}`, nil))
+	assert.Equal(t, http.StatusNotFound, do("/api/workshops/missing/clone", `{"name":"Missing"}`, nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, do("/api/workshops/spring/clone", `{"name":"Later","offsetDays":100000}`, nil))
+}
+
+func TestSessionReminders(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	outbox := &mailer.Outbox{}
+	handler := newMailingTestHandlers(mem, outbox)
+	router := mux.NewRouter()
+	router.HandleFunc("/api/sessions", handler.CreateSession).Methods("POST")
+	router.HandleFunc("/api/sessions/{id}", handler.DeleteSession).Methods("DELETE")
+	router.HandleFunc("/api/admin/reminders", handler.GetReminders).Methods("GET")
+	router.HandleFunc("/api/admin/reminders/{id}/cancel", handler.CancelReminder).Methods("POST")
+	router.HandleFunc("/api/admin/reminders/{id}/resend", handler.ResendReminder).Methods("POST")
+	do := func(method, path, body string, out interface{}) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		if out != nil {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), out), w.Body.String())
+		}
+		return w.Code
+	}
+
+	var session models.Session
+	require.Equal(t, http.StatusCreated, do("POST", "/api/sessions", `{"title":"Keynote","date":"2030-05-10","time":"10:00"}`, &session))
+
+	var list []models.Reminder
+	require.Equal(t, http.StatusOK, do("GET", "/api/admin/reminders", "", &list))
+	require.Len(t, list, 2)
+	assert.Equal(t, "24h", list[0].Lead)
+	assert.Equal(t, models.ReminderPending, list[0].Status)
+
+	var reminder models.Reminder
+	require.Equal(t, http.StatusOK, do("POST", "/api/admin/reminders/"+list[0].ID+"/cancel", "", &reminder))
+	assert.Equal(t, models.ReminderCancelled, reminder.Status)
+	require.Equal(t, http.StatusOK, do("GET", "/api/admin/reminders?status=pending", "", &list))
+	assert.Len(t, list, 1)
+
+	require.Equal(t, http.StatusAccepted, do("POST", "/api/admin/reminders/"+reminder.ID+"/resend", "", &reminder))
+	assert.Equal(t, models.ReminderPending, reminder.Status)
+	assert.Equal(t, http.StatusNotFound, do("POST", "/api/admin/reminders/missing/cancel", "", nil))
+
+	// The resent reminder reaches the session's enrollees only.
+	ada := &models.Attendee{Name: "Ada", Email: "ada@example.com", Designation: "Engineer"}
+	bob := &models.Attendee{Name: "Bob", Email: "bob@example.com", Designation: "Engineer"}
+	for _, a := range []*models.Attendee{ada, bob} {
+		require.NoError(t, mem.CreateAttendee(ctx, a))
+	}
+	_, err := mem.Enroll(ctx, session.ID, ada.ID)
+	require.NoError(t, err)
+	require.NoError(t, handler.reminders.SendDue(ctx))
+
+	require.Equal(t, http.StatusOK, do("DELETE", "/api/sessions/"+session.ID, "", nil))
+	require.Equal(t, http.StatusOK, do("GET", "/api/admin/reminders?sessionId="+session.ID+"&status=cancelled", "", &list))
+	assert.Len(t, list, 1, "the sent reminder stays sent")
+
+	require.NoError(t, handler.Close(ctx))
+	require.Len(t, outbox.Sent(), 1)
+	assert.Equal(t, "ada@example.com", outbox.Sent()[0].To)
+}
+
+func TestTicketsAndCheckIn(t *testing.T) {
+	mem := memory.New()
+	_, err := mem.UpdateEventSettings(context.Background(), &models.EventSettings{Capacity: 1})
+	require.NoError(t, err)
+	handler := newTestHandlers(mem)
+	_, err = handler.admins.Create(context.Background(), "door1", "password123", models.RoleCheckin)
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/attendees", handler.RegisterAttendee).Methods("POST")
+	router.HandleFunc("/api/registrations/{token}/ticket", handler.GetRegistrationTicket).Methods("GET")
+	router.HandleFunc("/api/admin/login", handler.AdminLogin).Methods("POST")
+	admin := router.NewRoute().Subrouter()
+	admin.Use(handler.RequireAdmin)
+	can := func(perm auth.Permission, f http.HandlerFunc) http.Handler { return handler.Require(perm)(f) }
+	admin.Handle("/api/checkin", can(auth.PermCheckIn, handler.CheckIn)).Methods("POST")
+	admin.Handle("/api/admin/checkin/stats", can(auth.PermViewAttendees, handler.GetCheckInStats)).Methods("GET")
+
+	token := ""
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	var ada, bob registration
+	w := do("POST", "/api/attendees", `{"name":"Ada","email":"ada@example.com","designation":"Engineer"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &ada))
+	w = do("POST", "/api/attendees", `{"name":"Bob","email":"bob@example.com","designation":"Engineer","checkedInBy":"me"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &bob))
+	assert.Empty(t, bob.CheckedInBy, "registrants cannot check themselves in")
+	require.NotEmpty(t, ada.Ticket)
+	assert.Empty(t, bob.Ticket, "the waitlist has no tickets")
+
+	w = do("GET", "/api/registrations/"+ada.ManageToken+"/ticket", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.True(t, bytes.HasPrefix(w.Body.Bytes(), []byte("\x89PNG")))
+	assert.Equal(t, http.StatusConflict, do("GET", "/api/registrations/"+bob.ManageToken+"/ticket", "").Code)
+	assert.Equal(t, http.StatusNotFound, do("GET", "/api/registrations/"+ada.Ticket+"/ticket", "").Code, "a ticket is not a manage token")
+
+	var login map[string]string
+	require.NoError(t, json.Unmarshal(do("POST", "/api/admin/login", `{"username":"door1","password":"password123"}`).Body.Bytes(), &login))
+	token = login["token"]
+
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/api/checkin", `{"ticket":"`+ada.ManageToken+`"}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, do("POST", "/api/checkin", `{}`).Code)
+	assert.Equal(t, http.StatusConflict, do("POST", "/api/checkin", `{"ticket":"`+handler.tickets.Sign(bob.ID)+`"}`).Code)
+
+	w = do("POST", "/api/checkin", `{"ticket":"`+ada.Ticket+`"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var checkedIn models.Attendee
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &checkedIn))
+	require.NotNil(t, checkedIn.CheckedInAt)
+	assert.Equal(t, "door1", checkedIn.CheckedInBy)
+
+	w = do("POST", "/api/checkin", `{"ticket":"`+ada.Ticket+`"}`)
+	require.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "Already checked in")
+
+	var stats checkInStats
+	require.NoError(t, json.Unmarshal(do("GET", "/api/admin/checkin/stats", "").Body.Bytes(), &stats))
+	assert.Equal(t, 1, stats.Registered)
+	assert.Equal(t, 1, stats.CheckedIn)
+	assert.Equal(t, 0, stats.NotCheckedIn)
+	assert.Equal(t, map[string]int{"door1": 1}, stats.ByOperator)
+	assert.NotNil(t, stats.LastCheckInAt)
+}
+
+func TestSessionEnrollment(t *testing.T) {
+	mem := memory.New()
+	_, err := mem.UpdateEventSettings(context.Background(), &models.EventSettings{Capacity: 2})
+	require.NoError(t, err)
+	handler := newTestHandlers(mem)
+	router := mux.NewRouter()
+	router.HandleFunc("/api/attendees", handler.RegisterAttendee).Methods("POST")
+	router.HandleFunc("/api/sessions", handler.GetSessions).Methods("GET")
+	router.HandleFunc("/api/sessions", handler.CreateSession).Methods("POST")
+	router.HandleFunc("/api/sessions/{id}", handler.DeleteSession).Methods("DELETE")
+	router.HandleFunc("/api/registrations/{token}", handler.CancelRegistration).Methods("DELETE")
+	router.HandleFunc("/api/registrations/{token}/sessions", handler.GetRegistrationSessions).Methods("GET")
+	router.HandleFunc("/api/registrations/{token}/sessions/{sessionId}", handler.EnrollRegistration).Methods("PUT")
+	router.HandleFunc("/api/registrations/{token}/sessions/{sessionId}", handler.UnenrollRegistration).Methods("DELETE")
+	router.HandleFunc("/api/admin/sessions/{id}/enrollees", handler.GetSessionEnrollees).Methods("GET")
+	router.HandleFunc("/api/admin/sessions/{id}/enrollees/{attendeeId}", handler.EnrollAttendee).Methods("PUT")
+	do := func(method, path, body string, out interface{}) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		if out != nil {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), out), w.Body.String())
+		}
+		return w.Code
+	}
+
+	var ada, bob, eve registration
+	require.Equal(t, http.StatusCreated, do("POST", "/api/attendees", `{"name":"Ada","email":"ada@example.com","designation":"Engineer"}`, &ada))
+	require.Equal(t, http.StatusCreated, do("POST", "/api/attendees", `{"name":"Bob","email":"bob@example.com","designation":"Engineer"}`, &bob))
+	require.Equal(t, http.StatusCreated, do("POST", "/api/attendees", `{"name":"Eve","email":"eve@example.com","designation":"Engineer"}`, &eve))
+	require.Equal(t, models.StatusWaitlisted, eve.Status)
+
+	var workshop, talk, lunch models.Session
+	require.Equal(t, http.StatusCreated, do("POST", "/api/sessions", `{"title":"Workshop","date":"2030-05-10","time":"10:00","duration":90,"capacity":1}`, &workshop))
+	require.Equal(t, http.StatusCreated, do("POST", "/api/sessions", `{"title":"Talk","date":"2030-05-10","time":"11:00"}`, &talk))
+	require.Equal(t, http.StatusCreated, do("POST", "/api/sessions", `{"title":"Lunch","date":"2030-05-10","time":"11:30","duration":60}`, &lunch))
+	assert.Equal(t, http.StatusUnprocessableEntity, do("POST", "/api/sessions", `{"title":"Bad","date":"2030-05-10","time":"11:30","capacity":-1}`, nil))
+
+	enroll := func(token, sessionID string) int {
+		return do("PUT", "/api/registrations/"+token+"/sessions/"+sessionID, "", nil)
+	}
+	require.Equal(t, http.StatusOK, enroll(ada.ManageToken, workshop.ID))
+	require.Equal(t, http.StatusOK, enroll(ada.ManageToken, workshop.ID), "enrolling twice is not an error")
+	assert.Equal(t, http.StatusConflict, enroll(bob.ManageToken, workshop.ID), "the workshop is full")
+	assert.Equal(t, http.StatusConflict, enroll(eve.ManageToken, talk.ID), "the waitlist cannot enroll")
+	assert.Equal(t, http.StatusNotFound, enroll(ada.ManageToken, "missing"))
+	assert.Equal(t, http.StatusNotFound, enroll("bogus", talk.ID))
+
+	var conflict map[string]string
+	require.Equal(t, http.StatusConflict, do("PUT", "/api/registrations/"+ada.ManageToken+"/sessions/"+talk.ID, "", &conflict))
+	assert.Equal(t, workshop.ID, conflict["sessionId"], "the workshop runs until 11:30")
+	require.Equal(t, http.StatusOK, enroll(ada.ManageToken, lunch.ID), "back-to-back sessions do not overlap")
+	require.Equal(t, http.StatusOK, enroll(bob.ManageToken, talk.ID))
+	assert.Equal(t, http.StatusConflict, do("PUT", "/api/admin/sessions/"+lunch.ID+"/enrollees/"+bob.ID, "", nil), "organizers get the same checks")
+
+	var schedule []models.Session
+	require.Equal(t, http.StatusOK, do("GET", "/api/registrations/"+ada.ManageToken+"/sessions", "", &schedule))
+	require.Len(t, schedule, 2)
+	assert.Equal(t, "Workshop", schedule[0].Title)
+	assert.Equal(t, 1, schedule[1].Enrolled)
+
+	var page struct{ Items []models.Session }
+	require.Equal(t, http.StatusOK, do("GET", "/api/sessions?orderBy=time", "", &page))
+	enrolled := map[string]int{}
+	for _, s := range page.Items {
+		enrolled[s.Title] = s.Enrolled
+	}
+	assert.Equal(t, map[string]int{"Workshop": 1, "Talk": 1, "Lunch": 1}, enrolled)
+
+	var enrollees []struct {
+		Name       string    `json:"name"`
+		EnrolledAt time.Time `json:"enrolledAt"`
+	}
+	require.Equal(t, http.StatusOK, do("GET", "/api/admin/sessions/"+lunch.ID+"/enrollees", "", &enrollees))
+	require.Len(t, enrollees, 1)
+	assert.Equal(t, "Ada", enrollees[0].Name)
+	assert.False(t, enrollees[0].EnrolledAt.IsZero())
+
+	// Leaving frees the place, and so does cancelling the registration,
+	// which also promotes Eve from the waitlist.
+	require.Equal(t, http.StatusOK, do("DELETE", "/api/registrations/"+ada.ManageToken+"/sessions/"+workshop.ID, "", nil))
+	require.Equal(t, http.StatusOK, do("DELETE", "/api/registrations/"+ada.ManageToken, "", nil))
+	require.Equal(t, http.StatusOK, do("GET", "/api/admin/sessions/"+lunch.ID+"/enrollees", "", &enrollees))
+	assert.Empty(t, enrollees)
+	assert.Equal(t, http.StatusOK, enroll(eve.ManageToken, workshop.ID))
+
+	// Deleting a session drops its enrollments.
+	require.Equal(t, http.StatusOK, do("DELETE", "/api/sessions/"+talk.ID, "", nil))
+	require.Equal(t, http.StatusOK, do("GET", "/api/registrations/"+bob.ManageToken+"/sessions", "", &schedule))
+	assert.Empty(t, schedule)
+	assert.Equal(t, http.StatusOK, do("PUT", "/api/admin/sessions/"+lunch.ID+"/enrollees/"+bob.ID, "", nil))
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	handler := newTestHandlers(mem)
+	router := mux.NewRouter()
+	router.HandleFunc("/api/admin/import/speakers", handler.ImportSpeakers).Methods("POST")
+	router.HandleFunc("/api/admin/import/sessions", handler.ImportSessions).Methods("POST")
+	router.HandleFunc("/api/admin/import/attendees", handler.ImportAttendees).Methods("POST")
+	do := func(path, contentType, body string, out interface{}) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		router.ServeHTTP(w, req)
+		if out != nil {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), out), w.Body.String())
+		}
+		return w.Code
+	}
+
+	var report importResponse
+	speakers := "id,name,bio\nada,Ada Lovelace,Mathematician\ngrace,,\n"
+	require.Equal(t, http.StatusOK, do("/api/admin/import/speakers?dryRun=true", "text/csv; charset=utf-8", speakers, &report))
+	assert.True(t, report.DryRun)
+	assert.Equal(t, []importer.RowError{{Row: 2, Field: "name", Message: "is required"}}, report.Errors)
+
+	report = importResponse{}
+	require.Equal(t, http.StatusUnprocessableEntity, do("/api/admin/import/speakers", "text/csv", speakers, &report))
+	assert.Equal(t, "Nothing was imported because of the errors listed", report.Error)
+	list, err := mem.ListSpeakers(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	report = importResponse{}
+	require.Equal(t, http.StatusCreated, do("/api/admin/import/speakers?format=csv", "", strings.Replace(speakers, "grace,,", "grace,Grace Hopper,", 1), &report))
+	assert.Equal(t, 2, report.Imported)
+
+	report = importResponse{}
+	sessions := `[{"title": "Keynote", "date": "2030-05-10", "time": "10:00", "speakerIds": ["ada", "grace"]}]`
+	require.Equal(t, http.StatusCreated, do("/api/admin/import/sessions", "application/json", sessions, &report))
+	assert.Equal(t, 1, report.Imported)
+	reminders, err := mem.ListReminders(ctx)
+	require.NoError(t, err)
+	assert.Len(t, reminders, 2, "imported sessions get their reminders")
+
+	report = importResponse{}
+	require.Equal(t, http.StatusCreated, do("/api/admin/import/attendees", "application/json", `[{"name": "Ada", "email": "ada@example.com", "designation": "Engineer"}]`, &report))
+	stats, err := mem.AttendeeStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Registered)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, do("/api/admin/import/attendees", "text/plain", "name\n", nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, do("/api/admin/import/attendees?format=csv&dryRun=maybe", "", "name\n", nil))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, do("/api/admin/import/attendees", "text/csv", "name\n"+strings.Repeat("x", maxImportBytes), nil))
 }
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"appdirect-workshop/internal/audit"
+	"appdirect-workshop/internal/importer"
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+// maxImportBytes bounds the size of an uploaded import file.
+const maxImportBytes = 10 << 20
+
+// ImportAttendees registers the attendees of a CSV or JSON file; see
+// importFile. Imported attendees are not emailed.
+func (h *Handlers) ImportAttendees(w http.ResponseWriter, r *http.Request) {
+	h.importFile(w, r, importer.Attendees)
+}
+
+// ImportSpeakers creates the speakers of a CSV or JSON file; see importFile.
+func (h *Handlers) ImportSpeakers(w http.ResponseWriter, r *http.Request) {
+	h.importFile(w, r, importer.Speakers)
+}
+
+// ImportSessions creates the sessions of a CSV or JSON file and schedules
+// their reminders; see importFile.
+func (h *Handlers) ImportSessions(w http.ResponseWriter, r *http.Request) {
+	h.importFile(w, r, importer.Sessions)
+}
+
+// importResponse is the import report, with the error that stopped the
+// store part way.
+type importResponse struct {
+	*importer.Report
+	Error string `json:"error,omitempty"`
+}
+
+// importFile imports the request body, read as ?format=csv or json or else
+// by its Content-Type. Every row is checked as the create endpoint would;
+// if any fails, nothing is stored and the report lists every problem with
+// a 422. ?dryRun=true only checks, answering 200 with the report either
+// way. Otherwise the rows are stored and the report returned with 201.
+func (h *Handlers) importFile(w http.ResponseWriter, r *http.Request, kind importer.Kind) {
+	q := r.URL.Query()
+	var errs models.ValidationErrors
+	format := importer.Format(q.Get("format"))
+	if format == "" {
+		format = importFormat(r.Header.Get("Content-Type"))
+	}
+	if format != importer.CSV && format != importer.JSON {
+		errs.Add("format", "must be csv or json")
+	}
+	dryRun := false
+	if v := q.Get("dryRun"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			errs.Add("dryRun", "must be true or false")
+		}
+	}
+	if len(errs) > 0 {
+		respondValidation(w, errs)
+		return
+	}
+
+	ctx := r.Context()
+	im := &importer.Importer{
+		Stores:   store.Stores{Attendees: h.attendees, Speakers: h.speakers, Sessions: h.sessions, Imports: h.imports},
+		Location: h.location,
+	}
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+	report, err := im.Import(ctx, body, importer.Options{Kind: kind, Format: format, DryRun: dryRun})
+	if report == nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Import files are limited to %d MB", maxImportBytes>>20))
+			return
+		}
+		respondError(w, http.StatusBadRequest, "Failed to read the import file")
+		return
+	}
+
+	if report.Imported > 0 {
+		for _, session := range report.Sessions {
+			if !session.StartsAt.IsZero() {
+				h.scheduleReminders(ctx, session)
+			}
+		}
+		if kind == importer.Attendees {
+			h.attendeeCount.invalidate()
+		}
+		entry := audit.Entry{Action: audit.Imported, IP: h.clientIP(r), Detail: fmt.Sprintf("%d %s from %s", report.Imported, kind, format)}
+		if admin, ok := currentAdmin(r); ok {
+			entry.Actor = admin.Username
+		}
+		h.audit.Record(ctx, entry)
+	}
+
+	switch {
+	case err != nil:
+		log.Printf("Importing %s after %d rows: %v", kind, report.Imported, err)
+		respondJSON(w, http.StatusInternalServerError, importResponse{Report: report, Error: "Failed to store the imported " + string(kind)})
+	case dryRun:
+		respondJSON(w, http.StatusOK, importResponse{Report: report})
+	case len(report.Errors) > 0:
+		respondJSON(w, http.StatusUnprocessableEntity, importResponse{Report: report, Error: "Nothing was imported because of the errors listed"})
+	default:
+		respondJSON(w, http.StatusCreated, importResponse{Report: report})
+	}
+}
+
+// importFormat picks the format matching a Content-Type, or "" when it names
+// neither.
+func importFormat(contentType string) importer.Format {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return importer.CSV
+	case "application/json":
+		return importer.JSON
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+// Page sizes for list endpoints.
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// listOptions reads limit, pageToken, orderBy ("name" or "createdAt desc")
+// and exact-match filters named after fields, such as designation=Engineer,
+// from the query string. Other parameters are ignored.
+func listOptions(r *http.Request, fields map[string]store.FieldKind) (store.ListOptions, error) {
+	q := r.URL.Query()
+	opts := store.ListOptions{Limit: defaultPageSize, PageToken: q.Get("pageToken")}
+	var errs models.ValidationErrors
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			errs.Add("limit", fmt.Sprintf("must be a number between 1 and %d", maxPageSize))
+		} else {
+			opts.Limit = n
+		}
+	}
+
+	if v := strings.TrimSpace(q.Get("orderBy")); v != "" {
+		parts := strings.Fields(v)
+		field := parts[0]
+		_, known := fields[field]
+		switch {
+		case !known:
+			errs.Add("orderBy", "must be one of "+strings.Join(fieldNames(fields, false), ", "))
+		case len(parts) == 2 && strings.EqualFold(parts[1], "desc"):
+			opts.OrderBy, opts.Descending = field, true
+		case len(parts) == 1 || (len(parts) == 2 && strings.EqualFold(parts[1], "asc")):
+			opts.OrderBy = field
+		default:
+			errs.Add("orderBy", `must be a field name optionally followed by "asc" or "desc"`)
+		}
+	}
+
+	for _, name := range fieldNames(fields, true) {
+		if v, ok := q[name]; ok {
+			if opts.Filters == nil {
+				opts.Filters = map[string]string{}
+			}
+			opts.Filters[name] = v[0]
+		}
+	}
+
+	if len(errs) > 0 {
+		return opts, errs
+	}
+	return opts, nil
+}
+
+// fieldNames returns the sorted field names, only the filterable ones when
+// filterable is set.
+func fieldNames(fields map[string]store.FieldKind, filterable bool) []string {
+	var names []string
+	for name, kind := range fields {
+		if !filterable || kind == store.StringField {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// respondListError maps errors from listOptions and the Page methods.
+func respondListError(w http.ResponseWriter, err error) {
+	var errs models.ValidationErrors
+	switch {
+	case errors.As(err, &errs):
+		respondValidation(w, errs)
+	case errors.Is(err, store.ErrInvalidPageToken):
+		respondError(w, http.StatusBadRequest, "Invalid page token")
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
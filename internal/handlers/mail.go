@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"appdirect-workshop/internal/mailer"
+	"appdirect-workshop/internal/models"
+)
+
+// defaultEventName names the event in emails unless EVENT_NAME is set.
+const defaultEventName = "AppDirect India AI Workshop"
+
+func eventName() string {
+	if name := os.Getenv("EVENT_NAME"); name != "" {
+		return name
+	}
+	return defaultEventName
+}
+
+// publicURL reads PUBLIC_URL, the address emailed links point at. Links are
+// never built from the request's Host header, which the client controls: a
+// cancellation with a forged Host would otherwise send the promoted
+// attendees' manage and ticket links to another site.
+func publicURL() string {
+	url := strings.TrimRight(os.Getenv("PUBLIC_URL"), "/")
+	if url == "" {
+		log.Println("⚠ PUBLIC_URL not set; emails will not include manage or ticket links")
+	}
+	return url
+}
+
+// registrationEmail fills the template data shared by every attendee email.
+// The manage and ticket links are left out unless PUBLIC_URL is set.
+func (h *Handlers) registrationEmail(a *models.Attendee) mailer.Registration {
+	data := mailer.Registration{
+		EventName:        h.eventName,
+		Name:             a.Name,
+		Email:            a.Email,
+		Status:           a.Status,
+		WaitlistPosition: a.WaitlistPosition,
+	}
+	if h.publicURL == "" {
+		return data
+	}
+	token := h.links.Sign(a.ID)
+	data.ManageURL = h.publicURL + h.pathPrefix() + "/registration/" + token
+	if a.HoldsSeat() {
+		data.TicketURL = h.publicURL + "/api" + h.pathPrefix() + "/registrations/" + token + "/ticket"
+	}
+	return data
+}
+
+// sendConfirmation queues the registration confirmation, or the "a seat
+// opened up" variant for attendees promoted from the waitlist. Attendees
+// holding a seat get the agenda attached as an .ics file.
+func (h *Handlers) sendConfirmation(r *http.Request, a *models.Attendee, promoted bool) {
+	data := h.registrationEmail(a)
+	data.Promoted = promoted
+	msg, err := mailer.Confirmation(data)
+	if err == nil && a.HoldsSeat() {
+		msg.Attachments = h.eventAttachment(r.Context())
+	}
+	h.queueEmail(msg, err)
+}
+
+func (h *Handlers) sendCancellation(r *http.Request, a *models.Attendee) {
+	h.queueEmail(mailer.Cancellation(h.registrationEmail(a)))
+}
+
+// sendPromotions tells each promoted attendee they now have a seat.
+func (h *Handlers) sendPromotions(r *http.Request, promoted []*models.Attendee) {
+	for _, a := range promoted {
+		h.sendConfirmation(r, a, true)
+	}
+}
+
+func (h *Handlers) queueEmail(msg *mailer.Message, err error) {
+	if err != nil {
+		// Templates are embedded and covered by tests, so this only
+		// happens on a programming error; never fail the request for it.
+		log.Printf("mail: rendering failed: %v", err)
+		return
+	}
+	h.mail.Enqueue(msg)
+}
+
+// Close stops the reminder schedulers and delivers queued emails, giving up
+// on retries when ctx ends.
+func (h *Handlers) Close(ctx context.Context) error {
+	h.stopWorkshopReminders()
+	if h.stopReminders != nil {
+		h.stopReminders()
+	}
+	return h.mail.Close(ctx)
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+
+	"github.com/gorilla/mux"
+)
+
+// registration is the response to a new registration. ManageToken is only
+// ever handed to the registrant; it is the key to the /registrations
+// endpoints below. Ticket is the QR payload checked at the door, issued
+// once the registration holds a seat.
+type registration struct {
+	*models.Attendee
+	ManageToken string `json:"manageToken"`
+	Ticket      string `json:"ticket,omitempty"`
+}
+
+// registrationFromToken resolves the {token} route variable to the attendee
+// it was issued for. Invalid tokens and missing attendees get the same 404
+// so a token cannot be used to probe for registrations.
+func (h *Handlers) registrationFromToken(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id, err := h.links.Verify(mux.Vars(r)["token"])
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Registration not found")
+		return "", false
+	}
+	return id, true
+}
+
+// notifyCancelled emails a cancellation confirmation unless the registration
+// had already been cancelled before start, so repeated requests send one.
+func (h *Handlers) notifyCancelled(r *http.Request, a *models.Attendee, start time.Time) {
+	if a.CancelledAt != nil && !a.CancelledAt.Before(start) {
+		h.sendCancellation(r, a)
+	}
+}
+
+// withWaitlistPosition fills in the position of a waitlisted attendee.
+func (h *Handlers) withWaitlistPosition(ctx context.Context, a *models.Attendee) error {
+	if a.Status != models.StatusWaitlisted {
+		return nil
+	}
+	waitlist, err := h.attendees.Waitlist(ctx)
+	if err != nil {
+		return err
+	}
+	for _, w := range waitlist {
+		if w.ID == a.ID {
+			a.WaitlistPosition = w.WaitlistPosition
+		}
+	}
+	return nil
+}
+
+// GetRegistration shows a registrant their own registration.
+func (h *Handlers) GetRegistration(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.registrationFromToken(w, r)
+	if !ok {
+		return
+	}
+
+	attendee, err := h.attendees.GetAttendee(r.Context(), id)
+	if err == nil {
+		err = h.withWaitlistPosition(r.Context(), attendee)
+	}
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			respondError(w, http.StatusNotFound, "Registration not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, attendee)
+}
+
+// UpdateRegistration lets a registrant correct their name, email or
+// designation. Their status and place in the waitlist are unchanged.
+func (h *Handlers) UpdateRegistration(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.registrationFromToken(w, r)
+	if !ok {
+		return
+	}
+
+	var attendee models.Attendee
+	if !decodeValid(w, r, &attendee) {
+		return
+	}
+	attendee.ID = id
+
+	err := h.attendees.UpdateAttendee(r.Context(), &attendee)
+	if err == nil {
+		err = h.withWaitlistPosition(r.Context(), &attendee)
+	}
+	if err != nil {
+		var dup *store.DuplicateError
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			respondError(w, http.StatusNotFound, "Registration not found")
+		case errors.Is(err, store.ErrCancelled):
+			respondError(w, http.StatusConflict, "This registration was cancelled")
+		case errors.As(err, &dup):
+			respondError(w, http.StatusConflict, "This email is already registered")
+		default:
+			respondError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, attendee)
+}
+
+// CancelRegistration lets a registrant give up their seat or waitlist place.
+// The registration is kept with a cancelled status and the seat goes to the
+// next person on the waitlist.
+func (h *Handlers) CancelRegistration(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.registrationFromToken(w, r)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	attendee, promoted, err := h.attendees.CancelAttendee(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			respondError(w, http.StatusNotFound, "Registration not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.attendeeCount.invalidate()
+	h.notifyCancelled(r, attendee, start)
+	h.releaseEnrollments(r.Context(), store.EnrollmentFilter{AttendeeID: attendee.ID})
+	h.sendPromotions(r, promoted)
+
+	respondJSON(w, http.StatusOK, attendee)
+}
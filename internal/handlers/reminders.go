@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/reminders"
+	"appdirect-workshop/internal/store"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultEventTimezone is the zone session dates and times are read in
+// unless EVENT_TIMEZONE is set.
+const defaultEventTimezone = "Asia/Kolkata"
+
+// reminderConfig reads the reminder lead times and event time zone from the
+// environment. REMINDER_LEADS is a comma-separated list of durations such as
+// "24h,1h", or "off" to send no reminders.
+func (h *Handlers) reminderConfig() reminders.Config {
+	cfg := reminders.Config{
+		Leads:     reminders.DefaultLeads,
+		Location:  h.location,
+		EventName: h.eventName,
+		ManageURL: func(id string) string {
+			if h.publicURL == "" {
+				return ""
+			}
+			return h.publicURL + h.pathPrefix() + "/registration/" + h.links.Sign(id)
+		},
+	}
+
+	switch v := os.Getenv("REMINDER_LEADS"); v {
+	case "":
+	case "off":
+		cfg.Leads = nil
+	default:
+		var leads []time.Duration
+		for _, field := range strings.Split(v, ",") {
+			d, err := time.ParseDuration(strings.TrimSpace(field))
+			if err != nil || d <= 0 {
+				log.Printf("Ignoring invalid REMINDER_LEADS %q", v)
+				return cfg
+			}
+			leads = append(leads, d)
+		}
+		cfg.Leads = leads
+	}
+	return cfg
+}
+
+// EventLocation returns the event time zone named by EVENT_TIMEZONE, in which
+// session dates and times are read.
+func EventLocation() *time.Location {
+	name := os.Getenv("EVENT_TIMEZONE")
+	if name == "" {
+		name = defaultEventTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("Ignoring invalid EVENT_TIMEZONE %q, using UTC", name)
+		return time.UTC
+	}
+	return loc
+}
+
+// StartReminders runs the reminder scheduler of every workshop in the
+// background until Close. Workshops created later start theirs when first
+// served.
+func (h *Handlers) StartReminders() {
+	h.startScheduler()
+	if err := h.startWorkshopReminders(context.Background()); err != nil {
+		log.Printf("Starting workshop reminders: %v", err)
+	}
+}
+
+func (h *Handlers) startScheduler() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.reminders.Run(ctx)
+	}()
+	h.stopReminders = func() {
+		cancel()
+		<-done
+	}
+}
+
+// scheduleReminders keeps the reminders of a created or updated session in
+// step with its start time. A failure is only logged: the session is saved
+// and the scheduler reschedules every session periodically.
+func (h *Handlers) scheduleReminders(ctx context.Context, session *models.Session) {
+	if err := h.reminders.Schedule(ctx, session); err != nil {
+		log.Printf("Scheduling reminders for session %s: %v", session.ID, err)
+	}
+}
+
+func (h *Handlers) unscheduleReminders(ctx context.Context, sessionID string) {
+	if err := h.reminders.Unschedule(ctx, sessionID); err != nil {
+		log.Printf("Cancelling reminders for session %s: %v", sessionID, err)
+	}
+}
+
+// GetReminders lists reminder jobs by due time, optionally filtered by
+// ?status= and ?sessionId=.
+func (h *Handlers) GetReminders(w http.ResponseWriter, r *http.Request) {
+	list, err := h.reminders.List(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	status, sessionID := r.URL.Query().Get("status"), r.URL.Query().Get("sessionId")
+	out := []*models.Reminder{}
+	for _, rem := range list {
+		if (status == "" || rem.Status == status) && (sessionID == "" || rem.SessionID == sessionID) {
+			out = append(out, rem)
+		}
+	}
+
+	respondJSON(w, http.StatusOK, out)
+}
+
+// CancelReminder stops a reminder that has not been sent.
+func (h *Handlers) CancelReminder(w http.ResponseWriter, r *http.Request) {
+	reminder, err := h.reminders.Cancel(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respondReminderError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, reminder)
+}
+
+// ResendReminder sends a reminder again straight away, whatever its status.
+// Sending happens in the background, so the response is 202.
+func (h *Handlers) ResendReminder(w http.ResponseWriter, r *http.Request) {
+	reminder, err := h.reminders.Resend(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respondReminderError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, reminder)
+}
+
+func respondReminderError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		respondError(w, http.StatusNotFound, "Reminder not found")
+	case errors.Is(err, reminders.ErrInProgress):
+		respondError(w, http.StatusConflict, "This reminder is being sent")
+	case errors.Is(err, reminders.ErrAlreadySent):
+		respondError(w, http.StatusConflict, "This reminder was already sent")
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
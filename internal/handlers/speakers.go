@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+// What DeleteSpeaker does with a speaker that is still assigned to sessions:
+// refuse, or remove them from those sessions first.
+const (
+	speakerDeleteBlock   = "block"
+	speakerDeleteCascade = "cascade"
+)
+
+func speakerDeleteMode() string {
+	switch v := os.Getenv("SPEAKER_DELETE_MODE"); v {
+	case "", speakerDeleteBlock:
+		return speakerDeleteBlock
+	case speakerDeleteCascade:
+		return speakerDeleteCascade
+	default:
+		log.Printf("Ignoring invalid SPEAKER_DELETE_MODE %q, using %s", v, speakerDeleteBlock)
+		return speakerDeleteBlock
+	}
+}
+
+// respondSessionError maps the errors of a session write to a response.
+// Unknown speakers are reported against speakerIds like any other invalid
+// field; a room double booking names the session holding the room.
+func respondSessionError(w http.ResponseWriter, err error) {
+	var missing *store.MissingSpeakersError
+	var room *store.RoomConflictError
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		respondError(w, http.StatusNotFound, "Session not found")
+	case errors.As(err, &missing):
+		var errs models.ValidationErrors
+		for _, id := range missing.IDs {
+			errs = append(errs, models.FieldError{Field: "speakerIds", Message: "unknown speaker " + id})
+		}
+		respondValidation(w, errs)
+	case errors.As(err, &room):
+		respondJSON(w, http.StatusConflict, map[string]string{
+			"error":     "Room " + room.Room + " is already booked at that time",
+			"sessionId": room.SessionID,
+		})
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// sessionWithSpeakers is a session with its speakers embedded, in the
+// order of SpeakerIDs.
+type sessionWithSpeakers struct {
+	*models.Session
+	Speakers []*models.Speaker `json:"speakers"`
+}
+
+// expandSpeakersParam reads ?expand=, which only knows "speakers".
+func expandSpeakersParam(r *http.Request) (bool, error) {
+	switch r.URL.Query().Get("expand") {
+	case "":
+		return false, nil
+	case "speakers":
+		return true, nil
+	default:
+		return false, models.ValidationErrors{{Field: "expand", Message: "must be speakers"}}
+	}
+}
+
+// expandSpeakers embeds the speakers of every session in a page.
+func (h *Handlers) expandSpeakers(ctx context.Context, page *store.Page[models.Session]) (*store.Page[sessionWithSpeakers], error) {
+	items, err := h.withSpeakers(ctx, page.Items)
+	if err != nil {
+		return nil, err
+	}
+	return &store.Page[sessionWithSpeakers]{Items: items, NextPageToken: page.NextPageToken}, nil
+}
+
+// withSpeakers pairs each session with its speakers, reading them in one
+// batch rather than once per session. Speakers that no longer exist are
+// skipped.
+func (h *Handlers) withSpeakers(ctx context.Context, sessions []*models.Session) ([]*sessionWithSpeakers, error) {
+	var ids []string
+	seen := map[string]bool{}
+	for _, s := range sessions {
+		for _, id := range s.SpeakerIDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	speakers, err := h.speakers.GetSpeakers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*sessionWithSpeakers, 0, len(sessions))
+	for _, s := range sessions {
+		item := &sessionWithSpeakers{Session: s, Speakers: []*models.Speaker{}}
+		for _, id := range s.SpeakerIDs {
+			if sp, ok := speakers[id]; ok {
+				item.Speakers = append(item.Speakers, sp)
+			}
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
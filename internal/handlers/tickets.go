@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"appdirect-workshop/internal/audit"
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+
+	"github.com/gorilla/mux"
+	"github.com/skip2/go-qrcode"
+)
+
+// ticketSize is the width and height of a ticket's QR code in pixels, large
+// enough to scan from a phone screen.
+const ticketSize = 512
+
+// ticket returns the signed QR payload of a seat holder's ticket, or "" for
+// waitlisted and cancelled registrations, which have none.
+func (h *Handlers) ticket(a *models.Attendee) string {
+	if !a.HoldsSeat() {
+		return ""
+	}
+	return h.tickets.Sign(a.ID)
+}
+
+func (h *Handlers) respondTicket(w http.ResponseWriter, a *models.Attendee) {
+	payload := h.ticket(a)
+	if payload == "" {
+		respondError(w, http.StatusConflict, "Only registered attendees have a ticket")
+		return
+	}
+	png, err := qrcode.Encode(payload, qrcode.Medium, ticketSize)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Write(png)
+}
+
+// GetRegistrationTicket serves a registrant their QR ticket as a PNG.
+func (h *Handlers) GetRegistrationTicket(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.registrationFromToken(w, r)
+	if !ok {
+		return
+	}
+
+	attendee, err := h.attendees.GetAttendee(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			respondError(w, http.StatusNotFound, "Registration not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondTicket(w, attendee)
+}
+
+// GetAttendeeTicket lets door staff reprint an attendee's ticket.
+func (h *Handlers) GetAttendeeTicket(w http.ResponseWriter, r *http.Request) {
+	attendee, err := h.attendees.GetAttendee(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			respondError(w, http.StatusNotFound, "Attendee not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondTicket(w, attendee)
+}
+
+// CheckIn checks in the attendee a scanned ticket belongs to. A second scan
+// of the same ticket is rejected with 409, saying when and by whom the
+// attendee was first checked in.
+func (h *Handlers) CheckIn(w http.ResponseWriter, r *http.Request) {
+	var req models.CheckIn
+	if !decodeValid(w, r, &req) {
+		return
+	}
+	id, err := h.tickets.Verify(req.Ticket)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ticket")
+		return
+	}
+
+	operator, _ := currentAdmin(r)
+	attendee, err := h.attendees.CheckInAttendee(r.Context(), id, operator.Username)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		respondError(w, http.StatusNotFound, "Attendee not found")
+	case errors.Is(err, store.ErrCheckedIn):
+		respondJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":    "Already checked in",
+			"attendee": attendee,
+		})
+	case errors.Is(err, store.ErrNoSeat):
+		respondJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":    "This registration is " + attendee.Status + ", not registered",
+			"attendee": attendee,
+		})
+	case err != nil:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	default:
+		h.audit.Record(r.Context(), audit.Entry{
+			Action: audit.CheckedIn,
+			Actor:  operator.Username,
+			IP:     h.clientIP(r),
+			Detail: attendee.ID,
+		})
+		respondJSON(w, http.StatusOK, attendee)
+	}
+}
+
+// checkInStats summarizes arrivals for the door team. Only attendees holding
+// a seat are counted.
+type checkInStats struct {
+	Registered    int            `json:"registered"`
+	CheckedIn     int            `json:"checkedIn"`
+	NotCheckedIn  int            `json:"notCheckedIn"`
+	ByOperator    map[string]int `json:"byOperator"`
+	LastCheckInAt *time.Time     `json:"lastCheckInAt"`
+}
+
+// GetCheckInStats reports how many registered attendees have arrived.
+func (h *Handlers) GetCheckInStats(w http.ResponseWriter, r *http.Request) {
+	attendees, err := h.attendees.ListAttendees(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	stats := checkInStats{ByOperator: map[string]int{}}
+	for _, a := range attendees {
+		if !a.HoldsSeat() {
+			continue
+		}
+		stats.Registered++
+		if a.CheckedInAt == nil {
+			continue
+		}
+		stats.CheckedIn++
+		stats.ByOperator[a.CheckedInBy]++
+		if stats.LastCheckInAt == nil || a.CheckedInAt.After(*stats.LastCheckInAt) {
+			stats.LastCheckInAt = a.CheckedInAt
+		}
+	}
+	stats.NotCheckedIn = stats.Registered - stats.CheckedIn
+
+	respondJSON(w, http.StatusOK, stats)
+}
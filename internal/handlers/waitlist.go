@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+
+	"github.com/gorilla/mux"
+)
+
+// attendeeCount is the public registration summary. Capacity and Remaining
+// are null when the event has no seat limit. Count repeats Registered for
+// clients written before the waitlist existed.
+type attendeeCount struct {
+	Count      int  `json:"count"`
+	Registered int  `json:"registered"`
+	Waitlisted int  `json:"waitlisted"`
+	Capacity   *int `json:"capacity"`
+	Remaining  *int `json:"remaining"`
+}
+
+func (h *Handlers) loadAttendeeCount(ctx context.Context) (attendeeCount, error) {
+	stats, err := h.attendees.AttendeeStats(ctx)
+	if err != nil {
+		return attendeeCount{}, err
+	}
+	settings, err := h.attendees.EventSettings(ctx)
+	if err != nil {
+		return attendeeCount{}, err
+	}
+
+	out := attendeeCount{Count: stats.Registered, Registered: stats.Registered, Waitlisted: stats.Waitlisted}
+	if settings.Capacity > 0 {
+		capacity, remaining := settings.Capacity, settings.Capacity-stats.Registered
+		if remaining < 0 {
+			remaining = 0
+		}
+		out.Capacity, out.Remaining = &capacity, &remaining
+	}
+	return out, nil
+}
+
+// GetAttendeeCount serves the public registration counts from a short-lived
+// cache, since every landing page load asks for them.
+func (h *Handlers) GetAttendeeCount(w http.ResponseWriter, r *http.Request) {
+	count, err := h.attendeeCount.get(r.Context(), h.loadAttendeeCount)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, count)
+}
+
+// CancelAttendee cancels a registration and reports who was promoted from
+// the waitlist into the freed seat.
+func (h *Handlers) CancelAttendee(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	attendee, promoted, err := h.attendees.CancelAttendee(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			respondError(w, http.StatusNotFound, "Attendee not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.attendeeCount.invalidate()
+	h.notifyCancelled(r, attendee, start)
+	h.releaseEnrollments(r.Context(), store.EnrollmentFilter{AttendeeID: attendee.ID})
+	h.sendPromotions(r, promoted)
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"attendee": attendee,
+		"promoted": nonNil(promoted),
+	})
+}
+
+// GetWaitlist lists waitlisted attendees in promotion order.
+func (h *Handlers) GetWaitlist(w http.ResponseWriter, r *http.Request) {
+	waitlist, err := h.attendees.Waitlist(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, nonNil(waitlist))
+}
+
+func (h *Handlers) GetEventSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.attendees.EventSettings(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, settings)
+}
+
+// UpdateEventSettings replaces the event settings. Raising the capacity
+// promotes waitlisted attendees into the new seats straight away.
+func (h *Handlers) UpdateEventSettings(w http.ResponseWriter, r *http.Request) {
+	var settings models.EventSettings
+	if !decodeValid(w, r, &settings) {
+		return
+	}
+
+	promoted, err := h.attendees.UpdateEventSettings(r.Context(), &settings)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.attendeeCount.invalidate()
+	h.sendPromotions(r, promoted)
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"settings": settings,
+		"promoted": nonNil(promoted),
+	})
+}
+
+// nonNil makes empty results encode as [] rather than null.
+func nonNil(attendees []*models.Attendee) []*models.Attendee {
+	if attendees == nil {
+		return []*models.Attendee{}
+	}
+	return attendees
+}
@@ -0,0 +1,387 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/reminders"
+	"appdirect-workshop/internal/store"
+
+	"github.com/gorilla/mux"
+)
+
+// Handlers serve one workshop. NewHandlers returns those of the default
+// workshop, which the routes directly under /api serve for clients written
+// before there were several workshops. Routes under
+// /api/workshops/{workshopId} go through InWorkshop, which serves them with
+// handlers bound to the stores of the workshop in the path.
+
+// workshopScopes caches the handlers of each workshop other than the
+// default one, so every workshop keeps one attendee count cache and one
+// reminder scheduler.
+type workshopScopes struct {
+	mu       sync.Mutex
+	handlers map[string]*Handlers
+	// running is set by StartReminders; handlers created afterwards start
+	// their scheduler right away.
+	running bool
+}
+
+// InWorkshop adapts a handler method, such as (*Handlers).GetSessions, to
+// serve the workshop named by the workshopId path variable, or the default
+// workshop on routes without one. Unknown workshops answer 404.
+func (h *Handlers) InWorkshop(f func(*Handlers, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := mux.Vars(r)["workshopId"]
+		if !ok {
+			f(h, w, r)
+			return
+		}
+		scoped, err := h.forWorkshop(r.Context(), id)
+		if errors.Is(err, store.ErrNotFound) {
+			respondError(w, http.StatusNotFound, "Workshop not found")
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		f(scoped, w, r)
+	}
+}
+
+// forWorkshop returns the handlers of a workshop. The workshop is read on
+// every call so a workshop deleted by another server instance is not served
+// from the cache; the cached handlers are rebuilt when it was renamed.
+func (h *Handlers) forWorkshop(ctx context.Context, id string) (*Handlers, error) {
+	if id == h.defaultWorkshop {
+		return h, nil
+	}
+	if h.workshops == nil {
+		return nil, store.ErrNotFound
+	}
+	ws, err := h.workshops.GetWorkshop(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	h.scopes.mu.Lock()
+	defer h.scopes.mu.Unlock()
+	cached := h.scopes.handlers[id]
+	if cached != nil && cached.workshopUpdated.Equal(ws.UpdatedAt) {
+		return cached, nil
+	}
+	if cached != nil && cached.stopReminders != nil {
+		// The new scheduler may overlap the old one briefly; reminder
+		// leases keep them from sending the same reminder twice.
+		go cached.stopReminders()
+	}
+	scoped := h.scoped(ws)
+	if h.scopes.running {
+		scoped.startScheduler()
+	}
+	h.scopes.handlers[id] = scoped
+	return scoped, nil
+}
+
+// scoped returns a copy of the default workshop's handlers bound to the
+// stores of ws, naming ws in emails.
+func (h *Handlers) scoped(ws *models.Workshop) *Handlers {
+	stores := h.workshops.Scoped(ws.ID)
+	c := *h
+	c.attendees = stores.Attendees
+	c.speakers = stores.Speakers
+	c.sessions = stores.Sessions
+	c.enrollments = stores.Enrollments
+	c.imports = stores.Imports
+	c.workshop, c.workshopUpdated = ws.ID, ws.UpdatedAt
+	c.eventName = ws.Name
+	c.attendeeCount = newTTLCache[attendeeCount](attendeeCountTTL)
+	c.stopReminders = nil
+	c.reminders = reminders.NewScheduler(stores, c.mail, c.reminderConfig())
+	return &c
+}
+
+// dropWorkshop forgets the handlers of a deleted workshop, stopping its
+// reminders.
+func (h *Handlers) dropWorkshop(id string) {
+	h.scopes.mu.Lock()
+	cached := h.scopes.handlers[id]
+	delete(h.scopes.handlers, id)
+	h.scopes.mu.Unlock()
+
+	if cached != nil && cached.stopReminders != nil {
+		cached.stopReminders()
+	}
+}
+
+// startWorkshopReminders starts the reminder scheduler of every workshop
+// other than the default one.
+func (h *Handlers) startWorkshopReminders(ctx context.Context) error {
+	h.scopes.mu.Lock()
+	h.scopes.running = true
+	h.scopes.mu.Unlock()
+
+	if h.workshops == nil {
+		return nil
+	}
+	list, err := h.workshops.ListWorkshops(ctx)
+	if err != nil {
+		return err
+	}
+	for _, ws := range list {
+		if _, err := h.forWorkshop(ctx, ws.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stopWorkshopReminders stops the schedulers started for workshops.
+func (h *Handlers) stopWorkshopReminders() {
+	h.scopes.mu.Lock()
+	scoped := make([]*Handlers, 0, len(h.scopes.handlers))
+	for _, s := range h.scopes.handlers {
+		scoped = append(scoped, s)
+	}
+	h.scopes.running = false
+	h.scopes.mu.Unlock()
+
+	for _, s := range scoped {
+		if s.stopReminders != nil {
+			s.stopReminders()
+		}
+	}
+}
+
+// pathPrefix is inserted after /api, or after the site root, in links to
+// the workshop: empty for the default workshop, whose links predate
+// workshops.
+func (h *Handlers) pathPrefix() string {
+	if h.workshop == h.defaultWorkshop {
+		return ""
+	}
+	return "/workshops/" + h.workshop
+}
+
+// EnsureDefaultWorkshop creates the record of the default workshop, named
+// after the event, if it does not exist yet. Its data is whatever was stored
+// before workshops existed.
+func (h *Handlers) EnsureDefaultWorkshop(ctx context.Context) error {
+	_, err := h.workshops.GetWorkshop(ctx, h.defaultWorkshop)
+	if !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+	err = h.workshops.CreateWorkshop(ctx, &models.Workshop{ID: h.defaultWorkshop, Name: h.eventName})
+	var dup *store.DuplicateError
+	if errors.As(err, &dup) {
+		return nil // another instance created it first
+	}
+	return err
+}
+
+// workshopResponse marks which workshop the legacy routes serve.
+type workshopResponse struct {
+	*models.Workshop
+	Default bool `json:"default"`
+}
+
+func (h *Handlers) workshopResponse(ws *models.Workshop) workshopResponse {
+	return workshopResponse{Workshop: ws, Default: ws.ID == h.defaultWorkshop}
+}
+
+// ListWorkshops lists every workshop by ID.
+func (h *Handlers) ListWorkshops(w http.ResponseWriter, r *http.Request) {
+	list, err := h.workshops.ListWorkshops(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	out := make([]workshopResponse, 0, len(list))
+	for _, ws := range list {
+		out = append(out, h.workshopResponse(ws))
+	}
+	respondJSON(w, http.StatusOK, out)
+}
+
+func (h *Handlers) GetWorkshop(w http.ResponseWriter, r *http.Request) {
+	ws, err := h.workshops.GetWorkshop(r.Context(), mux.Vars(r)["workshopId"])
+	if err != nil {
+		respondWorkshopError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, h.workshopResponse(ws))
+}
+
+// CreateWorkshop adds a workshop under the id in the body, or a generated
+// one. It starts out with no attendees, speakers or sessions and unlimited
+// capacity.
+func (h *Handlers) CreateWorkshop(w http.ResponseWriter, r *http.Request) {
+	var ws models.Workshop
+	if !decodeValid(w, r, &ws) {
+		return
+	}
+	if ws.ID == "" {
+		ws.ID = strings.ToLower(store.NewID())
+	}
+
+	if err := h.workshops.CreateWorkshop(r.Context(), &ws); err != nil {
+		var dup *store.DuplicateError
+		if errors.As(err, &dup) {
+			respondError(w, http.StatusConflict, "A workshop with this ID already exists")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, h.workshopResponse(&ws))
+}
+
+// UpdateWorkshop renames or redescribes a workshop. Its ID cannot change.
+func (h *Handlers) UpdateWorkshop(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["workshopId"]
+	var ws models.Workshop
+	if !decodeValid(w, r, &ws) {
+		return
+	}
+	if ws.ID != "" && ws.ID != id {
+		respondValidation(w, models.ValidationErrors{{Field: "id", Message: "cannot be changed"}})
+		return
+	}
+	ws.ID = id
+
+	if err := h.workshops.UpdateWorkshop(r.Context(), &ws); err != nil {
+		respondWorkshopError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, h.workshopResponse(&ws))
+}
+
+// DeleteWorkshop deletes a workshop together with its attendees, speakers,
+// sessions and reminders. The default workshop cannot be deleted.
+func (h *Handlers) DeleteWorkshop(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["workshopId"]
+	if id == h.defaultWorkshop {
+		respondError(w, http.StatusConflict, "The default workshop cannot be deleted")
+		return
+	}
+
+	if err := h.workshops.DeleteWorkshop(r.Context(), id); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.dropWorkshop(id)
+	log.Printf("Deleted workshop %s", id)
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Workshop deleted"})
+}
+
+// cloneResponse reports the workshop CloneWorkshop created and what it
+// copied into it.
+type cloneResponse struct {
+	Workshop workshopResponse `json:"workshop"`
+	Speakers int              `json:"speakers"`
+	Sessions int              `json:"sessions"`
+}
+
+// CloneWorkshop creates a workshop from the speakers and sessions of the one
+// in the path, so a workshop that runs again need not be entered by hand.
+// Attendees, enrollments and settings are not copied. Copies get new IDs,
+// and sessions move by offsetDays days at the same wall clock time in the
+// event time zone.
+func (h *Handlers) CloneWorkshop(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	source, err := h.forWorkshop(ctx, mux.Vars(r)["workshopId"])
+	if err != nil {
+		respondWorkshopError(w, err)
+		return
+	}
+	var clone models.WorkshopClone
+	if !decodeValid(w, r, &clone) {
+		return
+	}
+	ws := clone.Workshop
+	if ws.ID == "" {
+		ws.ID = strings.ToLower(store.NewID())
+	}
+
+	speakers, err := source.speakers.ListSpeakers(ctx)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sessions, err := source.sessions.ListSessions(ctx)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	speakerIDs := make(map[string]string, len(speakers))
+	for _, sp := range speakers {
+		speakerIDs[sp.ID] = store.NewID()
+		sp.ID = speakerIDs[sp.ID]
+	}
+	source.syncSessions(sessions...)
+	for _, se := range sessions {
+		se.ID = store.NewID()
+		ids := make([]string, 0, len(se.SpeakerIDs))
+		for _, id := range se.SpeakerIDs {
+			if cloned, ok := speakerIDs[id]; ok {
+				ids = append(ids, cloned)
+			}
+		}
+		se.SpeakerIDs, se.SpeakerID = ids, ""
+		se.SyncSpeakers()
+		se.Enrolled = 0
+		if !se.StartsAt.IsZero() && clone.OffsetDays != 0 {
+			length := se.Length()
+			se.StartsAt = se.StartsAt.In(h.location).AddDate(0, 0, clone.OffsetDays)
+			se.EndsAt = se.StartsAt.Add(length)
+			se.SyncSchedule(h.location)
+		}
+	}
+
+	if err := h.workshops.CloneWorkshop(ctx, &ws, speakers, sessions); err != nil {
+		var dup *store.DuplicateError
+		if errors.As(err, &dup) {
+			respondError(w, http.StatusConflict, "A workshop with this ID already exists")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	log.Printf("Cloned workshop %s into %s: %d speakers, %d sessions", source.workshop, ws.ID, len(speakers), len(sessions))
+
+	if cloned, err := h.forWorkshop(ctx, ws.ID); err != nil {
+		log.Printf("Scheduling reminders for workshop %s: %v", ws.ID, err)
+	} else {
+		for _, se := range sessions {
+			if !se.StartsAt.IsZero() {
+				cloned.scheduleReminders(ctx, se)
+			}
+		}
+	}
+
+	respondJSON(w, http.StatusCreated, cloneResponse{
+		Workshop: h.workshopResponse(&ws),
+		Speakers: len(speakers),
+		Sessions: len(sessions),
+	})
+}
+
+func respondWorkshopError(w http.ResponseWriter, err error) {
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(w, http.StatusNotFound, "Workshop not found")
+		return
+	}
+	respondError(w, http.StatusInternalServerError, err.Error())
+}
@@ -0,0 +1,105 @@
+// Package ical writes iCalendar (RFC 5545) files so attendees can add the
+// agenda to their calendar app or subscribe to it.
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType is the media type of an encoded calendar.
+const ContentType = "text/calendar; charset=utf-8"
+
+// Event is a single calendar entry. UID must stay the same for the life of
+// the event so calendar apps update an entry rather than add a duplicate
+// when its time changes.
+type Event struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+	Categories  []string
+}
+
+// Calendar is a named list of events.
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// Encode renders the calendar, stamping every event with now.
+func (c *Calendar) Encode(now time.Time) []byte {
+	var buf bytes.Buffer
+	line := func(name, value string) { writeLine(&buf, name+":"+value) }
+	text := func(name, value string) {
+		if value != "" {
+			line(name, escape(value))
+		}
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//AppDirect//Workshop//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	text("X-WR-CALNAME", c.Name)
+	for _, e := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", e.UID)
+		line("DTSTAMP", formatTime(now))
+		line("DTSTART", formatTime(e.Start))
+		line("DTEND", formatTime(e.End))
+		text("SUMMARY", e.Summary)
+		text("DESCRIPTION", e.Description)
+		text("LOCATION", e.Location)
+		if len(e.Categories) > 0 {
+			escaped := make([]string, len(e.Categories))
+			for i, c := range e.Categories {
+				escaped[i] = escape(c)
+			}
+			line("CATEGORIES", strings.Join(escaped, ","))
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return buf.Bytes()
+}
+
+// formatTime writes t as a UTC date-time, which needs no VTIMEZONE.
+func formatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// escape quotes the characters that are special in TEXT values.
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// maxLineOctets is the longest a content line may be before folding.
+const maxLineOctets = 75
+
+// writeLine writes a content line, folding it onto continuation lines that
+// start with a space so no line exceeds 75 octets. Lines are only broken
+// between UTF-8 sequences.
+func writeLine(buf *bytes.Buffer, s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		buf.WriteString(s[:cut])
+		buf.WriteString("\r\n ")
+		s = s[cut:]
+		// The leading space counts towards the continuation line.
+		limit = maxLineOctets - 1
+	}
+	buf.WriteString(s)
+	buf.WriteString("\r\n")
+}
@@ -0,0 +1,53 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode(t *testing.T) {
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.FixedZone("IST", 5*3600+1800))
+	cal := &Calendar{
+		Name: "AI Workshop",
+		Events: []Event{{
+			UID:         "s1@appdirect-workshop",
+			Start:       start,
+			End:         start.Add(time.Hour),
+			Summary:     "Keynote; opening, remarks",
+			Description: "Line one\nLine two",
+			Location:    "Hall A",
+			Categories:  []string{"AI, ML"},
+		}},
+	}
+	out := string(cal.Encode(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VEVENT\r\nEND:VCALENDAR\r\n"))
+	assert.Contains(t, out, "\r\nDTSTAMP:20250101T000000Z\r\n")
+	assert.Contains(t, out, "\r\nDTSTART:20250301T033000Z\r\n", "times are written in UTC")
+	assert.Contains(t, out, "\r\nDTEND:20250301T043000Z\r\n")
+	assert.Contains(t, out, `SUMMARY:Keynote\; opening\, remarks`)
+	assert.Contains(t, out, `DESCRIPTION:Line one\nLine two`)
+	assert.Contains(t, out, `CATEGORIES:AI\, ML`)
+	assert.Contains(t, out, "X-WR-CALNAME:AI Workshop")
+}
+
+func TestLongLinesAreFolded(t *testing.T) {
+	title := strings.Repeat("é", 100)
+	out := string((&Calendar{Events: []Event{{UID: "x", Summary: title}}}).Encode(time.Now()))
+
+	var unfolded strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+		assert.True(t, strings.ToValidUTF8(line, "?") == line, "folding never splits a character")
+		if strings.HasPrefix(line, " ") {
+			unfolded.WriteString(line[1:])
+			continue
+		}
+		unfolded.WriteString("\n" + line)
+	}
+	assert.Contains(t, unfolded.String(), "\nSUMMARY:"+title+"\n")
+}
@@ -0,0 +1,292 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"appdirect-workshop/internal/models"
+)
+
+// valueKind says how a CSV cell becomes a JSON value.
+type valueKind int
+
+const (
+	text valueKind = iota
+	number
+	list
+	timestamp
+)
+
+// column is a field a file may set, named by its JSON field name.
+type column struct {
+	name string
+	kind valueKind
+}
+
+// kindColumns lists the fields each kind of file may set: those the create
+// endpoints accept, plus createdAt for attendees and id for speakers and
+// sessions, which other rows may refer to. Fields the server maintains, such
+// as an attendee's status, cannot be imported, nor can attendee IDs: manage
+// links and tickets sign the ID alone, so it must be unique across
+// workshops.
+var kindColumns = map[Kind][]column{
+	Attendees: {
+		{"name", text}, {"email", text}, {"designation", text}, {"createdAt", timestamp},
+	},
+	Speakers: {
+		{"id", text}, {"name", text}, {"bio", text},
+	},
+	Sessions: {
+		{"id", text}, {"title", text}, {"description", text}, {"date", text}, {"time", text},
+		{"duration", number}, {"capacity", number}, {"speakerIds", list},
+		{"startsAt", timestamp}, {"endsAt", timestamp}, {"room", text}, {"track", text},
+	},
+}
+
+// timeLayouts are the timestamp formats CSV cells may use besides RFC 3339,
+// read in the event time zone. The first is the attendee export's.
+var timeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02 15:04", models.DateLayout}
+
+// record is one row of a file as a JSON object, with the errors found
+// turning it into one. raw is nil when the row is not an object.
+type record struct {
+	row  int
+	raw  json.RawMessage
+	errs models.ValidationErrors
+}
+
+// readCSV maps the header onto cols and turns every non-blank row into a
+// record. Columns with a blank header are ignored. Problems with the file
+// go in rep; the error is for failing to read r.
+func readCSV(r io.Reader, cols []column, loc *time.Location, rep *Report) ([]record, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		rep.fail(0, "", "is empty")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, csvError(err, rep)
+	}
+
+	fields := make([]*column, len(header))
+	seen := map[string]bool{}
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if name == "" {
+			continue
+		}
+		c := lookup(cols, name)
+		switch {
+		case c == nil:
+			rep.fail(0, name, "is not a column; use "+columnNames(cols))
+		case seen[c.name]:
+			rep.fail(0, c.name, "is listed twice")
+		default:
+			seen[c.name] = true
+			fields[i] = c
+		}
+	}
+	if len(rep.Errors) > 0 {
+		return nil, nil
+	}
+
+	var records []record
+	for row := 1; ; row++ {
+		cells, err := cr.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, csvError(err, rep)
+		}
+		if blank(cells) {
+			continue
+		}
+		if len(records) == MaxRows {
+			rep.fail(0, "", "has more than "+strconv.Itoa(MaxRows)+" rows")
+			return nil, nil
+		}
+		records = append(records, cellsRecord(row, cells, fields, loc))
+	}
+}
+
+// csvError records a malformed file in rep and returns other errors, which
+// come from reading.
+func csvError(err error, rep *Report) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		rep.fail(0, "", "is not valid CSV: "+parseErr.Error())
+		return nil
+	}
+	return err
+}
+
+func lookup(cols []column, name string) *column {
+	for i := range cols {
+		if strings.EqualFold(cols[i].name, name) {
+			return &cols[i]
+		}
+	}
+	return nil
+}
+
+func columnNames(cols []column) string {
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = c.name
+	}
+	return strings.Join(names, ", ")
+}
+
+func blank(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// cellsRecord converts the cells of a row to the JSON object the create
+// endpoint would receive. Empty number, list and timestamp cells are left
+// out, as are cells that cannot be converted.
+func cellsRecord(row int, cells []string, fields []*column, loc *time.Location) record {
+	rec := record{row: row}
+	obj := map[string]interface{}{}
+	for i, c := range fields {
+		if c == nil {
+			continue
+		}
+		cell := cells[i]
+		if c.kind != text {
+			cell = strings.TrimSpace(cell)
+			if cell == "" {
+				continue
+			}
+		}
+		switch c.kind {
+		case text:
+			obj[c.name] = cell
+		case number:
+			n, err := strconv.Atoi(cell)
+			if err != nil {
+				rec.errs.Add(c.name, "must be a whole number")
+				continue
+			}
+			obj[c.name] = n
+		case list:
+			var values []string
+			for _, v := range strings.Split(cell, ";") {
+				if v = strings.TrimSpace(v); v != "" {
+					values = append(values, v)
+				}
+			}
+			obj[c.name] = values
+		case timestamp:
+			t, err := parseTime(cell, loc)
+			if err != nil {
+				rec.errs.Add(c.name, "must be a time such as 2006-01-02 15:04 or 2006-01-02T15:04:05Z")
+				continue
+			}
+			obj[c.name] = t
+		}
+	}
+	rec.raw, _ = json.Marshal(obj)
+	return rec
+}
+
+func parseTime(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	var err error
+	for _, layout := range timeLayouts {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, value, loc); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// readJSON reads an array of objects. An element that sets a field outside
+// cols gets an error for that field. Problems with the file go in rep; the
+// error is for failing to read r.
+func readJSON(r io.Reader, cols []column, rep *Report) ([]record, error) {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, jsonError(err, rep)
+	}
+	var records []record
+	for row := 1; dec.More(); row++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, jsonError(err, rep)
+		}
+		if len(records) == MaxRows {
+			rep.fail(0, "", "has more than "+strconv.Itoa(MaxRows)+" rows")
+			return nil, nil
+		}
+		rec := record{row: row, raw: raw}
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil || obj == nil {
+			rec.raw = nil
+			rec.errs.Add("", "must be an object")
+		}
+		var unknown []string
+		for name := range obj {
+			if c := lookup(cols, name); c == nil || c.name != name {
+				unknown = append(unknown, name)
+			}
+		}
+		sort.Strings(unknown)
+		for _, name := range unknown {
+			rec.errs.Add(name, "is not allowed")
+		}
+		records = append(records, rec)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, jsonError(err, rep)
+	}
+	return records, nil
+}
+
+// jsonError records a malformed file in rep and returns other errors, which
+// come from reading. A nil err means the file did not start with an array.
+func jsonError(err error, rep *Report) error {
+	var syntaxErr *json.SyntaxError
+	switch {
+	case err == nil, err == io.EOF, errors.Is(err, io.ErrUnexpectedEOF), errors.As(err, &syntaxErr):
+		rep.fail(0, "", "must be a JSON array of objects")
+		return nil
+	}
+	return err
+}
+
+// decodeRow decodes a record into v, then normalizes and validates it as
+// the create endpoints do.
+func decodeRow(raw json.RawMessage, v validatable) models.ValidationErrors {
+	if err := json.Unmarshal(raw, v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return models.ValidationErrors{{Field: typeErr.Field, Message: "must be a " + typeErr.Type.String()}}
+		}
+		return models.ValidationErrors{{Message: "is not valid: " + err.Error()}}
+	}
+	v.Normalize()
+	if err := v.Validate(); err != nil {
+		var errs models.ValidationErrors
+		if errors.As(err, &errs) {
+			return errs
+		}
+		return models.ValidationErrors{{Message: err.Error()}}
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+// Package importer loads attendees, speakers and sessions in bulk from CSV or
+// JSON files. Every row is checked with the rules of the matching create
+// endpoint, and the rows are only stored when all of them pass, so a file
+// is imported whole or not at all unless the store fails part way.
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+// Kind is what a file holds.
+type Kind string
+
+const (
+	Attendees Kind = "attendees"
+	Speakers  Kind = "speakers"
+	Sessions  Kind = "sessions"
+)
+
+// Format is how a file is encoded.
+type Format string
+
+const (
+	// CSV files have a header row naming the columns, by their JSON field
+	// names in any case. List columns separate their values with ';'.
+	CSV Format = "csv"
+	// JSON files hold an array of objects shaped like the create
+	// endpoint's request body.
+	JSON Format = "json"
+)
+
+// MaxRows bounds the rows of one file.
+const MaxRows = 10000
+
+// RowError is a problem with one row of a file. Rows are numbered from 1 in
+// file order, not counting the CSV header; Row is 0 for a problem with the
+// whole file.
+type RowError struct {
+	Row     int    `json:"row,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Report describes the outcome of an import.
+type Report struct {
+	Kind   Kind `json:"kind"`
+	DryRun bool `json:"dryRun"`
+	// Rows is the number of rows read, leaving out blank CSV lines.
+	Rows     int        `json:"rows"`
+	Imported int        `json:"imported"`
+	Errors   []RowError `json:"errors"`
+	// Sessions are the sessions a session import stored, for scheduling
+	// their reminders.
+	Sessions []*models.Session `json:"-"`
+}
+
+func (r *Report) fail(row int, field, message string) {
+	r.Errors = append(r.Errors, RowError{Row: row, Field: field, Message: message})
+}
+
+func (r *Report) failRow(row int, errs models.ValidationErrors) {
+	for _, fe := range errs {
+		r.fail(row, fe.Field, fe.Message)
+	}
+}
+
+// Options select what an import reads and whether it stores anything.
+type Options struct {
+	Kind   Kind
+	Format Format
+	// DryRun checks every row and reports what is wrong without storing
+	// anything.
+	DryRun bool
+}
+
+// Importer imports into the stores of one workshop. It needs Attendees,
+// Speakers, Sessions and Imports.
+type Importer struct {
+	Stores store.Stores
+	// Location is the event time zone. Session dates and times, and CSV
+	// timestamps without an offset, are read in it.
+	Location *time.Location
+}
+
+// Import reads a file from r and checks every row. When all of them are
+// valid and this is not a dry run, it stores them in batches of
+// store.ImportBatchSize. Problems with the file or its rows are listed in
+// the report. The error is for failures to read r, when the report is nil,
+// or of the stores, when the report tells how many rows were stored first.
+func (im *Importer) Import(ctx context.Context, r io.Reader, opts Options) (*Report, error) {
+	cols, ok := kindColumns[opts.Kind]
+	if !ok {
+		return nil, fmt.Errorf("importer: unknown kind %q", opts.Kind)
+	}
+	rep := &Report{Kind: opts.Kind, DryRun: opts.DryRun, Errors: []RowError{}}
+	var records []record
+	var err error
+	switch opts.Format {
+	case CSV:
+		records, err = readCSV(r, cols, im.Location, rep)
+	case JSON:
+		records, err = readJSON(r, cols, rep)
+	default:
+		return nil, fmt.Errorf("importer: unknown format %q", opts.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rep.Rows = len(records)
+	if len(rep.Errors) == 0 && len(records) == 0 {
+		rep.fail(0, "", "has no rows")
+	}
+	if len(rep.Errors) > 0 {
+		return rep, nil
+	}
+
+	switch opts.Kind {
+	case Attendees:
+		_, err = importRows(ctx, rep, records, im.checkAttendees, im.Stores.Imports.ImportAttendees)
+	case Speakers:
+		_, err = importRows(ctx, rep, records, im.checkSpeakers, im.Stores.Imports.ImportSpeakers)
+	case Sessions:
+		rep.Sessions, err = importRows(ctx, rep, records, im.checkSessions, im.Stores.Imports.ImportSessions)
+	}
+	sort.SliceStable(rep.Errors, func(i, j int) bool { return rep.Errors[i].Row < rep.Errors[j].Row })
+	return rep, err
+}
+
+// validatable is implemented by the models the importer reads.
+type validatable interface {
+	Normalize()
+	Validate() error
+}
+
+// importRows decodes and validates each record, runs check over the rows
+// that passed, then saves them all unless a row failed or this is a dry run.
+// Rows that failed to decode are nil when check sees them. It returns what
+// was saved.
+func importRows[T any, P interface {
+	*T
+	validatable
+}](ctx context.Context, rep *Report, records []record, check func(context.Context, *Report, []int, []P) error, save func(context.Context, []P) (int, error)) ([]P, error) {
+	rows := make([]int, len(records))
+	items := make([]P, len(records))
+	for i, rec := range records {
+		rows[i] = rec.row
+		v := P(new(T))
+		errs := rec.errs
+		if rec.raw != nil {
+			errs = append(errs, decodeRow(rec.raw, v)...)
+		}
+		if len(errs) > 0 {
+			rep.failRow(rec.row, errs)
+			continue
+		}
+		items[i] = v
+	}
+	if err := check(ctx, rep, rows, items); err != nil {
+		return nil, err
+	}
+	if len(rep.Errors) > 0 || rep.DryRun {
+		return nil, nil
+	}
+	n, err := save(ctx, items)
+	rep.Imported = n
+	return items[:n], err
+}
+
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func validID(id string) bool {
+	return len(id) <= models.MaxIDLength && idPattern.MatchString(id)
+}
+
+// assignIDs checks the IDs rows give for documents of collection: each must
+// be well formed, not used by an earlier row and not taken, as
+// ImportStore.IDsInUse tells. Rows without an ID get a new one. ids holds
+// nil for rows that failed to decode.
+func (im *Importer) assignIDs(ctx context.Context, rep *Report, collection string, rows []int, ids []*string) error {
+	var given []string
+	for _, id := range ids {
+		if id != nil && validID(*id) {
+			given = append(given, *id)
+		}
+	}
+	taken := map[string]bool{}
+	if len(given) > 0 {
+		var err error
+		if taken, err = im.Stores.Imports.IDsInUse(ctx, collection, given); err != nil {
+			return err
+		}
+	}
+
+	used := map[string]int{}
+	for i, id := range ids {
+		if id == nil {
+			continue
+		}
+		if *id == "" {
+			*id = store.NewID()
+			continue
+		}
+		switch first, dup := used[*id]; {
+		case !validID(*id):
+			rep.fail(rows[i], "id", fmt.Sprintf("must be 1-%d letters, digits, '_' or '-'", models.MaxIDLength))
+		case dup:
+			rep.fail(rows[i], "id", fmt.Sprintf("is also used by row %d", first))
+		case taken[*id]:
+			rep.fail(rows[i], "id", "is already taken")
+		default:
+			used[*id] = rows[i]
+		}
+	}
+	return nil
+}
+
+// checkAttendees treats each row as a new registration, like
+// RegisterAttendee: the attendee gets a new ID, the status fields are left
+// for the store to set, and the registration time defaults to now. Each
+// email may only be registered once among the rows and the attendees who
+// have not cancelled.
+func (im *Importer) checkAttendees(ctx context.Context, rep *Report, rows []int, attendees []*models.Attendee) error {
+	stored, err := im.Stores.Attendees.ListAttendees(ctx)
+	if err != nil {
+		return err
+	}
+	registered := map[string]bool{}
+	for _, a := range stored {
+		if a.Status != models.StatusCancelled {
+			registered[models.NormalizeEmail(a.Email)] = true
+		}
+	}
+
+	byEmail := map[string]int{}
+	now := time.Now().UTC()
+	for i, a := range attendees {
+		if a == nil {
+			continue
+		}
+		a.ID = store.NewID()
+		// A microsecond apart, so the waitlist keeps the file's order.
+		if a.CreatedAt.IsZero() {
+			a.CreatedAt = now.Add(time.Duration(i) * time.Microsecond)
+		}
+		email := models.NormalizeEmail(a.Email)
+		if first, ok := byEmail[email]; ok {
+			rep.fail(rows[i], "email", fmt.Sprintf("is also on row %d", first))
+		} else if registered[email] {
+			rep.fail(rows[i], "email", "is already registered")
+		}
+		byEmail[email] = rows[i]
+	}
+	return nil
+}
+
+func (im *Importer) checkSpeakers(ctx context.Context, rep *Report, rows []int, speakers []*models.Speaker) error {
+	ids := make([]*string, len(speakers))
+	for i, sp := range speakers {
+		if sp != nil {
+			ids[i] = &sp.ID
+		}
+	}
+	return im.assignIDs(ctx, rep, "speakers", rows, ids)
+}
+
+// checkSessions schedules each session in the event time zone, like
+// CreateSession, and checks that its speakers exist and that its room is
+// free of the stored sessions and the rows before it.
+func (im *Importer) checkSessions(ctx context.Context, rep *Report, rows []int, sessions []*models.Session) error {
+	stored, err := im.Stores.Sessions.ListSessions(ctx)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]*string, len(sessions))
+	var speakerIDs []string
+	for i, se := range sessions {
+		if se == nil {
+			continue
+		}
+		se.SyncSchedule(im.Location)
+		se.SyncSpeakers()
+		se.Enrolled = 0
+		ids[i] = &se.ID
+		speakerIDs = append(speakerIDs, se.SpeakerIDs...)
+	}
+	if err := im.assignIDs(ctx, rep, "sessions", rows, ids); err != nil {
+		return err
+	}
+
+	speakers := map[string]*models.Speaker{}
+	if len(speakerIDs) > 0 {
+		if speakers, err = im.Stores.Speakers.GetSpeakers(ctx, speakerIDs); err != nil {
+			return err
+		}
+	}
+	others := stored
+	rowOf := map[string]int{}
+	for i, se := range sessions {
+		if se == nil {
+			continue
+		}
+		for _, id := range se.SpeakerIDs {
+			if speakers[id] == nil {
+				rep.fail(rows[i], "speakerIds", "unknown speaker "+id)
+			}
+		}
+		var room *store.RoomConflictError
+		if err := store.CheckRoom(se, others); errors.As(err, &room) {
+			booked := "session " + room.SessionID
+			if row, ok := rowOf[room.SessionID]; ok {
+				booked = fmt.Sprintf("row %d", row)
+			}
+			rep.fail(rows[i], "room", "is booked by "+booked+" at the same time")
+			continue
+		}
+		others = append(others, se)
+		rowOf[se.ID] = rows[i]
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package importer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"appdirect-workshop/internal/memory"
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newImporter(mem *memory.Store) *Importer {
+	return &Importer{
+		Stores:   store.Stores{Attendees: mem, Speakers: mem, Sessions: mem, Imports: mem},
+		Location: time.UTC,
+	}
+}
+
+func TestImportSpeakersAndSessionsFromCSV(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	im := newImporter(mem)
+
+	speakers := "\ufeffID, Name ,BIO,\nada,Ada Lovelace,\"Wrote the first program,\nin 1843\",\n,,,\ngrace,Grace Hopper,,\n"
+	rep, err := im.Import(ctx, strings.NewReader(speakers), Options{Kind: Speakers, Format: CSV})
+	require.NoError(t, err)
+	assert.Empty(t, rep.Errors)
+	assert.Equal(t, 2, rep.Rows, "the blank row is skipped")
+	assert.Equal(t, 2, rep.Imported)
+	stored, err := mem.GetSpeakers(ctx, []string{"ada", "grace"})
+	require.NoError(t, err)
+	assert.Equal(t, "Wrote the first program,\nin 1843", stored["ada"].Bio)
+
+	rep, err = im.Import(ctx, strings.NewReader("id,name\nada,Again\nbad id,Bad\nlin,Lin\nlin,Lin again\n"), Options{Kind: Speakers, Format: CSV})
+	require.NoError(t, err)
+	assert.Equal(t, []RowError{
+		{Row: 1, Field: "id", Message: "is already taken"},
+		{Row: 2, Field: "id", Message: "must be 1-128 letters, digits, '_' or '-'"},
+		{Row: 4, Field: "id", Message: "is also used by row 3"},
+	}, rep.Errors)
+
+	sessions := "title,date,time,duration,speakerIds,room\n" +
+		"Keynote,2027-05-10,09:00,60,ada; grace,Main\n" +
+		"Clash,2027-05-10,09:30,30,,main\n" +
+		",2027-05-10,11:00,ten,nobody,\n"
+	rep, err = im.Import(ctx, strings.NewReader(sessions), Options{Kind: Sessions, Format: CSV, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, []RowError{
+		{Row: 2, Field: "room", Message: "is booked by row 1 at the same time"},
+		{Row: 3, Field: "duration", Message: "must be a whole number"},
+		{Row: 3, Field: "title", Message: "is required"},
+	}, rep.Errors)
+
+	sessions = "title,date,time,duration,speakerIds,room\n" +
+		"Keynote,2027-05-10,09:00,60,ada; grace,Main\n" +
+		"Workshop,2027-05-10,10:00,90,nobody,Main\n"
+	rep, err = im.Import(ctx, strings.NewReader(sessions), Options{Kind: Sessions, Format: CSV})
+	require.NoError(t, err)
+	assert.Equal(t, []RowError{{Row: 2, Field: "speakerIds", Message: "unknown speaker nobody"}}, rep.Errors)
+	assert.Zero(t, rep.Imported)
+	list, err := mem.ListSessions(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, list, "nothing is stored while a row is invalid")
+
+	rep, err = im.Import(ctx, strings.NewReader(strings.Replace(sessions, "nobody", "grace", 1)), Options{Kind: Sessions, Format: CSV})
+	require.NoError(t, err)
+	require.Empty(t, rep.Errors)
+	require.Len(t, rep.Sessions, 2)
+	keynote := rep.Sessions[0]
+	assert.Equal(t, []string{"ada", "grace"}, keynote.SpeakerIDs)
+	assert.Equal(t, "ada", keynote.SpeakerID)
+	assert.Equal(t, time.Date(2027, 5, 10, 9, 0, 0, 0, time.UTC), keynote.StartsAt)
+	assert.Equal(t, time.Date(2027, 5, 10, 10, 0, 0, 0, time.UTC), keynote.EndsAt)
+
+	// Stored sessions take part in the room check.
+	imported := rep.Sessions
+	rep, err = im.Import(ctx, strings.NewReader("title,date,time,room\nLate,2027-05-10,10:30,MAIN\n"), Options{Kind: Sessions, Format: CSV})
+	require.NoError(t, err)
+	require.Len(t, rep.Errors, 1)
+	assert.Equal(t, "room", rep.Errors[0].Field)
+	assert.Equal(t, "is booked by session "+imported[1].ID+" at the same time", rep.Errors[0].Message)
+}
+
+func TestImportAttendeesFromJSON(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	im := newImporter(mem)
+	_, err := mem.UpdateEventSettings(ctx, &models.EventSettings{Capacity: 2})
+	require.NoError(t, err)
+	require.NoError(t, mem.CreateAttendee(ctx, &models.Attendee{Name: "Existing", Email: "taken@example.com", Designation: "Engineer"}))
+
+	file := `[
+		{"name": "Ada", "email": "ada@example.com", "designation": "Engineer"},
+		{"name": "Ada Again", "email": " ADA@example.com", "designation": "Engineer"},
+		{"name": "Taken", "email": "taken@example.com", "designation": "Engineer"},
+		{"name": 7, "email": "x@example.com", "designation": "Engineer", "status": "registered"},
+		"not an object"
+	]`
+	rep, err := im.Import(ctx, strings.NewReader(file), Options{Kind: Attendees, Format: JSON})
+	require.NoError(t, err)
+	assert.Equal(t, 5, rep.Rows)
+	assert.Equal(t, []RowError{
+		{Row: 2, Field: "email", Message: "is also on row 1"},
+		{Row: 3, Field: "email", Message: "is already registered"},
+		{Row: 4, Field: "status", Message: "is not allowed"},
+		{Row: 4, Field: "name", Message: "must be a string"},
+		{Row: 5, Message: "must be an object"},
+	}, rep.Errors)
+
+	file = `[
+		{"name": "Ada", "email": "ada@example.com", "designation": "Engineer"},
+		{"name": "Grace", "email": "grace@example.com", "designation": "Admiral", "createdAt": "2026-01-02T03:04:05Z"}
+	]`
+	rep, err = im.Import(ctx, strings.NewReader(file), Options{Kind: Attendees, Format: JSON})
+	require.NoError(t, err)
+	require.Empty(t, rep.Errors)
+	assert.Equal(t, 2, rep.Imported)
+
+	list, err := mem.ListAttendees(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	for _, a := range list {
+		if a.Email == "ada@example.com" {
+			assert.Equal(t, models.StatusRegistered, a.Status)
+		}
+	}
+	waitlist, err := mem.Waitlist(ctx)
+	require.NoError(t, err)
+	require.Len(t, waitlist, 1, "the import fills the last seat, then waitlists")
+	assert.Equal(t, "grace@example.com", waitlist[0].Email)
+	assert.Equal(t, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), waitlist[0].CreatedAt)
+
+	// Attendees always get new IDs, which links and tickets sign.
+	rep, err = im.Import(ctx, strings.NewReader(`[{"id": "a1", "name": "B", "email": "b@example.com", "designation": "E"}]`), Options{Kind: Attendees, Format: JSON})
+	require.NoError(t, err)
+	assert.Equal(t, []RowError{{Row: 1, Field: "id", Message: "is not allowed"}}, rep.Errors)
+}
+
+func TestImportFileErrors(t *testing.T) {
+	ctx := context.Background()
+	im := newImporter(memory.New())
+
+	for _, tc := range []struct {
+		name   string
+		format Format
+		body   string
+		want   []RowError
+	}{
+		{"empty CSV", CSV, "", []RowError{{Message: "is empty"}}},
+		{"header only", CSV, "name,bio\n", []RowError{{Message: "has no rows"}}},
+		{"unknown column", CSV, "name,age,Name\nAda,36,Ada\n", []RowError{
+			{Field: "age", Message: "is not a column; use id, name, bio"},
+			{Field: "name", Message: "is listed twice"},
+		}},
+		{"ragged CSV", CSV, "name,bio\nAda\n", []RowError{{Message: "is not valid CSV: record on line 2: wrong number of fields"}}},
+		{"JSON object", JSON, `{"name": "Ada"}`, []RowError{{Message: "must be a JSON array of objects"}}},
+		{"truncated JSON", JSON, `[{"name": "Ada"}`, []RowError{{Message: "must be a JSON array of objects"}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rep, err := im.Import(ctx, strings.NewReader(tc.body), Options{Kind: Speakers, Format: tc.format})
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, rep.Errors)
+			assert.Zero(t, rep.Imported)
+		})
+	}
+}
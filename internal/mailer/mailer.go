@@ -0,0 +1,203 @@
+// Package mailer renders and delivers the emails sent to attendees. Senders
+// are pluggable: SMTP in production and an outbox that logs and optionally
+// writes .eml files for development and tests. A Queue delivers messages in
+// the background so request handlers never wait on a mail server.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Message is a single email with a plain text body, an optional HTML
+// alternative and optional attachments.
+type Message struct {
+	To          string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Attachment is a file sent with a message.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Sender delivers messages. Implementations must be safe for concurrent use.
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// FromEnv returns an SMTP sender when SMTP_HOST is set and a logging outbox
+// otherwise, writing .eml files to MAIL_OUTBOX_DIR if it is set.
+func FromEnv() Sender {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		dir := os.Getenv("MAIL_OUTBOX_DIR")
+		if dir != "" {
+			log.Printf("Mail is written to the outbox directory %s", dir)
+		} else {
+			log.Println("SMTP_HOST not set; mail is logged instead of sent")
+		}
+		return &Outbox{Dir: dir, From: from}
+	}
+
+	port := 587
+	if v := os.Getenv("SMTP_PORT"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			log.Printf("Ignoring invalid SMTP_PORT %q", v)
+		} else {
+			port = n
+		}
+	}
+	log.Printf("Sending mail through SMTP server %s:%d", host, port)
+	return &SMTP{
+		Host:     host,
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     from,
+	}
+}
+
+// encode renders msg as an RFC 5322 message. Bodies are quoted-printable so
+// long lines and non-ASCII names survive any relay; attachments follow them
+// in a multipart/mixed message, base64 encoded.
+func (msg *Message) encode(from string, now time.Time) ([]byte, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	header := func(k, v string) { fmt.Fprintf(&buf, "%s: %s\r\n", k, v) }
+	header("From", from)
+	header("To", msg.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", "<"+hex.EncodeToString(id)+"@workshop>")
+	header("MIME-Version", "1.0")
+
+	bodyHeader, body, err := msg.body()
+	if err != nil {
+		return nil, err
+	}
+	if len(msg.Attachments) == 0 {
+		header("Content-Type", bodyHeader.Get("Content-Type"))
+		if enc := bodyHeader.Get("Content-Transfer-Encoding"); enc != "" {
+			header("Content-Transfer-Encoding", enc)
+		}
+		buf.WriteString("\r\n")
+		buf.Write(body)
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	buf.WriteString("\r\n")
+	w, err := mw.CreatePart(bodyHeader)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	for _, a := range msg.Attachments {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.ContentType},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64(w, a.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// body renders the text, with its HTML alternative if there is one, and
+// returns the headers describing it.
+func (msg *Message) body() (textproto.MIMEHeader, []byte, error) {
+	var buf bytes.Buffer
+	if msg.HTML == "" {
+		h := textproto.MIMEHeader{
+			"Content-Type":              {"text/plain; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		}
+		if err := writeQP(&buf, msg.Text); err != nil {
+			return nil, nil, err
+		}
+		return h, buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := writeQP(w, part.body); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, nil, err
+	}
+	h := textproto.MIMEHeader{"Content-Type": {"multipart/alternative; boundary=" + mw.Boundary()}}
+	return h, buf.Bytes(), nil
+}
+
+// writeBase64 encodes data in lines of 76 characters, as RFC 2045 requires.
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := io.WriteString(w, encoded+"\r\n")
+	return err
+}
+
+func writeQP(w interface{ Write([]byte) (int, error) }, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
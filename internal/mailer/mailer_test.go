@@ -0,0 +1,199 @@
+package mailer
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmationTemplates(t *testing.T) {
+	r := Registration{EventName: "AI Workshop", Name: "Ada <3", Email: "ada@example.com", Status: "registered", ManageURL: "https://example.com/registration/tok"}
+
+	msg, err := Confirmation(r)
+	require.NoError(t, err)
+	assert.Equal(t, "ada@example.com", msg.To)
+	assert.Equal(t, "You're registered for AI Workshop", msg.Subject)
+	assert.Contains(t, msg.Text, "Hi Ada <3,")
+	assert.Contains(t, msg.Text, "https://example.com/registration/tok")
+	assert.Contains(t, msg.HTML, "Hi Ada &lt;3,", "HTML bodies are escaped")
+
+	r.Status, r.WaitlistPosition = "waitlisted", 3
+	msg, err = Confirmation(r)
+	require.NoError(t, err)
+	assert.Equal(t, "You're on the waitlist for AI Workshop", msg.Subject)
+	assert.Contains(t, msg.Text, "number 3 on the waitlist")
+
+	r.Status, r.Promoted = "registered", true
+	msg, err = Confirmation(r)
+	require.NoError(t, err)
+	assert.Contains(t, msg.Subject, "A seat opened up")
+
+	msg, err = Cancellation(r)
+	require.NoError(t, err)
+	assert.Equal(t, "Your registration for AI Workshop is cancelled", msg.Subject)
+}
+
+func TestReminderTemplate(t *testing.T) {
+	msg, err := Reminder(SessionReminder{
+		Registration: Registration{EventName: "AI Workshop", Name: "Ada", Email: "ada@example.com"},
+		SessionTitle: "Keynote",
+		StartsAt:     "Friday 10 May, 10:00 IST",
+		StartsIn:     "1 hour",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Reminder: Keynote starts in 1 hour", msg.Subject)
+	assert.Contains(t, msg.Text, "at Friday 10 May, 10:00 IST")
+	assert.NotContains(t, msg.HTML, "cancel your registration", "the manage link is optional")
+}
+
+func TestEncode(t *testing.T) {
+	msg := &Message{To: "ada@example.com", Subject: "Grüße", Text: "plain body", HTML: "<p>html body</p>"}
+	raw, err := msg.encode("Workshop <no-reply@example.com>", time.Now())
+	require.NoError(t, err)
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Grüße", subject)
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	mr := multipart.NewReader(parsed.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+		bodies = append(bodies, string(body))
+	}
+	assert.Equal(t, []string{"plain body", "<p>html body</p>"}, bodies)
+}
+
+func TestOutboxWritesFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	o := &Outbox{Dir: dir, From: "no-reply@example.com"}
+
+	require.NoError(t, o.Send(context.Background(), &Message{To: "ada@example.com", Subject: "Hi", Text: "Hello"}))
+	assert.Len(t, o.Sent(), 1)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.True(t, strings.HasSuffix(files[0].Name(), ".eml"))
+}
+
+// flakySender fails the first `failures` sends and then succeeds.
+type flakySender struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	sent     []*Message
+}
+
+func (f *flakySender) Send(ctx context.Context, msg *Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts++
+	if f.attempts <= f.failures {
+		return errors.New("connection refused")
+	}
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+func TestQueueRetries(t *testing.T) {
+	sender := &flakySender{failures: 2}
+	q := newQueue(sender, 1, 3, time.Millisecond)
+
+	q.Enqueue(&Message{To: "ada@example.com"})
+	require.NoError(t, q.Close(context.Background()))
+	assert.Equal(t, 3, sender.attempts)
+	assert.Len(t, sender.sent, 1)
+
+	// Closed queues drop new messages instead of blocking or panicking.
+	q.Enqueue(&Message{To: "bob@example.com"})
+	assert.Len(t, sender.sent, 1)
+}
+
+func TestQueueGivesUp(t *testing.T) {
+	sender := &flakySender{failures: 10}
+	q := newQueue(sender, 1, 2, time.Millisecond)
+
+	q.Enqueue(&Message{To: "ada@example.com"})
+	require.NoError(t, q.Close(context.Background()))
+	assert.Equal(t, 2, sender.attempts)
+	assert.Empty(t, sender.sent)
+}
+
+func TestQueueCloseAbandonsRetriesAtDeadline(t *testing.T) {
+	sender := &flakySender{failures: 10}
+	q := newQueue(sender, 1, 5, time.Hour)
+
+	q.Enqueue(&Message{To: "ada@example.com"})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, q.Close(ctx), context.DeadlineExceeded)
+	assert.Equal(t, 1, sender.attempts)
+}
+
+func TestEncodeAttachments(t *testing.T) {
+	calendar := []byte("BEGIN:VCALENDAR\r\n" + strings.Repeat("X", 200) + "\r\nEND:VCALENDAR\r\n")
+	msg := &Message{
+		To:          "ada@example.com",
+		Subject:     "Hello",
+		Text:        "plain body",
+		Attachments: []Attachment{{Filename: "event.ics", ContentType: "text/calendar; charset=utf-8", Data: calendar}},
+	}
+	raw, err := msg.encode("no-reply@example.com", time.Now())
+	require.NoError(t, err)
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	require.NoError(t, err)
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	mr := multipart.NewReader(parsed.Body, params["boundary"])
+	part, err := mr.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", part.Header.Get("Content-Type"))
+	body, err := io.ReadAll(part)
+	require.NoError(t, err)
+	assert.Equal(t, "plain body", string(body))
+
+	part, err = mr.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "event.ics", part.FileName())
+	assert.Equal(t, "base64", part.Header.Get("Content-Transfer-Encoding"))
+	encoded, err := io.ReadAll(part)
+	require.NoError(t, err)
+	for _, line := range strings.Split(strings.TrimSpace(string(encoded)), "\r\n") {
+		assert.LessOrEqual(t, len(line), 76)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	require.NoError(t, err)
+	assert.Equal(t, calendar, decoded)
+
+	_, err = mr.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
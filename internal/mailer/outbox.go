@@ -0,0 +1,51 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Outbox stands in for a mail server during development and tests. Every
+// message is logged and kept in memory, and also written as an .eml file
+// under Dir when it is set, which any mail client can open.
+type Outbox struct {
+	Dir  string
+	From string
+
+	mu   sync.Mutex
+	sent []*Message
+}
+
+func (o *Outbox) Send(ctx context.Context, msg *Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.sent = append(o.sent, msg)
+	log.Printf("mail: to=%s subject=%q", msg.To, msg.Subject)
+	if o.Dir == "" {
+		return nil
+	}
+
+	now := time.Now()
+	raw, err := msg.encode(o.From, now)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(o.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%03d.eml", now.UTC().Format("20060102T150405.000000000"), len(o.sent))
+	return os.WriteFile(filepath.Join(o.Dir, name), raw, 0o644)
+}
+
+// Sent returns the messages sent so far.
+func (o *Outbox) Sent() []*Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]*Message(nil), o.sent...)
+}
@@ -0,0 +1,158 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Delivery defaults. A message is tried MaxAttempts times with the delay
+// doubling after each failure, so a mail server that is down for a couple
+// of minutes does not lose anything.
+const (
+	DefaultWorkers     = 2
+	DefaultMaxAttempts = 5
+	DefaultBackoff     = 5 * time.Second
+
+	queueSize   = 1000
+	sendTimeout = 30 * time.Second
+)
+
+// ErrClosed is returned by EnqueueWait once the queue is closed.
+var ErrClosed = errors.New("mail queue closed")
+
+// Queue delivers messages in the background. Enqueue never blocks, so a slow
+// or unreachable mail server cannot delay the request that triggered the
+// email.
+type Queue struct {
+	sender      Sender
+	maxAttempts int
+	backoff     time.Duration
+
+	jobs   chan *Message
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewQueue starts workers delivering through sender with the default retry
+// policy.
+func NewQueue(sender Sender, workers int) *Queue {
+	return newQueue(sender, workers, DefaultMaxAttempts, DefaultBackoff)
+}
+
+func newQueue(sender Sender, workers, maxAttempts int, backoff time.Duration) *Queue {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &Queue{
+		sender:      sender,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		jobs:        make(chan *Message, queueSize),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// Enqueue schedules msg for delivery. If the queue is full or closed the
+// message is dropped and logged rather than blocking the caller.
+func (q *Queue) Enqueue(msg *Message) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		log.Printf("mail: queue closed, dropping message to %s", msg.To)
+		return
+	}
+	select {
+	case q.jobs <- msg:
+	default:
+		log.Printf("mail: queue full, dropping message to %s", msg.To)
+	}
+}
+
+// EnqueueWait schedules msg for delivery, waiting for room in the queue
+// instead of dropping it. It is meant for background jobs sending to many
+// recipients, never for request handlers.
+func (q *Queue) EnqueueWait(ctx context.Context, msg *Message) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+	select {
+	case q.jobs <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting messages and waits for queued ones to be delivered.
+// When ctx ends first, pending retries are abandoned.
+func (q *Queue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for msg := range q.jobs {
+		q.deliver(msg)
+	}
+}
+
+// deliver sends msg, retrying with exponential backoff.
+func (q *Queue) deliver(msg *Message) {
+	delay := q.backoff
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(q.ctx, sendTimeout)
+		err := q.sender.Send(ctx, msg)
+		cancel()
+		if err == nil {
+			return
+		}
+		if attempt == q.maxAttempts {
+			log.Printf("mail: giving up on message to %s after %d attempts: %v", msg.To, attempt, err)
+			return
+		}
+		log.Printf("mail: attempt %d to %s failed, retrying in %s: %v", attempt, msg.To, delay, err)
+
+		select {
+		case <-time.After(delay):
+		case <-q.ctx.Done():
+			log.Printf("mail: shutting down, dropping message to %s", msg.To)
+			return
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,96 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Each email kind has a .txt template, which also defines its "subject",
+// and an .html template rendering the same content. Kinds are parsed into
+// separate sets so their subjects do not collide.
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+var templates = parseTemplates("confirmation", "cancellation", "reminder")
+
+func parseTemplates(names ...string) map[string]emailTemplate {
+	out := map[string]emailTemplate{}
+	for _, name := range names {
+		out[name] = emailTemplate{
+			text: texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/"+name+".txt")),
+			html: htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/"+name+".html")),
+		}
+	}
+	return out
+}
+
+// Registration is the data the attendee templates render.
+type Registration struct {
+	EventName        string
+	Name             string
+	Email            string
+	Status           string
+	WaitlistPosition int
+	// Promoted is set when a waitlisted attendee was given a seat.
+	Promoted bool
+	// ManageURL links to the self-service registration page.
+	ManageURL string
+	// TicketURL links to the QR ticket shown at the door, for attendees
+	// holding a seat.
+	TicketURL string
+}
+
+// Confirmation tells an attendee they are registered, waitlisted or, when
+// Promoted is set, moved off the waitlist.
+func Confirmation(r Registration) (*Message, error) {
+	return render("confirmation", r.Email, r)
+}
+
+// Cancellation confirms that a registration was cancelled.
+func Cancellation(r Registration) (*Message, error) {
+	return render("cancellation", r.Email, r)
+}
+
+// SessionReminder is the data of a reminder sent ahead of a session.
+type SessionReminder struct {
+	Registration
+	SessionTitle string
+	// StartsAt is the formatted start time and StartsIn the lead time in
+	// words, such as "24 hours".
+	StartsAt string
+	StartsIn string
+}
+
+// Reminder reminds a registered attendee that a session starts soon.
+func Reminder(r SessionReminder) (*Message, error) {
+	return render("reminder", r.Email, r)
+}
+
+func render(name, to string, data interface{}) (*Message, error) {
+	t := templates[name]
+	var subject, text, html bytes.Buffer
+	if err := t.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := t.text.ExecuteTemplate(&text, name+".txt", data); err != nil {
+		return nil, err
+	}
+	if err := t.html.Execute(&html, data); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
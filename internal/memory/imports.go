@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+// The memory store holds its lock for a whole import, so each import is a
+// single batch: it is stored entirely or not at all.
+
+func (s *Store) ImportAttendees(ctx context.Context, attendees []*models.Attendee) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	taken := map[string]string{}
+	for _, id := range sortedIDs(s.data.Attendees) {
+		if a := s.data.Attendees[id]; a.Status != models.StatusCancelled {
+			taken[models.NormalizeEmail(a.Email)] = id
+		}
+	}
+	for _, a := range attendees {
+		email := models.NormalizeEmail(a.Email)
+		if id, ok := taken[email]; ok {
+			return 0, &store.DuplicateError{ExistingID: id}
+		}
+		taken[email] = a.ID
+	}
+
+	stats := s.stats()
+	for _, a := range attendees {
+		a.Status = models.StatusRegistered
+		a.WaitlistPosition = 0
+		if !stats.HasSeat(s.data.Event.Capacity) {
+			a.Status = models.StatusWaitlisted
+			a.WaitlistPosition = stats.Waitlisted + 1
+		}
+		stats.Add(a.Status, 1)
+		stored := *a
+		stored.WaitlistPosition = 0
+		s.data.Attendees[a.ID] = stored
+	}
+	return len(attendees), nil
+}
+
+func (s *Store) ImportSpeakers(ctx context.Context, speakers []*models.Speaker) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sp := range speakers {
+		if _, ok := s.data.Speakers[sp.ID]; ok {
+			return 0, &store.DuplicateError{ExistingID: sp.ID}
+		}
+	}
+	for _, sp := range speakers {
+		s.data.Speakers[sp.ID] = *sp
+	}
+	return len(speakers), nil
+}
+
+func (s *Store) ImportSessions(ctx context.Context, sessions []*models.Session) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, se := range sessions {
+		se.SyncSpeakers()
+		err := s.checkSession(se)
+		if _, ok := s.data.Sessions[se.ID]; ok && err == nil {
+			err = &store.DuplicateError{ExistingID: se.ID}
+		}
+		if err != nil {
+			for _, added := range sessions[:i] {
+				delete(s.data.Sessions, added.ID)
+			}
+			return 0, err
+		}
+		s.data.Sessions[se.ID] = ownSession(se)
+	}
+	return len(sessions), nil
+}
+
+func (s *Store) IDsInUse(ctx context.Context, collection string, ids []string) (map[string]bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := map[string]bool{}
+	for _, id := range ids {
+		switch collection {
+		case "speakers":
+			_, out[id] = s.data.Speakers[id]
+		case "sessions":
+			_, out[id] = s.data.Sessions[id]
+		default:
+			return nil, fmt.Errorf("memory: cannot look up IDs of %s", collection)
+		}
+	}
+	return out, nil
+}
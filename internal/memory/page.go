@@ -0,0 +1,141 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+// page filters, orders and slices items the way the Firestore backend does
+// with a query cursor: by the OrderBy field, then by ID.
+func page[T any](items []*T, opts store.ListOptions, id func(*T) string, field func(*T, string) string) (*store.Page[T], error) {
+	cursor, err := store.DecodeCursor(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*T
+	for _, item := range items {
+		ok := true
+		for f, v := range opts.Filters {
+			if field(item, f) != v {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, item)
+		}
+	}
+
+	// less orders by (field value, ID), reversed when descending.
+	less := func(av, aid, bv, bid string) bool {
+		if opts.Descending {
+			av, aid, bv, bid = bv, bid, av, aid
+		}
+		if av != bv {
+			return av < bv
+		}
+		return aid < bid
+	}
+	key := func(item *T) string {
+		if opts.OrderBy == "" {
+			return ""
+		}
+		return field(item, opts.OrderBy)
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return less(key(matched[i]), id(matched[i]), key(matched[j]), id(matched[j]))
+	})
+
+	start := 0
+	if cursor != nil {
+		start = sort.Search(len(matched), func(i int) bool {
+			return less(cursor.Value, cursor.ID, key(matched[i]), id(matched[i]))
+		})
+	}
+
+	out := &store.Page[T]{Items: []*T{}}
+	end := start + opts.Limit
+	if end < len(matched) {
+		last := matched[end-1]
+		out.NextPageToken = store.EncodeCursor(store.Cursor{
+			OrderBy:    opts.OrderBy,
+			Descending: opts.Descending,
+			Value:      key(last),
+			ID:         id(last),
+		})
+	} else {
+		end = len(matched)
+	}
+	out.Items = append(out.Items, matched[start:end]...)
+	return out, nil
+}
+
+func attendeeField(a *models.Attendee, name string) string {
+	switch name {
+	case "createdAt":
+		return store.FormatTime(a.CreatedAt)
+	case "name":
+		return a.Name
+	case "email":
+		return a.Email
+	case "designation":
+		return a.Designation
+	case "status":
+		return a.Status
+	}
+	return ""
+}
+
+func speakerField(s *models.Speaker, name string) string {
+	switch name {
+	case "name":
+		return s.Name
+	}
+	return ""
+}
+
+func sessionField(s *models.Session, name string) string {
+	switch name {
+	case "title":
+		return s.Title
+	case "date":
+		return s.Date
+	case "time":
+		return s.Time
+	case "speakerId":
+		return s.SpeakerID
+	case "room":
+		return s.Room
+	case "track":
+		return s.Track
+	}
+	return ""
+}
+
+func (s *Store) PageAttendees(ctx context.Context, opts store.ListOptions) (*store.Page[models.Attendee], error) {
+	items, err := s.ListAttendees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return page(items, opts, func(a *models.Attendee) string { return a.ID }, attendeeField)
+}
+
+func (s *Store) PageSpeakers(ctx context.Context, opts store.ListOptions) (*store.Page[models.Speaker], error) {
+	items, err := s.ListSpeakers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return page(items, opts, func(sp *models.Speaker) string { return sp.ID }, speakerField)
+}
+
+func (s *Store) PageSessions(ctx context.Context, opts store.ListOptions) (*store.Page[models.Session], error) {
+	items, err := s.ListSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return page(items, opts, func(se *models.Session) string { return se.ID }, sessionField)
+}
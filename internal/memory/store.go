@@ -0,0 +1,720 @@
+// Package memory provides an in-process implementation of the store
+// interfaces. It is intended for local development and CI where Firestore
+// credentials are not available, and can optionally persist its contents to a
+// JSON snapshot between runs.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+var (
+	_ store.AttendeeStore   = (*Store)(nil)
+	_ store.SpeakerStore    = (*Store)(nil)
+	_ store.SessionStore    = (*Store)(nil)
+	_ store.AdminStore      = (*Store)(nil)
+	_ store.ReminderStore   = (*Store)(nil)
+	_ store.EnrollmentStore = (*Store)(nil)
+	_ store.WorkshopStore   = (*Store)(nil)
+	_ store.ImportStore     = (*Store)(nil)
+)
+
+// Store keeps every collection in memory, keyed by document ID. Documents are
+// stored by value so callers never share memory with the store.
+type Store struct {
+	mu   sync.RWMutex
+	data snapshot
+	// workshops holds a Store for the data of each workshop, created by
+	// Scoped. The default workshop's data is data itself.
+	workshops map[string]*Store
+}
+
+// snapshot is the JSON layout used by Load and Save.
+type snapshot struct {
+	Attendees map[string]models.Attendee `json:"attendees"`
+	Speakers  map[string]models.Speaker  `json:"speakers"`
+	Sessions  map[string]models.Session  `json:"sessions"`
+	Admins    map[string]adminRecord     `json:"admins"`
+	Event     models.EventSettings       `json:"event"`
+	Reminders map[string]models.Reminder `json:"reminders"`
+	// Enrollments are keyed by enrollmentKey.
+	Enrollments map[string]models.Enrollment `json:"enrollments"`
+	Workshops   map[string]models.Workshop   `json:"workshops,omitempty"`
+	// WorkshopData is the data of each workshop, keyed by workshop ID.
+	WorkshopData map[string]*snapshot `json:"workshopData,omitempty"`
+}
+
+// adminRecord persists the password hash, which models.Admin keeps out of
+// its JSON encoding.
+type adminRecord struct {
+	models.Admin
+	PasswordHash string `json:"passwordHash"`
+}
+
+// New returns an empty Store.
+func New() *Store {
+	s := &Store{workshops: map[string]*Store{}}
+	s.data.init()
+	return s
+}
+
+func (d *snapshot) init() {
+	if d.Attendees == nil {
+		d.Attendees = map[string]models.Attendee{}
+	}
+	if d.Speakers == nil {
+		d.Speakers = map[string]models.Speaker{}
+	}
+	if d.Sessions == nil {
+		d.Sessions = map[string]models.Session{}
+	}
+	if d.Admins == nil {
+		d.Admins = map[string]adminRecord{}
+	}
+	if d.Reminders == nil {
+		d.Reminders = map[string]models.Reminder{}
+	}
+	if d.Enrollments == nil {
+		d.Enrollments = map[string]models.Enrollment{}
+	}
+	if d.Workshops == nil {
+		d.Workshops = map[string]models.Workshop{}
+	}
+	for id, a := range d.Attendees {
+		if a.Status == "" {
+			a.Status = models.StatusRegistered
+			d.Attendees[id] = a
+		}
+	}
+}
+
+// Load replaces the store contents with the snapshot at path. A missing file
+// is not an error so the first run can start from an empty store.
+func (s *Store) Load(path string) error {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var data snapshot
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return err
+	}
+	data.init()
+	workshops := map[string]*Store{}
+	for id, d := range data.WorkshopData {
+		d.init()
+		workshops[id] = &Store{data: *d}
+	}
+	data.WorkshopData = nil
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data, s.workshops = data, workshops
+	return nil
+}
+
+// Save writes the store contents to path as JSON. The snapshot is written to
+// a temporary file first so a crash mid-write never leaves a truncated file.
+func (s *Store) Save(path string) error {
+	raw, err := s.encode()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// encode marshals the store contents together with those of every
+// workshop.
+func (s *Store) encode() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data := s.data
+	data.WorkshopData = map[string]*snapshot{}
+	for id, w := range s.workshops {
+		w.mu.RLock()
+		defer w.mu.RUnlock()
+		data.WorkshopData[id] = &w.data
+	}
+	return json.MarshalIndent(data, "", "  ")
+}
+
+// sortedIDs returns the keys of m in ascending order, which matches
+// Firestore's default document ordering.
+func sortedIDs[T any](m map[string]T) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Attendees
+
+func (s *Store) ListAttendees(ctx context.Context) ([]*models.Attendee, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*models.Attendee
+	for _, id := range sortedIDs(s.data.Attendees) {
+		a := s.data.Attendees[id]
+		a.ID = id
+		out = append(out, &a)
+	}
+	return out, nil
+}
+
+func (s *Store) GetAttendee(ctx context.Context, id string) (*models.Attendee, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a, ok := s.data.Attendees[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	a.ID = id
+	return &a, nil
+}
+
+func (s *Store) CreateAttendee(ctx context.Context, attendee *models.Attendee) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	email := models.NormalizeEmail(attendee.Email)
+	for _, id := range sortedIDs(s.data.Attendees) {
+		a := s.data.Attendees[id]
+		if a.Status != models.StatusCancelled && models.NormalizeEmail(a.Email) == email {
+			return &store.DuplicateError{ExistingID: id}
+		}
+	}
+
+	stats := s.stats()
+	attendee.Status = models.StatusRegistered
+	attendee.WaitlistPosition = 0
+	if !stats.HasSeat(s.data.Event.Capacity) {
+		attendee.Status = models.StatusWaitlisted
+		attendee.WaitlistPosition = stats.Waitlisted + 1
+	}
+
+	attendee.ID = store.NewID()
+	stored := *attendee
+	stored.WaitlistPosition = 0 // computed on read, never stored
+	s.data.Attendees[attendee.ID] = stored
+	return nil
+}
+
+func (s *Store) UpdateAttendee(ctx context.Context, attendee *models.Attendee) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.data.Attendees[attendee.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if stored.Status == models.StatusCancelled {
+		return store.ErrCancelled
+	}
+	email := models.NormalizeEmail(attendee.Email)
+	for _, id := range sortedIDs(s.data.Attendees) {
+		a := s.data.Attendees[id]
+		if id != attendee.ID && a.Status != models.StatusCancelled && models.NormalizeEmail(a.Email) == email {
+			return &store.DuplicateError{ExistingID: id}
+		}
+	}
+
+	stored.Name, stored.Email, stored.Designation = attendee.Name, attendee.Email, attendee.Designation
+	s.data.Attendees[attendee.ID] = stored
+	stored.ID = attendee.ID
+	*attendee = stored
+	return nil
+}
+
+func (s *Store) CancelAttendee(ctx context.Context, id string) (*models.Attendee, []*models.Attendee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.data.Attendees[id]
+	if !ok {
+		return nil, nil, store.ErrNotFound
+	}
+	if a.Status != models.StatusCancelled {
+		now := time.Now().UTC()
+		a.Status = models.StatusCancelled
+		a.CancelledAt = &now
+		s.data.Attendees[id] = a
+	}
+	a.ID = id
+	return &a, s.promote(), nil
+}
+
+func (s *Store) CheckInAttendee(ctx context.Context, id, operator string) (*models.Attendee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.data.Attendees[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	a.ID = id
+	if err := store.CheckIn(&a, operator, time.Now().UTC()); err != nil {
+		return &a, err
+	}
+	s.data.Attendees[id] = a
+	return &a, nil
+}
+
+func (s *Store) Waitlist(ctx context.Context) ([]*models.Attendee, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.waitlist(), nil
+}
+
+func (s *Store) AttendeeStats(ctx context.Context) (store.AttendeeStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.stats(), nil
+}
+
+func (s *Store) MergeAttendees(ctx context.Context, keep *models.Attendee, duplicateIDs []string) ([]*models.Attendee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range duplicateIDs {
+		delete(s.data.Attendees, id)
+	}
+	s.data.Attendees[keep.ID] = *keep
+	return s.promote(), nil
+}
+
+func (s *Store) EventSettings(ctx context.Context) (*models.EventSettings, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	settings := s.data.Event
+	return &settings, nil
+}
+
+func (s *Store) UpdateEventSettings(ctx context.Context, settings *models.EventSettings) ([]*models.Attendee, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Event = *settings
+	return s.promote(), nil
+}
+
+func (s *Store) stats() store.AttendeeStats {
+	var stats store.AttendeeStats
+	for _, a := range s.data.Attendees {
+		stats.Add(a.Status, 1)
+	}
+	return stats
+}
+
+// waitlist returns waitlisted attendees by registration time, then ID.
+func (s *Store) waitlist() []*models.Attendee {
+	var out []*models.Attendee
+	for _, id := range sortedIDs(s.data.Attendees) {
+		a := s.data.Attendees[id]
+		if a.Status == models.StatusWaitlisted {
+			a.ID = id
+			out = append(out, &a)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	for i, a := range out {
+		a.WaitlistPosition = i + 1
+	}
+	return out
+}
+
+// promote moves waitlisted attendees into open seats. The caller must hold
+// the write lock.
+func (s *Store) promote() []*models.Attendee {
+	n := s.stats().Promotable(s.data.Event.Capacity)
+	if n == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	promoted := s.waitlist()[:n]
+	for _, a := range promoted {
+		a.Status = models.StatusRegistered
+		a.WaitlistPosition = 0
+		a.PromotedAt = &now
+		s.data.Attendees[a.ID] = *a
+	}
+	return promoted
+}
+
+// Speakers
+
+func (s *Store) ListSpeakers(ctx context.Context) ([]*models.Speaker, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*models.Speaker
+	for _, id := range sortedIDs(s.data.Speakers) {
+		sp := s.data.Speakers[id]
+		sp.ID = id
+		out = append(out, &sp)
+	}
+	return out, nil
+}
+
+func (s *Store) GetSpeakers(ctx context.Context, ids []string) (map[string]*models.Speaker, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make(map[string]*models.Speaker, len(ids))
+	for _, id := range ids {
+		if sp, ok := s.data.Speakers[id]; ok {
+			sp.ID = id
+			out[id] = &sp
+		}
+	}
+	return out, nil
+}
+
+func (s *Store) CreateSpeaker(ctx context.Context, speaker *models.Speaker) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	speaker.ID = store.NewID()
+	s.data.Speakers[speaker.ID] = *speaker
+	return nil
+}
+
+func (s *Store) UpdateSpeaker(ctx context.Context, speaker *models.Speaker) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data.Speakers[speaker.ID]; !ok {
+		return store.ErrNotFound
+	}
+	s.data.Speakers[speaker.ID] = *speaker
+	return nil
+}
+
+func (s *Store) DeleteSpeaker(ctx context.Context, id string, cascade bool) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var sessionIDs []string
+	for _, sid := range sortedIDs(s.data.Sessions) {
+		se := s.data.Sessions[sid]
+		if se.HasSpeaker(id) {
+			sessionIDs = append(sessionIDs, sid)
+		}
+	}
+	if len(sessionIDs) > 0 && !cascade {
+		return sessionIDs, &store.SpeakerInUseError{SessionIDs: sessionIDs}
+	}
+
+	for _, sid := range sessionIDs {
+		se := s.data.Sessions[sid]
+		se.RemoveSpeaker(id)
+		s.data.Sessions[sid] = se
+	}
+	delete(s.data.Speakers, id)
+	return sessionIDs, nil
+}
+
+// checkSession reports the speakers a session names that do not exist and
+// room double bookings. The caller must hold the lock.
+func (s *Store) checkSession(session *models.Session) error {
+	var missing []string
+	for _, id := range session.SpeakerIDs {
+		if _, ok := s.data.Speakers[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		return &store.MissingSpeakersError{IDs: missing}
+	}
+	if session.Room == "" {
+		return nil
+	}
+	var others []*models.Session
+	for _, id := range sortedIDs(s.data.Sessions) {
+		other := s.data.Sessions[id]
+		other.ID = id
+		others = append(others, &other)
+	}
+	return store.CheckRoom(session, others)
+}
+
+// Sessions
+
+// ownSession returns the copy of session the store keeps, so the caller
+// cannot change its speakers afterwards.
+func ownSession(session *models.Session) models.Session {
+	se := *session
+	se.SpeakerIDs = append([]string(nil), session.SpeakerIDs...)
+	return se
+}
+
+func (s *Store) ListSessions(ctx context.Context) ([]*models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*models.Session
+	for _, id := range sortedIDs(s.data.Sessions) {
+		se := s.data.Sessions[id]
+		se.ID = id
+		se.SpeakerIDs = append([]string(nil), se.SpeakerIDs...)
+		out = append(out, &se)
+	}
+	return out, nil
+}
+
+func (s *Store) CreateSession(ctx context.Context, session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session.SyncSpeakers()
+	if err := s.checkSession(session); err != nil {
+		return err
+	}
+	session.ID = store.NewID()
+	s.data.Sessions[session.ID] = ownSession(session)
+	return nil
+}
+
+func (s *Store) UpdateSession(ctx context.Context, session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data.Sessions[session.ID]; !ok {
+		return store.ErrNotFound
+	}
+	session.SyncSpeakers()
+	if err := s.checkSession(session); err != nil {
+		return err
+	}
+	s.data.Sessions[session.ID] = ownSession(session)
+	return nil
+}
+
+func (s *Store) DeleteSession(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data.Sessions, id)
+	return nil
+}
+
+// Admins
+
+func (s *Store) ListAdmins(ctx context.Context) ([]*models.Admin, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*models.Admin
+	for _, username := range sortedIDs(s.data.Admins) {
+		out = append(out, s.data.Admins[username].admin(username))
+	}
+	return out, nil
+}
+
+func (s *Store) GetAdmin(ctx context.Context, username string) (*models.Admin, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rec, ok := s.data.Admins[username]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return rec.admin(username), nil
+}
+
+func (s *Store) CreateAdmin(ctx context.Context, admin *models.Admin) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data.Admins[admin.Username]; ok {
+		return &store.DuplicateError{ExistingID: admin.Username}
+	}
+	s.data.Admins[admin.Username] = adminRecord{Admin: *admin, PasswordHash: admin.PasswordHash}
+	return nil
+}
+
+func (s *Store) UpdateAdmin(ctx context.Context, admin *models.Admin) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data.Admins[admin.Username]; !ok {
+		return store.ErrNotFound
+	}
+	s.data.Admins[admin.Username] = adminRecord{Admin: *admin, PasswordHash: admin.PasswordHash}
+	return nil
+}
+
+// Reminders
+
+func (s *Store) ListReminders(ctx context.Context) ([]*models.Reminder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*models.Reminder
+	for _, id := range sortedIDs(s.data.Reminders) {
+		r := s.data.Reminders[id]
+		r.ID = id
+		out = append(out, &r)
+	}
+	return out, nil
+}
+
+func (s *Store) GetReminder(ctx context.Context, id string) (*models.Reminder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.data.Reminders[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	r.ID = id
+	return &r, nil
+}
+
+func (s *Store) UpdateReminder(ctx context.Context, id string, update func(*models.Reminder) error) (*models.Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := s.data.Reminders[id]
+	r.ID = id
+	if err := update(&r); err != nil {
+		return nil, err
+	}
+	s.data.Reminders[id] = r
+	out := r
+	return &out, nil
+}
+
+// Enrollments
+
+func enrollmentKey(sessionID, attendeeID string) string {
+	return sessionID + "/" + attendeeID
+}
+
+func (s *Store) Enroll(ctx context.Context, sessionID, attendeeID string) (*models.Enrollment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.data.Sessions[sessionID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	session.ID = sessionID
+	a, ok := s.data.Attendees[attendeeID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	if !a.HoldsSeat() {
+		return nil, store.ErrNoSeat
+	}
+	key := enrollmentKey(sessionID, attendeeID)
+	if e, ok := s.data.Enrollments[key]; ok {
+		return &e, nil
+	}
+
+	enrolled := 0
+	var schedule []*models.Session
+	for _, e := range s.data.Enrollments {
+		if e.SessionID == sessionID {
+			enrolled++
+		}
+		if other, ok := s.data.Sessions[e.SessionID]; ok && e.AttendeeID == attendeeID {
+			other.ID = e.SessionID
+			schedule = append(schedule, &other)
+		}
+	}
+	if err := store.CheckEnrollment(&session, enrolled, schedule); err != nil {
+		return nil, err
+	}
+
+	e := models.Enrollment{SessionID: sessionID, AttendeeID: attendeeID, CreatedAt: time.Now().UTC()}
+	s.data.Enrollments[key] = e
+	return &e, nil
+}
+
+func (s *Store) Unenroll(ctx context.Context, sessionID, attendeeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data.Enrollments, enrollmentKey(sessionID, attendeeID))
+	return nil
+}
+
+func (s *Store) ListEnrollments(ctx context.Context, filter store.EnrollmentFilter) ([]*models.Enrollment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*models.Enrollment
+	for _, key := range sortedIDs(s.data.Enrollments) {
+		e := s.data.Enrollments[key]
+		if filter.Matches(&e) {
+			out = append(out, &e)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *Store) DeleteEnrollments(ctx context.Context, filter store.EnrollmentFilter) error {
+	if filter == (store.EnrollmentFilter{}) {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, e := range s.data.Enrollments {
+		if filter.Matches(&e) {
+			delete(s.data.Enrollments, key)
+		}
+	}
+	return nil
+}
+
+func (s *Store) EnrollmentCounts(ctx context.Context) (map[string]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := map[string]int{}
+	for _, e := range s.data.Enrollments {
+		counts[e.SessionID]++
+	}
+	return counts, nil
+}
+
+func (r adminRecord) admin(username string) *models.Admin {
+	a := r.Admin
+	a.Username = username
+	a.PasswordHash = r.PasswordHash
+	return &a
+}
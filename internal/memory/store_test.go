@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpeakerLifecycle(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	speaker := &models.Speaker{Name: "Ada"}
+	require.NoError(t, s.CreateSpeaker(ctx, speaker))
+	assert.Len(t, speaker.ID, 20)
+
+	speaker.Name = "Ada Lovelace"
+	require.NoError(t, s.UpdateSpeaker(ctx, speaker))
+
+	speakers, err := s.ListSpeakers(ctx)
+	require.NoError(t, err)
+	require.Len(t, speakers, 1)
+	assert.Equal(t, speaker.ID, speakers[0].ID)
+	assert.Equal(t, "Ada Lovelace", speakers[0].Name)
+
+	_, err = s.DeleteSpeaker(ctx, speaker.ID, false)
+	require.NoError(t, err)
+	speakers, err = s.ListSpeakers(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, speakers)
+}
+
+func TestUpdateMissingDocument(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	err := s.UpdateSpeaker(ctx, &models.Speaker{ID: "missing", Name: "Nobody"})
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	err = s.UpdateSession(ctx, &models.Session{ID: "missing", Title: "Nothing"})
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestListReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	input := &models.Session{Title: "Keynote"}
+	require.NoError(t, s.CreateSession(ctx, input))
+	input.Title = "mutated"
+
+	sessions, err := s.ListSessions(ctx)
+	require.NoError(t, err)
+	sessions[0].Title = "mutated again"
+
+	sessions, err = s.ListSessions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Keynote", sessions[0].Title)
+
+	// Speaker lists are copied both ways too.
+	ada, bob := &models.Speaker{Name: "Ada"}, &models.Speaker{Name: "Bob"}
+	require.NoError(t, s.CreateSpeaker(ctx, ada))
+	require.NoError(t, s.CreateSpeaker(ctx, bob))
+	input = &models.Session{Title: "Panel", SpeakerIDs: []string{ada.ID}}
+	require.NoError(t, s.CreateSession(ctx, input))
+	input.SpeakerIDs[0] = bob.ID
+	input.SpeakerIDs = []string{ada.ID, bob.ID}
+	require.NoError(t, s.UpdateSession(ctx, input))
+	input.SpeakerIDs[1] = ada.ID
+
+	page, err := s.PageSessions(ctx, store.ListOptions{Limit: 10})
+	require.NoError(t, err)
+	for _, se := range page.Items {
+		se.SpeakerIDs = append(se.SpeakerIDs[:0], "mutated")
+	}
+	sessions, err = s.ListSessions(ctx)
+	require.NoError(t, err)
+	for _, se := range sessions {
+		if se.ID == input.ID {
+			assert.Equal(t, []string{ada.ID, bob.ID}, se.SpeakerIDs)
+		}
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	s := New()
+	require.NoError(t, s.Load(path), "missing snapshot should not be an error")
+
+	require.NoError(t, s.CreateAttendee(ctx, &models.Attendee{Name: "Grace", Email: "grace@example.com"}))
+	require.NoError(t, s.Save(path))
+
+	restored := New()
+	require.NoError(t, restored.Load(path))
+
+	stats, err := restored.AttendeeStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Registered)
+
+	attendees, err := restored.ListAttendees(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "grace@example.com", attendees[0].Email)
+}
+
+func TestWorkshopSnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	s := New()
+	require.NoError(t, s.CreateWorkshop(ctx, &models.Workshop{ID: "spring", Name: "Spring"}))
+	require.NoError(t, s.Scoped("spring").Attendees.CreateAttendee(ctx, &models.Attendee{Name: "Grace", Email: "grace@example.com"}))
+	require.NoError(t, s.Save(path))
+
+	restored := New()
+	require.NoError(t, restored.Load(path))
+
+	ws, err := restored.GetWorkshop(ctx, "spring")
+	require.NoError(t, err)
+	assert.Equal(t, "Spring", ws.Name)
+	stats, err := restored.AttendeeStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.Registered)
+	stats, err = restored.Scoped("spring").Attendees.AttendeeStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Registered)
+}
+
+func TestWaitlistPromotion(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	_, err := s.UpdateEventSettings(ctx, &models.EventSettings{Capacity: 1})
+	require.NoError(t, err)
+
+	base := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	first := &models.Attendee{Email: "a@example.com", CreatedAt: base}
+	second := &models.Attendee{Email: "b@example.com", CreatedAt: base.Add(time.Minute)}
+	require.NoError(t, s.CreateAttendee(ctx, first))
+	require.NoError(t, s.CreateAttendee(ctx, second))
+	assert.Equal(t, models.StatusWaitlisted, second.Status)
+	assert.Equal(t, 1, second.WaitlistPosition)
+
+	_, promoted, err := s.CancelAttendee(ctx, first.ID)
+	require.NoError(t, err)
+	require.Len(t, promoted, 1)
+	assert.Equal(t, second.ID, promoted[0].ID)
+	assert.NotNil(t, promoted[0].PromotedAt)
+
+	stats, err := s.AttendeeStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, store.AttendeeStats{Registered: 1, Cancelled: 1}, stats)
+
+	_, _, err = s.CancelAttendee(ctx, "missing")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestAdminSnapshotKeepsPasswordHash(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	s := New()
+	require.NoError(t, s.CreateAdmin(ctx, &models.Admin{Username: "alice", PasswordHash: "hash"}))
+	var dup *store.DuplicateError
+	assert.ErrorAs(t, s.CreateAdmin(ctx, &models.Admin{Username: "alice"}), &dup)
+	require.NoError(t, s.Save(path))
+
+	restored := New()
+	require.NoError(t, restored.Load(path))
+	admin, err := restored.GetAdmin(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "hash", admin.PasswordHash)
+
+	_, err = restored.GetAdmin(ctx, "nobody")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
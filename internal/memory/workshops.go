@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+func (s *Store) ListWorkshops(ctx context.Context) ([]*models.Workshop, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*models.Workshop
+	for _, id := range sortedIDs(s.data.Workshops) {
+		w := s.data.Workshops[id]
+		w.ID = id
+		out = append(out, &w)
+	}
+	return out, nil
+}
+
+func (s *Store) GetWorkshop(ctx context.Context, id string) (*models.Workshop, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	w, ok := s.data.Workshops[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	w.ID = id
+	return &w, nil
+}
+
+func (s *Store) CreateWorkshop(ctx context.Context, workshop *models.Workshop) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data.Workshops[workshop.ID]; ok {
+		return &store.DuplicateError{ExistingID: workshop.ID}
+	}
+	now := time.Now().UTC()
+	workshop.CreatedAt, workshop.UpdatedAt = now, now
+	s.data.Workshops[workshop.ID] = *workshop
+	return nil
+}
+
+func (s *Store) CloneWorkshop(ctx context.Context, workshop *models.Workshop, speakers []*models.Speaker, sessions []*models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data.Workshops[workshop.ID]; ok {
+		return &store.DuplicateError{ExistingID: workshop.ID}
+	}
+	data := New()
+	for _, sp := range speakers {
+		data.data.Speakers[sp.ID] = *sp
+	}
+	for _, se := range sessions {
+		se.SyncSpeakers()
+		if err := data.checkSession(se); err != nil {
+			return err
+		}
+		data.data.Sessions[se.ID] = ownSession(se)
+	}
+
+	now := time.Now().UTC()
+	workshop.CreatedAt, workshop.UpdatedAt = now, now
+	s.data.Workshops[workshop.ID] = *workshop
+	s.workshops[workshop.ID] = data
+	return nil
+}
+
+func (s *Store) UpdateWorkshop(ctx context.Context, workshop *models.Workshop) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.data.Workshops[workshop.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	workshop.CreatedAt, workshop.UpdatedAt = stored.CreatedAt, time.Now().UTC()
+	s.data.Workshops[workshop.ID] = *workshop
+	return nil
+}
+
+func (s *Store) DeleteWorkshop(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data.Workshops, id)
+	delete(s.workshops, id)
+	return nil
+}
+
+// Scoped returns a Store for the workshop's data, creating it on first use.
+func (s *Store) Scoped(id string) store.Stores {
+	scoped := s
+	if id != "" {
+		s.mu.Lock()
+		if s.workshops[id] == nil {
+			s.workshops[id] = New()
+		}
+		scoped = s.workshops[id]
+		s.mu.Unlock()
+	}
+	return store.Stores{
+		Attendees:   scoped,
+		Speakers:    scoped,
+		Sessions:    scoped,
+		Admins:      s,
+		Reminders:   scoped,
+		Enrollments: scoped,
+		Workshops:   s,
+		Imports:     scoped,
+	}
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Admin is a named administrator account. The password hash is never
+// serialized to JSON so accounts can be returned from the API as-is.
+type Admin struct {
+	Username          string    `json:"username" firestore:"-"`
+	PasswordHash      string    `json:"-" firestore:"passwordHash"`
+	Role              Role      `json:"role" firestore:"role"`
+	Disabled          bool      `json:"disabled" firestore:"disabled"`
+	CreatedAt         time.Time `json:"createdAt" firestore:"createdAt"`
+	PasswordChangedAt time.Time `json:"passwordChangedAt" firestore:"passwordChangedAt"`
+}
+
+// Role determines what an admin account may do; see auth.Permissions.
+type Role string
+
+// Roles, from most to least privileged.
+const (
+	// RoleOwner can do everything, including managing admin accounts.
+	RoleOwner Role = "owner"
+	// RoleOrganizer runs the event: attendees, speakers and sessions.
+	RoleOrganizer Role = "organizer"
+	// RoleCoordinator maintains speaker profiles only.
+	RoleCoordinator Role = "coordinator"
+	// RoleCheckin is for volunteers checking attendees in at the door.
+	RoleCheckin Role = "checkin"
+	// RoleViewer has read-only access to the dashboard.
+	RoleViewer Role = "viewer"
+)
+
+// Roles lists every valid role, from most to least privileged.
+var Roles = []Role{RoleOwner, RoleOrganizer, RoleCoordinator, RoleCheckin, RoleViewer}
+
+// Valid reports whether r is one of Roles.
+func (r Role) Valid() bool {
+	for _, role := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateRole checks a role assigned to an account.
+func ValidateRole(role Role) error {
+	var errs ValidationErrors
+	errs.role("role", role)
+	return errs.err()
+}
+
+func (e *ValidationErrors) role(field string, value Role) {
+	e.required(field, string(value))
+	if value != "" && !value.Valid() {
+		names := make([]string, len(Roles))
+		for i, r := range Roles {
+			names[i] = string(r)
+		}
+		e.Add(field, "must be one of "+strings.Join(names, ", "))
+	}
+}
+
+// MinPasswordLength is the shortest admin password accepted.
+const MinPasswordLength = 10
+
+var usernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{2,63}$`)
+
+// ValidateNewAdmin checks the username, password and role of a new account.
+func ValidateNewAdmin(username, password string, role Role) error {
+	var errs ValidationErrors
+	errs.required("username", username)
+	if username != "" && !usernamePattern.MatchString(username) {
+		errs.Add("username", "must be 3-64 lowercase letters, digits, '.', '_' or '-'")
+	}
+	errs.password("password", password)
+	errs.role("role", role)
+	return errs.err()
+}
+
+// ValidatePassword checks a new admin password.
+func ValidatePassword(password string) error {
+	var errs ValidationErrors
+	errs.password("password", password)
+	return errs.err()
+}
+
+func (e *ValidationErrors) password(field, value string) {
+	e.required(field, value)
+	if value != "" && len(value) < MinPasswordLength {
+		e.Add(field, fmt.Sprintf("must be at least %d characters", MinPasswordLength))
+	}
+	// bcrypt ignores everything past 72 bytes
+	if len(value) > 72 {
+		e.Add(field, "must be at most 72 bytes")
+	}
+}
@@ -0,0 +1,359 @@
+// Package models defines the typed documents the API accepts and stores,
+// together with the server-side validation rules for each of them.
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Attendee is a workshop registration. Status and the fields after it are
+// managed by the server and ignored when clients send them.
+type Attendee struct {
+	ID          string    `json:"id" firestore:"-"`
+	Name        string    `json:"name" firestore:"name"`
+	Email       string    `json:"email" firestore:"email"`
+	Designation string    `json:"designation" firestore:"designation"`
+	CreatedAt   time.Time `json:"createdAt" firestore:"createdAt"`
+	Status      string    `json:"status" firestore:"status"`
+	// WaitlistPosition is the 1-based place in the waitlist, reported when a
+	// registration is waitlisted. It is computed, not stored.
+	WaitlistPosition int        `json:"waitlistPosition,omitempty" firestore:"-"`
+	PromotedAt       *time.Time `json:"promotedAt,omitempty" firestore:"promotedAt,omitempty"`
+	CancelledAt      *time.Time `json:"cancelledAt,omitempty" firestore:"cancelledAt,omitempty"`
+	// CheckedInAt and CheckedInBy record when and by which admin the
+	// attendee was checked in at the door.
+	CheckedInAt *time.Time `json:"checkedInAt,omitempty" firestore:"checkedInAt,omitempty"`
+	CheckedInBy string     `json:"checkedInBy,omitempty" firestore:"checkedInBy,omitempty"`
+}
+
+// Attendee statuses. Registrations stored before statuses existed have an
+// empty status and hold a seat.
+const (
+	StatusRegistered = "registered"
+	StatusWaitlisted = "waitlisted"
+	StatusCancelled  = "cancelled"
+)
+
+// HoldsSeat reports whether the attendee counts against capacity.
+func (a *Attendee) HoldsSeat() bool {
+	return a.Status == StatusRegistered || a.Status == ""
+}
+
+// EventSettings configures registration for the event.
+type EventSettings struct {
+	// Capacity is the number of seats; 0 means unlimited. Registrations
+	// beyond it are waitlisted.
+	Capacity int `json:"capacity" firestore:"capacity"`
+}
+
+// Normalize is a no-op; it lets EventSettings be decoded like other models.
+func (e *EventSettings) Normalize() {}
+
+// Validate reports whether the settings are acceptable.
+func (e *EventSettings) Validate() error {
+	var errs ValidationErrors
+	if e.Capacity < 0 {
+		errs.Add("capacity", "must be zero (unlimited) or a positive number")
+	}
+	return errs.err()
+}
+
+// CheckIn is a request to check in the attendee a scanned ticket belongs to.
+type CheckIn struct {
+	Ticket string `json:"ticket"`
+}
+
+// Normalize trims the whitespace scanners sometimes append.
+func (c *CheckIn) Normalize() {
+	c.Ticket = trim(c.Ticket)
+}
+
+// Validate reports whether a ticket was given.
+func (c *CheckIn) Validate() error {
+	var errs ValidationErrors
+	errs.required("ticket", c.Ticket)
+	return errs.err()
+}
+
+// NormalizeEmail returns the key used to detect duplicate registrations:
+// the address trimmed and lower-cased.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Speaker is a speaker profile shown on the public agenda.
+type Speaker struct {
+	ID   string `json:"id" firestore:"-"`
+	Name string `json:"name" firestore:"name"`
+	Bio  string `json:"bio" firestore:"bio"`
+}
+
+// Session is a single agenda slot. It runs from StartsAt to EndsAt. Date
+// (YYYY-MM-DD), Time (24-hour HH:MM) and Duration show the same schedule as
+// wall clock time in the event time zone, matching the dashboard's date and
+// time inputs; see SyncSchedule.
+type Session struct {
+	ID          string `json:"id" firestore:"-"`
+	Title       string `json:"title" firestore:"title"`
+	Description string `json:"description" firestore:"description"`
+	Date        string `json:"date" firestore:"date"`
+	Time        string `json:"time" firestore:"time"`
+	SpeakerID   string `json:"speakerId" firestore:"speakerId"`
+	// SpeakerIDs lists every speaker of the session in billing order.
+	// SpeakerID is kept equal to the first of them for clients that only
+	// know about one speaker; see SyncSpeakers.
+	SpeakerIDs []string `json:"speakerIds" firestore:"speakerIds"`
+	// Duration is the length of the session in minutes; 0 means
+	// DefaultSessionMinutes.
+	Duration int `json:"duration" firestore:"duration"`
+	// Capacity limits how many attendees may enroll; 0 means unlimited.
+	Capacity int `json:"capacity" firestore:"capacity"`
+	// StartsAt and EndsAt are the instants the session runs between, in
+	// UTC once synced.
+	StartsAt time.Time `json:"startsAt" firestore:"startsAt"`
+	EndsAt   time.Time `json:"endsAt" firestore:"endsAt"`
+	// Room is where the session takes place. Two sessions cannot be in the
+	// same room at the same time.
+	Room string `json:"room" firestore:"room"`
+	// Track groups related sessions on the agenda.
+	Track string `json:"track" firestore:"track"`
+	// Enrolled is the number of attendees enrolled. It is computed, not
+	// stored.
+	Enrolled int `json:"enrolled" firestore:"-"`
+}
+
+// DefaultSessionMinutes is the length of a session without a Duration.
+const DefaultSessionMinutes = 60
+
+// Enrollment records that an attendee chose to attend a session.
+type Enrollment struct {
+	SessionID  string    `json:"sessionId" firestore:"sessionId"`
+	AttendeeID string    `json:"attendeeId" firestore:"attendeeId"`
+	CreatedAt  time.Time `json:"createdAt" firestore:"createdAt"`
+}
+
+// Field length limits.
+const (
+	MaxNameLength        = 100
+	MaxEmailLength       = 254
+	MaxDesignationLength = 100
+	MaxBioLength         = 2000
+	MaxTitleLength       = 200
+	MaxDescriptionLength = 5000
+	MaxIDLength          = 128
+	MaxSessionMinutes    = 24 * 60
+	MaxSessionSpeakers   = 10
+	MaxLabelLength       = 100
+)
+
+// Layouts accepted for Session.Date and Session.Time.
+const (
+	DateLayout = "2006-01-02"
+	TimeLayout = "15:04"
+)
+
+// Normalize trims surrounding whitespace from every text field.
+func (a *Attendee) Normalize() {
+	a.Name = trim(a.Name)
+	a.Email = trim(a.Email)
+	a.Designation = trim(a.Designation)
+}
+
+// Validate reports every field that does not satisfy the attendee rules.
+func (a *Attendee) Validate() error {
+	var errs ValidationErrors
+	errs.required("name", a.Name)
+	errs.maxLength("name", a.Name, MaxNameLength)
+	errs.required("email", a.Email)
+	errs.maxLength("email", a.Email, MaxEmailLength)
+	errs.email("email", a.Email)
+	errs.required("designation", a.Designation)
+	errs.maxLength("designation", a.Designation, MaxDesignationLength)
+	return errs.err()
+}
+
+// Normalize trims surrounding whitespace from every text field.
+func (s *Speaker) Normalize() {
+	s.Name = trim(s.Name)
+	s.Bio = trim(s.Bio)
+}
+
+// Validate reports every field that does not satisfy the speaker rules.
+func (s *Speaker) Validate() error {
+	var errs ValidationErrors
+	errs.required("name", s.Name)
+	errs.maxLength("name", s.Name, MaxNameLength)
+	errs.maxLength("bio", s.Bio, MaxBioLength)
+	return errs.err()
+}
+
+// Normalize trims surrounding whitespace from every text field.
+func (s *Session) Normalize() {
+	s.Title = trim(s.Title)
+	s.Description = trim(s.Description)
+	s.Date = trim(s.Date)
+	s.Time = trim(s.Time)
+	s.Room = trim(s.Room)
+	s.Track = trim(s.Track)
+	s.SpeakerID = trim(s.SpeakerID)
+	for i, id := range s.SpeakerIDs {
+		s.SpeakerIDs[i] = trim(id)
+	}
+	s.SyncSpeakers()
+}
+
+// SyncSpeakers reconciles SpeakerIDs and SpeakerID. A non-empty SpeakerIDs
+// wins and SpeakerID becomes its first entry; otherwise SpeakerIDs is built
+// from SpeakerID, as for sessions stored before sessions had several
+// speakers. Blank and repeated IDs are dropped.
+func (s *Session) SyncSpeakers() {
+	ids := s.SpeakerIDs
+	if len(ids) == 0 && s.SpeakerID != "" {
+		ids = []string{s.SpeakerID}
+	}
+
+	seen := map[string]bool{}
+	out := []string{}
+	for _, id := range ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	s.SpeakerIDs, s.SpeakerID = out, ""
+	if len(out) > 0 {
+		s.SpeakerID = out[0]
+	}
+}
+
+// HasSpeaker reports whether id is one of the session's speakers.
+func (s *Session) HasSpeaker(id string) bool {
+	for _, sp := range s.SpeakerIDs {
+		if sp == id {
+			return true
+		}
+	}
+	return s.SpeakerID == id && id != ""
+}
+
+// RemoveSpeaker drops id from the session's speakers.
+func (s *Session) RemoveSpeaker(id string) {
+	kept := []string{}
+	for _, sp := range s.SpeakerIDs {
+		if sp != id {
+			kept = append(kept, sp)
+		}
+	}
+	s.SpeakerIDs = kept
+	if s.SpeakerID == id {
+		s.SpeakerID = ""
+	}
+	s.SyncSpeakers()
+}
+
+// SyncSchedule reconciles StartsAt and EndsAt with Date, Time and Duration
+// read as wall clock time in loc. A set StartsAt wins; otherwise it is read
+// from Date and Time, as for sessions stored before sessions had
+// timestamps. A missing or earlier EndsAt is StartsAt plus Duration. The
+// session is left alone if it has no StartsAt and its date or time cannot be
+// parsed.
+func (s *Session) SyncSchedule(loc *time.Location) {
+	if s.StartsAt.IsZero() {
+		start, err := time.ParseInLocation(DateLayout+" "+TimeLayout, s.Date+" "+s.Time, loc)
+		if err != nil {
+			return
+		}
+		s.StartsAt, s.EndsAt = start, time.Time{}
+	}
+	if !s.EndsAt.After(s.StartsAt) {
+		s.EndsAt = s.StartsAt.Add(durationLength(s.Duration))
+	}
+	s.StartsAt, s.EndsAt = s.StartsAt.UTC(), s.EndsAt.UTC()
+
+	local := s.StartsAt.In(loc)
+	s.Date, s.Time = local.Format(DateLayout), local.Format(TimeLayout)
+	s.Duration = int(s.EndsAt.Sub(s.StartsAt) / time.Minute)
+}
+
+// Start returns when the session begins in loc. Without StartsAt, Date and
+// Time are read as wall clock time in loc.
+func (s *Session) Start(loc *time.Location) (time.Time, error) {
+	if !s.StartsAt.IsZero() {
+		return s.StartsAt.In(loc), nil
+	}
+	return time.ParseInLocation(DateLayout+" "+TimeLayout, s.Date+" "+s.Time, loc)
+}
+
+// Length returns how long the session runs.
+func (s *Session) Length() time.Duration {
+	if !s.StartsAt.IsZero() && s.EndsAt.After(s.StartsAt) {
+		return s.EndsAt.Sub(s.StartsAt)
+	}
+	return durationLength(s.Duration)
+}
+
+func durationLength(minutes int) time.Duration {
+	if minutes <= 0 {
+		return DefaultSessionMinutes * time.Minute
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// Overlaps reports whether two sessions run at the same time. Sessions
+// whose date or time cannot be parsed overlap nothing.
+func (s *Session) Overlaps(o *Session) bool {
+	start, err := s.Start(time.UTC)
+	if err != nil {
+		return false
+	}
+	other, err := o.Start(time.UTC)
+	if err != nil {
+		return false
+	}
+	return start.Before(other.Add(o.Length())) && other.Before(start.Add(s.Length()))
+}
+
+// SharesRoom reports whether two sessions are booked into the same room.
+func (s *Session) SharesRoom(o *Session) bool {
+	return s.Room != "" && strings.EqualFold(s.Room, o.Room)
+}
+
+// Validate reports every field that does not satisfy the session rules.
+func (s *Session) Validate() error {
+	var errs ValidationErrors
+	errs.required("title", s.Title)
+	errs.maxLength("title", s.Title, MaxTitleLength)
+	errs.maxLength("description", s.Description, MaxDescriptionLength)
+	if s.StartsAt.IsZero() {
+		errs.required("date", s.Date)
+		errs.required("time", s.Time)
+	} else if !s.EndsAt.IsZero() {
+		switch length := s.EndsAt.Sub(s.StartsAt); {
+		case length <= 0:
+			errs.Add("endsAt", "must be after startsAt")
+		case length > MaxSessionMinutes*time.Minute:
+			errs.Add("endsAt", "must be at most 24 hours after startsAt")
+		}
+	}
+	errs.layout("date", s.Date, DateLayout, "must be a date in YYYY-MM-DD format")
+	errs.layout("time", s.Time, TimeLayout, "must be a time in HH:MM format")
+	errs.maxLength("room", s.Room, MaxLabelLength)
+	errs.maxLength("track", s.Track, MaxLabelLength)
+	errs.maxLength("speakerId", s.SpeakerID, MaxIDLength)
+	if len(s.SpeakerIDs) > MaxSessionSpeakers {
+		errs.Add("speakerIds", "must list at most 10 speakers")
+	}
+	for _, id := range s.SpeakerIDs {
+		if !errs.has("speakerIds") {
+			errs.maxLength("speakerIds", id, MaxIDLength)
+		}
+	}
+	if s.Duration < 0 || s.Duration > MaxSessionMinutes {
+		errs.Add("duration", "must be between 0 (the default length) and 1440 minutes")
+	}
+	if s.Capacity < 0 {
+		errs.Add("capacity", "must be zero (unlimited) or a positive number")
+	}
+	return errs.err()
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsEmail(t *testing.T) {
+	valid := []string{"a@example.com", "first.last+tag@sub.example.org"}
+	invalid := []string{"", "plain", "a@localhost", "Ann <a@example.com>", "a@@example.com", "@example.com"}
+
+	for _, v := range valid {
+		assert.True(t, IsEmail(v), v)
+	}
+	for _, v := range invalid {
+		assert.False(t, IsEmail(v), v)
+	}
+}
+
+func TestAttendeeValidate(t *testing.T) {
+	a := &Attendee{Name: "  Grace Hopper ", Email: " grace@example.com ", Designation: "Rear Admiral"}
+	a.Normalize()
+	assert.NoError(t, a.Validate())
+	assert.Equal(t, "Grace Hopper", a.Name)
+	assert.Equal(t, "grace@example.com", a.Email)
+
+	a = &Attendee{Name: strings.Repeat("x", MaxNameLength+1)}
+	err := a.Validate()
+	errs, ok := err.(ValidationErrors)
+	assert.True(t, ok)
+	assert.Equal(t, ValidationErrors{
+		{Field: "name", Message: "must be at most 100 characters"},
+		{Field: "email", Message: "is required"},
+		{Field: "designation", Message: "is required"},
+	}, errs)
+}
+
+func TestSessionValidate(t *testing.T) {
+	s := &Session{Title: "Keynote", Date: "2025-03-01", Time: "09:30"}
+	assert.NoError(t, s.Validate())
+
+	s = &Session{Title: "Keynote", Date: "2025-02-30", Time: "25:00"}
+	assert.Equal(t, "date must be a date in YYYY-MM-DD format; time must be a time in HH:MM format", s.Validate().Error())
+}
+
+func TestSessionOverlaps(t *testing.T) {
+	keynote := &Session{Date: "2025-03-01", Time: "09:00", Duration: 90}
+	assert.Equal(t, 90*time.Minute, keynote.Length())
+	assert.True(t, keynote.Overlaps(&Session{Date: "2025-03-01", Time: "10:00"}))
+	assert.False(t, keynote.Overlaps(&Session{Date: "2025-03-01", Time: "10:30"}), "back-to-back sessions do not overlap")
+	assert.False(t, keynote.Overlaps(&Session{Date: "2025-03-02", Time: "09:00"}))
+	assert.True(t, (&Session{Date: "2025-03-01", Time: "08:30"}).Overlaps(keynote), "a default session lasts an hour")
+	assert.False(t, keynote.Overlaps(&Session{Date: "someday", Time: "09:00"}))
+}
+
+func TestSessionSyncSpeakers(t *testing.T) {
+	s := &Session{SpeakerID: "ada"}
+	s.SyncSpeakers()
+	assert.Equal(t, []string{"ada"}, s.SpeakerIDs)
+
+	s = &Session{SpeakerID: "old", SpeakerIDs: []string{"grace", "", "ada", "grace"}}
+	s.SyncSpeakers()
+	assert.Equal(t, []string{"grace", "ada"}, s.SpeakerIDs)
+	assert.Equal(t, "grace", s.SpeakerID)
+
+	s.RemoveSpeaker("grace")
+	assert.Equal(t, []string{"ada"}, s.SpeakerIDs)
+	assert.Equal(t, "ada", s.SpeakerID)
+	s.RemoveSpeaker("ada")
+	assert.Empty(t, s.SpeakerIDs)
+	assert.Empty(t, s.SpeakerID)
+	assert.NotNil(t, s.SpeakerIDs)
+}
+
+func TestSessionSyncSchedule(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+
+	legacy := &Session{Date: "2025-03-01", Time: "09:30", Duration: 90}
+	legacy.SyncSchedule(kolkata)
+	assert.Equal(t, time.Date(2025, 3, 1, 4, 0, 0, 0, time.UTC), legacy.StartsAt)
+	assert.Equal(t, time.Date(2025, 3, 1, 5, 30, 0, 0, time.UTC), legacy.EndsAt)
+
+	// StartsAt wins over the wall clock fields, which follow it.
+	s := &Session{
+		Date:     "2025-01-01",
+		Time:     "00:00",
+		StartsAt: time.Date(2025, 3, 2, 23, 0, 0, 0, time.UTC),
+		EndsAt:   time.Date(2025, 3, 3, 0, 15, 0, 0, time.UTC),
+	}
+	s.SyncSchedule(kolkata)
+	assert.Equal(t, "2025-03-03", s.Date)
+	assert.Equal(t, "04:30", s.Time)
+	assert.Equal(t, 75, s.Duration)
+	assert.Equal(t, 75*time.Minute, s.Length())
+
+	unparsed := &Session{Date: "someday"}
+	unparsed.SyncSchedule(kolkata)
+	assert.True(t, unparsed.StartsAt.IsZero())
+}
+
+func TestSessionValidateTimestamps(t *testing.T) {
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	assert.NoError(t, (&Session{Title: "Keynote", StartsAt: start}).Validate(), "date and time are optional with startsAt")
+
+	s := &Session{Title: "Keynote", StartsAt: start, EndsAt: start.Add(-time.Minute), Room: strings.Repeat("r", MaxLabelLength+1)}
+	assert.Equal(t, "endsAt must be after startsAt; room must be at most 100 characters", s.Validate().Error())
+}
+
+func TestSessionSharesRoom(t *testing.T) {
+	hall := &Session{Room: "Hall A"}
+	assert.True(t, hall.SharesRoom(&Session{Room: "hall a"}))
+	assert.False(t, hall.SharesRoom(&Session{Room: "Hall B"}))
+	assert.False(t, (&Session{}).SharesRoom(&Session{}), "sessions without a room never clash")
+}
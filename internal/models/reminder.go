@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Reminder is a scheduled email reminding attendees of an upcoming session.
+// Each session gets one reminder per configured lead time, so its ID is
+// derived from the session ID and the lead time and scheduling the same
+// reminder twice updates it instead of creating a duplicate.
+type Reminder struct {
+	ID        string `json:"id" firestore:"-"`
+	SessionID string `json:"sessionId" firestore:"sessionId"`
+	// Lead is how long before the session starts the reminder goes out,
+	// such as "24h" or "1h".
+	Lead   string    `json:"lead" firestore:"lead"`
+	DueAt  time.Time `json:"dueAt" firestore:"dueAt"`
+	Status string    `json:"status" firestore:"status"`
+	// LeaseUntil is set while a server instance is sending the reminder.
+	// Another instance may take over once it has passed, which only happens
+	// when the sender crashed mid-send.
+	LeaseUntil *time.Time `json:"leaseUntil,omitempty" firestore:"leaseUntil,omitempty"`
+	Attempts   int        `json:"attempts" firestore:"attempts"`
+	LastError  string     `json:"lastError,omitempty" firestore:"lastError,omitempty"`
+	SentAt     *time.Time `json:"sentAt,omitempty" firestore:"sentAt,omitempty"`
+	// Recipients is the number of attendees the last send emailed.
+	Recipients int       `json:"recipients" firestore:"recipients"`
+	CreatedAt  time.Time `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt" firestore:"updatedAt"`
+}
+
+// Reminder statuses.
+const (
+	ReminderPending   = "pending"
+	ReminderSending   = "sending"
+	ReminderSent      = "sent"
+	ReminderFailed    = "failed"
+	ReminderCancelled = "cancelled"
+)
+
+// Due reports whether the reminder should be sent at now: it is pending and
+// its time has come, or a previous send was abandoned.
+func (r *Reminder) Due(now time.Time) bool {
+	switch r.Status {
+	case ReminderPending:
+		return !r.DueAt.After(now)
+	case ReminderSending:
+		return r.LeaseUntil == nil || r.LeaseUntil.Before(now)
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError describes why a single field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors collects every field error found while validating a
+// document so clients can surface them all at once.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + " " + fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Add records an error for field.
+func (e *ValidationErrors) Add(field, message string) {
+	*e = append(*e, FieldError{Field: field, Message: message})
+}
+
+// has reports whether field already has an error, so later checks do not pile
+// redundant messages onto a field that is missing altogether.
+func (e ValidationErrors) has(field string) bool {
+	for _, fe := range e {
+		if fe.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+func (e ValidationErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationErrors) required(field, value string) {
+	if value == "" {
+		e.Add(field, "is required")
+	}
+}
+
+func (e *ValidationErrors) maxLength(field, value string, max int) {
+	if utf8.RuneCountInString(value) > max {
+		e.Add(field, fmt.Sprintf("must be at most %d characters", max))
+	}
+}
+
+func (e *ValidationErrors) email(field, value string) {
+	if value == "" || e.has(field) {
+		return
+	}
+	if !IsEmail(value) {
+		e.Add(field, "must be a valid email address")
+	}
+}
+
+func (e *ValidationErrors) layout(field, value, layout, message string) {
+	if value == "" || e.has(field) {
+		return
+	}
+	if _, err := time.Parse(layout, value); err != nil {
+		e.Add(field, message)
+	}
+}
+
+// IsEmail reports whether value is a bare address such as "a@example.com".
+// Display-name forms like "Ann <a@example.com>" are rejected.
+func IsEmail(value string) bool {
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Address != value {
+		return false
+	}
+	at := strings.LastIndex(value, "@")
+	return at > 0 && strings.Contains(value[at+1:], ".")
+}
+
+func trim(s string) string {
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Workshop is one run of the event. Each workshop has its own attendees,
+// speakers, sessions and settings; its ID appears in API paths such as
+// /api/workshops/{id}/sessions.
+type Workshop struct {
+	ID          string    `json:"id" firestore:"-"`
+	Name        string    `json:"name" firestore:"name"`
+	Description string    `json:"description" firestore:"description"`
+	CreatedAt   time.Time `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt" firestore:"updatedAt"`
+}
+
+var workshopIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Normalize trims surrounding whitespace from every text field.
+func (w *Workshop) Normalize() {
+	w.ID = trim(w.ID)
+	w.Name = trim(w.Name)
+	w.Description = trim(w.Description)
+}
+
+// Validate reports every field that does not satisfy the workshop rules. The
+// ID may be empty, in which case the store generates one.
+func (w *Workshop) Validate() error {
+	var errs ValidationErrors
+	w.validate(&errs)
+	return errs.err()
+}
+
+func (w *Workshop) validate(errs *ValidationErrors) {
+	if w.ID != "" && !workshopIDPattern.MatchString(w.ID) {
+		errs.Add("id", "must be 1-64 lowercase letters, digits, '_' or '-'")
+	}
+	errs.required("name", w.Name)
+	errs.maxLength("name", w.Name, MaxTitleLength)
+	errs.maxLength("description", w.Description, MaxDescriptionLength)
+}
+
+// MaxCloneOffsetDays bounds WorkshopClone.OffsetDays, about ten years.
+const MaxCloneOffsetDays = 3660
+
+// WorkshopClone asks for a new workshop holding copies of the speakers and
+// sessions of another one. Its sessions take place OffsetDays days later, or
+// earlier when negative, at the same wall clock time.
+type WorkshopClone struct {
+	Workshop
+	OffsetDays int `json:"offsetDays"`
+}
+
+// Validate checks the new workshop like Workshop.Validate and bounds the
+// offset.
+func (c *WorkshopClone) Validate() error {
+	var errs ValidationErrors
+	c.Workshop.validate(&errs)
+	if c.OffsetDays < -MaxCloneOffsetDays || c.OffsetDays > MaxCloneOffsetDays {
+		errs.Add("offsetDays", fmt.Sprintf("must be between -%d and %d", MaxCloneOffsetDays, MaxCloneOffsetDays))
+	}
+	return errs.err()
+}
@@ -0,0 +1,480 @@
+// Package reminders emails attendees ahead of each session. Every session
+// gets one reminder job per lead time (24 and 1 hour before it starts by
+// default), persisted through store.ReminderStore so that a restart neither
+// loses a reminder nor sends it twice. Jobs are claimed with a lease before
+// sending, which also keeps several server instances from sending the same
+// reminder.
+package reminders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"appdirect-workshop/internal/mailer"
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+// DefaultLeads are how long before a session its reminders go out.
+var DefaultLeads = []time.Duration{24 * time.Hour, time.Hour}
+
+const (
+	// DefaultInterval is how often the scheduler looks for due reminders.
+	DefaultInterval = time.Minute
+	// syncInterval is how often every session is rescheduled, which picks
+	// up sessions changed without going through the API.
+	syncInterval = time.Hour
+	// leaseDuration bounds how long a crashed sender blocks a reminder.
+	leaseDuration = 10 * time.Minute
+	// maxAttempts is how many times a failing reminder is tried.
+	maxAttempts = 5
+)
+
+var (
+	// ErrInProgress is returned when changing a reminder that is being sent.
+	ErrInProgress = errors.New("reminder is being sent")
+	// ErrAlreadySent is returned when cancelling a reminder that was sent.
+	ErrAlreadySent = errors.New("reminder was already sent")
+
+	errUnchanged = errors.New("unchanged")
+	errNotDue    = errors.New("not due")
+)
+
+// Mailer is the part of *mailer.Queue the scheduler uses.
+type Mailer interface {
+	EnqueueWait(ctx context.Context, msg *mailer.Message) error
+}
+
+// Config controls which reminders are sent and what they say.
+type Config struct {
+	Leads []time.Duration
+	// Location is the time zone session dates and times are written in.
+	Location  *time.Location
+	EventName string
+	// ManageURL returns the self-service link for an attendee, or "" to
+	// leave it out.
+	ManageURL func(attendeeID string) string
+	Interval  time.Duration
+}
+
+// Scheduler creates reminder jobs for sessions and sends them when due.
+type Scheduler struct {
+	reminders   store.ReminderStore
+	sessions    store.SessionStore
+	attendees   store.AttendeeStore
+	enrollments store.EnrollmentStore
+	mail        Mailer
+	cfg         Config
+	now         func() time.Time
+	wake        chan struct{}
+}
+
+// NewScheduler returns a Scheduler; call Run to start sending.
+func NewScheduler(stores store.Stores, mail Mailer, cfg Config) *Scheduler {
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.ManageURL == nil {
+		cfg.ManageURL = func(string) string { return "" }
+	}
+	return &Scheduler{
+		reminders:   stores.Reminders,
+		sessions:    stores.Sessions,
+		attendees:   stores.Attendees,
+		enrollments: stores.Enrollments,
+		mail:        mail,
+		cfg:         cfg,
+		now:         time.Now,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// ID returns the ID of the reminder sent lead before a session.
+func ID(sessionID string, lead time.Duration) string {
+	return sessionID + "-" + leadLabel(lead)
+}
+
+// leadLabel formats a lead time without zero units: "24h", "1h30m", "45m".
+func leadLabel(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// missed reports whether a reminder is too late to be useful: more than half
+// its lead time has passed since it was due, so a shorter reminder is about
+// to go out or the session is about to start.
+func missed(r *models.Reminder, now time.Time) bool {
+	lead, err := time.ParseDuration(r.Lead)
+	if err != nil {
+		return false
+	}
+	return now.After(r.DueAt.Add(lead / 2))
+}
+
+// Run sends due reminders until ctx ends. It schedules every session on
+// start and then periodically, so reminders exist for sessions created
+// before the scheduler ran.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	var lastSync time.Time
+	for {
+		if time.Since(lastSync) >= syncInterval {
+			if err := s.ScheduleAll(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("reminders: scheduling sessions: %v", err)
+			}
+			lastSync = time.Now()
+		}
+		if err := s.SendDue(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("reminders: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// ScheduleAll schedules the reminders of every session. Sessions without a
+// valid start are logged and skipped; the other failures are joined so one
+// session cannot hold up the rest.
+func (s *Scheduler) ScheduleAll(ctx context.Context) error {
+	sessions, err := s.sessions.ListSessions(ctx)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, session := range sessions {
+		if _, err := session.Start(s.cfg.Location); err != nil {
+			log.Printf("reminders: session %s has no valid date and time; not scheduling it", session.ID)
+			continue
+		}
+		if err := s.Schedule(ctx, session); err != nil {
+			errs = append(errs, fmt.Errorf("session %s: %w", session.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Schedule creates or moves the reminders of a session so each falls due its
+// lead time before the session starts. A reminder that would already be
+// missed is not created, and a sent reminder is only sent again if the
+// session moved to a later time. Cancelled reminders stay cancelled.
+func (s *Scheduler) Schedule(ctx context.Context, session *models.Session) error {
+	start, err := session.Start(s.cfg.Location)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+
+	for _, lead := range s.cfg.Leads {
+		due := start.Add(-lead)
+		_, err := s.reminders.UpdateReminder(ctx, ID(session.ID, lead), func(r *models.Reminder) error {
+			if r.CreatedAt.IsZero() {
+				r.SessionID, r.Lead, r.DueAt = session.ID, leadLabel(lead), due
+				if missed(r, now) {
+					return errUnchanged
+				}
+				r.Status, r.CreatedAt, r.UpdatedAt = models.ReminderPending, now, now
+				return nil
+			}
+			if r.DueAt.Equal(due) || r.Status == models.ReminderSending {
+				return errUnchanged
+			}
+
+			r.DueAt, r.UpdatedAt = due, now
+			if r.Status != models.ReminderCancelled && due.After(now) {
+				r.Status, r.Attempts, r.LastError, r.SentAt = models.ReminderPending, 0, "", nil
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errUnchanged) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unschedule cancels the outstanding reminders of a deleted session.
+func (s *Scheduler) Unschedule(ctx context.Context, sessionID string) error {
+	now := s.now()
+	for _, lead := range s.cfg.Leads {
+		_, err := s.reminders.UpdateReminder(ctx, ID(sessionID, lead), func(r *models.Reminder) error {
+			if r.CreatedAt.IsZero() || (r.Status != models.ReminderPending && r.Status != models.ReminderFailed) {
+				return errUnchanged
+			}
+			r.Status, r.LastError, r.UpdatedAt = models.ReminderCancelled, "session was deleted", now
+			return nil
+		})
+		if err != nil && !errors.Is(err, errUnchanged) {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns every reminder ordered by due time.
+func (s *Scheduler) List(ctx context.Context) ([]*models.Reminder, error) {
+	reminders, err := s.reminders.ListReminders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(reminders, func(i, j int) bool {
+		return reminders[i].DueAt.Before(reminders[j].DueAt)
+	})
+	return reminders, nil
+}
+
+// Cancel stops a reminder from being sent. Cancelling twice is not an
+// error. It returns store.ErrNotFound, ErrInProgress or ErrAlreadySent.
+func (s *Scheduler) Cancel(ctx context.Context, id string) (*models.Reminder, error) {
+	now := s.now()
+	r, err := s.reminders.UpdateReminder(ctx, id, func(r *models.Reminder) error {
+		switch {
+		case r.CreatedAt.IsZero():
+			return store.ErrNotFound
+		case r.Status == models.ReminderSending && !r.Due(now):
+			return ErrInProgress
+		case r.Status == models.ReminderSent:
+			return ErrAlreadySent
+		}
+		r.Status, r.LeaseUntil, r.UpdatedAt = models.ReminderCancelled, nil, now
+		return nil
+	})
+	return r, err
+}
+
+// Resend queues a reminder to be sent again straight away, whatever its
+// status. It returns store.ErrNotFound or ErrInProgress.
+func (s *Scheduler) Resend(ctx context.Context, id string) (*models.Reminder, error) {
+	now := s.now()
+	r, err := s.reminders.UpdateReminder(ctx, id, func(r *models.Reminder) error {
+		switch {
+		case r.CreatedAt.IsZero():
+			return store.ErrNotFound
+		case r.Status == models.ReminderSending && !r.Due(now):
+			return ErrInProgress
+		}
+		r.Status, r.DueAt, r.LeaseUntil = models.ReminderPending, now, nil
+		r.Attempts, r.LastError, r.UpdatedAt = 0, "", now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return r, nil
+}
+
+// SendDue sends every reminder that is due. A failed send does not stop the
+// ones after it; the failures are joined.
+func (s *Scheduler) SendDue(ctx context.Context) error {
+	reminders, err := s.reminders.ListReminders(ctx)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	var due []*models.Reminder
+	for _, r := range reminders {
+		if r.Due(now) {
+			due = append(due, r)
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	sessions, err := s.sessions.ListSessions(ctx)
+	if err != nil {
+		return err
+	}
+	byID := map[string]*models.Session{}
+	for _, session := range sessions {
+		byID[session.ID] = session
+	}
+
+	var errs []error
+	for _, r := range due {
+		if err := s.send(ctx, r.ID, byID[r.SessionID]); err != nil {
+			errs = append(errs, fmt.Errorf("sending %s: %w", r.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// send claims a due reminder and emails it to the session's audience; see
+// render.
+// session is nil if it was deleted.
+func (s *Scheduler) send(ctx context.Context, id string, session *models.Session) error {
+	now := s.now()
+	r, err := s.reminders.UpdateReminder(ctx, id, func(r *models.Reminder) error {
+		if !r.Due(now) {
+			return errNotDue
+		}
+		lease := now.Add(leaseDuration)
+		r.Status, r.LeaseUntil, r.UpdatedAt = models.ReminderSending, &lease, now
+		r.Attempts++
+		return nil
+	})
+	if errors.Is(err, errNotDue) {
+		return nil // another instance got to it first
+	}
+	if err != nil {
+		return err
+	}
+
+	var start time.Time
+	if session != nil {
+		start, err = session.Start(s.cfg.Location)
+	}
+	switch {
+	case session == nil:
+		return s.finish(r.ID, models.ReminderCancelled, "session was deleted", 0)
+	case err != nil:
+		return s.finish(r.ID, models.ReminderFailed, err.Error(), 0)
+	case !start.After(now):
+		return s.finish(r.ID, models.ReminderFailed, "session had already started", 0)
+	case missed(r, now):
+		return s.finish(r.ID, models.ReminderFailed, "missed: too close to the session to be useful", 0)
+	}
+
+	messages, err := s.render(ctx, session, start, now)
+	if err != nil {
+		status := models.ReminderPending
+		if r.Attempts >= maxAttempts {
+			status = models.ReminderFailed
+		}
+		return s.finish(r.ID, status, err.Error(), 0)
+	}
+	for i, msg := range messages {
+		if err := s.mail.EnqueueWait(ctx, msg); err != nil {
+			// Retrying would email the first recipients again, so leave it
+			// to an admin to decide whether to re-send.
+			return s.finish(r.ID, models.ReminderFailed,
+				fmt.Sprintf("interrupted after %d of %d recipients: %v", i, len(messages), err), i)
+		}
+	}
+	return s.finish(r.ID, models.ReminderSent, "", len(messages))
+}
+
+// render builds the reminder for the attendees enrolled in the session who
+// hold a seat. Events where nobody has enrolled in any session are treated
+// as everyone attending everything, so every attendee holding a seat is
+// reminded.
+func (s *Scheduler) render(ctx context.Context, session *models.Session, start, now time.Time) ([]*mailer.Message, error) {
+	enrolled, err := s.enrolled(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	attendees, err := s.attendees.ListAttendees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []*mailer.Message
+	for _, a := range attendees {
+		if !a.HoldsSeat() || (enrolled != nil && !enrolled[a.ID]) {
+			continue
+		}
+		msg, err := mailer.Reminder(mailer.SessionReminder{
+			Registration: mailer.Registration{
+				EventName: s.cfg.EventName,
+				Name:      a.Name,
+				Email:     a.Email,
+				Status:    a.Status,
+				ManageURL: s.cfg.ManageURL(a.ID),
+			},
+			SessionTitle: session.Title,
+			StartsAt:     start.Format("Monday 2 January, 15:04 MST"),
+			StartsIn:     startsIn(start.Sub(now)),
+		})
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// enrolled returns the IDs of the attendees enrolled in a session, or nil
+// when nobody has enrolled in any session.
+func (s *Scheduler) enrolled(ctx context.Context, sessionID string) (map[string]bool, error) {
+	if s.enrollments == nil {
+		return nil, nil
+	}
+	counts, err := s.enrollments.EnrollmentCounts(ctx)
+	if err != nil || len(counts) == 0 {
+		return nil, err
+	}
+	list, err := s.enrollments.ListEnrollments(ctx, store.EnrollmentFilter{SessionID: sessionID})
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(list))
+	for _, e := range list {
+		ids[e.AttendeeID] = true
+	}
+	return ids, nil
+}
+
+// finish records the outcome of a send. It runs even when the scheduler is
+// shutting down, since the emails already queued will still go out.
+func (s *Scheduler) finish(id, status, lastError string, recipients int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	now := s.now()
+	_, err := s.reminders.UpdateReminder(ctx, id, func(r *models.Reminder) error {
+		r.Status, r.LeaseUntil, r.LastError, r.UpdatedAt = status, nil, lastError, now
+		if status == models.ReminderSent {
+			r.SentAt = &now
+		}
+		if recipients > 0 || status == models.ReminderSent {
+			r.Recipients = recipients
+		}
+		return nil
+	})
+	return err
+}
+
+// startsIn describes a duration the way a reminder would: "24 hours",
+// "1 hour", "45 minutes".
+func startsIn(d time.Duration) string {
+	if d >= 90*time.Minute {
+		return plural(int(d.Round(time.Hour)/time.Hour), "hour")
+	}
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	if minutes == 60 {
+		return "1 hour"
+	}
+	return plural(minutes, "minute")
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,315 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"appdirect-workshop/internal/mailer"
+	"appdirect-workshop/internal/memory"
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mu   sync.Mutex
+	sent []*mailer.Message
+}
+
+func (r *recorder) EnqueueWait(ctx context.Context, msg *mailer.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, msg)
+	return nil
+}
+
+func (r *recorder) recipients() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []string
+	for _, msg := range r.sent {
+		out = append(out, msg.To)
+	}
+	r.sent = nil
+	return out
+}
+
+// clock is a settable time source shared by schedulers in a test.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func newTestScheduler(mem *memory.Store, mail Mailer, c *clock) *Scheduler {
+	s := NewScheduler(store.Stores{Attendees: mem, Sessions: mem, Reminders: mem, Enrollments: mem}, mail, Config{
+		Leads:     DefaultLeads,
+		EventName: "AI Workshop",
+		ManageURL: func(id string) string { return "https://example.com/registration/" + id },
+	})
+	s.now = c.now
+	return s
+}
+
+func setup(t *testing.T) (*memory.Store, *models.Session, *clock) {
+	ctx := context.Background()
+	mem := memory.New()
+	for _, a := range []*models.Attendee{
+		{Name: "Ada", Email: "ada@example.com", Designation: "Engineer"},
+		{Name: "Bob", Email: "bob@example.com", Designation: "Engineer"},
+	} {
+		require.NoError(t, mem.CreateAttendee(ctx, a))
+	}
+	cancelled, _, err := mem.CancelAttendee(ctx, mustAttendee(t, mem, "bob@example.com").ID)
+	require.NoError(t, err)
+	require.Equal(t, models.StatusCancelled, cancelled.Status)
+
+	session := &models.Session{Title: "Keynote", Date: "2030-05-10", Time: "10:00"}
+	require.NoError(t, mem.CreateSession(ctx, session))
+	return mem, session, &clock{t: time.Date(2030, 5, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func mustAttendee(t *testing.T, mem *memory.Store, email string) *models.Attendee {
+	list, err := mem.ListAttendees(context.Background())
+	require.NoError(t, err)
+	for _, a := range list {
+		if a.Email == email {
+			return a
+		}
+	}
+	t.Fatalf("no attendee %s", email)
+	return nil
+}
+
+func TestScheduleAndSend(t *testing.T) {
+	ctx := context.Background()
+	mem, session, c := setup(t)
+	mail := &recorder{}
+	s := newTestScheduler(mem, mail, c)
+
+	require.NoError(t, s.Schedule(ctx, session))
+	require.NoError(t, s.Schedule(ctx, session), "scheduling twice is a no-op")
+	list, err := s.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, ID(session.ID, 24*time.Hour), list[0].ID)
+	assert.Equal(t, session.ID+"-1h", list[1].ID)
+	assert.Equal(t, time.Date(2030, 5, 9, 10, 0, 0, 0, time.UTC), list[0].DueAt)
+
+	require.NoError(t, s.SendDue(ctx))
+	assert.Empty(t, mail.recipients(), "nothing is due yet")
+
+	c.t = time.Date(2030, 5, 9, 10, 0, 30, 0, time.UTC)
+	require.NoError(t, s.SendDue(ctx))
+	mail.mu.Lock()
+	require.Len(t, mail.sent, 1, "cancelled attendees are not reminded")
+	msg := mail.sent[0]
+	mail.mu.Unlock()
+	assert.Equal(t, "ada@example.com", msg.To)
+	assert.Equal(t, "Reminder: Keynote starts in 24 hours", msg.Subject)
+	assert.Contains(t, msg.Text, "https://example.com/registration/")
+	mail.recipients()
+
+	// A restarted server sees the reminder as sent and does not repeat it.
+	restarted := newTestScheduler(mem, mail, c)
+	require.NoError(t, restarted.ScheduleAll(ctx))
+	require.NoError(t, restarted.SendDue(ctx))
+	assert.Empty(t, mail.recipients())
+
+	sent, err := mem.GetReminder(ctx, ID(session.ID, 24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, models.ReminderSent, sent.Status)
+	assert.Equal(t, 1, sent.Recipients)
+	assert.Nil(t, sent.LeaseUntil)
+}
+
+func TestRemindEnrollees(t *testing.T) {
+	ctx := context.Background()
+	mem, keynote, c := setup(t)
+	mail := &recorder{}
+	s := newTestScheduler(mem, mail, c)
+	require.NoError(t, mem.CreateAttendee(ctx, &models.Attendee{Name: "Cy", Email: "cy@example.com", Designation: "Engineer"}))
+	lab := &models.Session{Title: "Lab", Date: "2030-05-10", Time: "14:00"}
+	panel := &models.Session{Title: "Panel", Date: "2030-05-10", Time: "16:00"}
+	for _, se := range []*models.Session{lab, panel} {
+		require.NoError(t, mem.CreateSession(ctx, se))
+	}
+	for _, se := range []*models.Session{keynote, lab, panel} {
+		require.NoError(t, s.Schedule(ctx, se))
+	}
+
+	// Until someone enrolls, everyone holding a seat attends everything.
+	c.t = time.Date(2030, 5, 9, 10, 0, 30, 0, time.UTC)
+	require.NoError(t, s.SendDue(ctx))
+	assert.ElementsMatch(t, []string{"ada@example.com", "cy@example.com"}, mail.recipients())
+
+	// Then only a session's enrollees are reminded of it, and sessions
+	// nobody chose remind nobody.
+	_, err := mem.Enroll(ctx, lab.ID, mustAttendee(t, mem, "cy@example.com").ID)
+	require.NoError(t, err)
+	c.t = time.Date(2030, 5, 9, 16, 0, 30, 0, time.UTC)
+	require.NoError(t, s.SendDue(ctx))
+	assert.Equal(t, []string{"cy@example.com"}, mail.recipients())
+
+	sent, err := mem.GetReminder(ctx, ID(panel.ID, 24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, models.ReminderSent, sent.Status)
+	assert.Zero(t, sent.Recipients)
+}
+
+// flakyReminders fails to claim one reminder.
+type flakyReminders struct {
+	*memory.Store
+	fail string
+}
+
+func (f *flakyReminders) UpdateReminder(ctx context.Context, id string, update func(*models.Reminder) error) (*models.Reminder, error) {
+	if id == f.fail {
+		return nil, errors.New("store unavailable")
+	}
+	return f.Store.UpdateReminder(ctx, id, update)
+}
+
+func TestOneSessionDoesNotHoldUpOthers(t *testing.T) {
+	ctx := context.Background()
+	mem, keynote, c := setup(t)
+	mail := &recorder{}
+	s := newTestScheduler(mem, mail, c)
+	require.NoError(t, mem.CreateSession(ctx, &models.Session{Title: "TBD", Date: "not-a-date", Time: "10:00"}))
+	lab := &models.Session{Title: "Lab", Date: "2030-05-10", Time: "10:00"}
+	require.NoError(t, mem.CreateSession(ctx, lab))
+
+	// Sessions without a valid start are skipped, not fatal.
+	require.NoError(t, s.ScheduleAll(ctx))
+	list, err := s.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, list, 4)
+
+	// A reminder that fails ahead of another still lets the other go out.
+	first := min(ID(keynote.ID, 24*time.Hour), ID(lab.ID, 24*time.Hour))
+	s.reminders = &flakyReminders{Store: mem, fail: first}
+	c.t = time.Date(2030, 5, 9, 10, 0, 30, 0, time.UTC)
+	err = s.SendDue(ctx)
+	assert.ErrorContains(t, err, first)
+	assert.Equal(t, []string{"ada@example.com"}, mail.recipients())
+}
+
+func TestRescheduleAndMissedReminders(t *testing.T) {
+	ctx := context.Background()
+	mem, session, c := setup(t)
+	mail := &recorder{}
+	s := newTestScheduler(mem, mail, c)
+	require.NoError(t, s.Schedule(ctx, session))
+
+	// Moving the session moves its reminders.
+	session.Time = "14:30"
+	require.NoError(t, mem.UpdateSession(ctx, session))
+	require.NoError(t, s.Schedule(ctx, session))
+	r, err := mem.GetReminder(ctx, ID(session.ID, time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2030, 5, 10, 13, 30, 0, 0, time.UTC), r.DueAt)
+
+	// A server that was down for a day skips the 24 hour reminder rather than
+	// sending it an hour before the session, right next to the 1 hour one.
+	c.t = time.Date(2030, 5, 10, 13, 31, 0, 0, time.UTC)
+	require.NoError(t, s.SendDue(ctx))
+	assert.Equal(t, []string{"ada@example.com"}, mail.recipients())
+
+	list, err := s.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReminderFailed, list[0].Status)
+	assert.Contains(t, list[0].LastError, "missed")
+	assert.Equal(t, models.ReminderSent, list[1].Status)
+
+	// New sessions only get reminders that can still go out on time.
+	late := &models.Session{Title: "Late addition", Date: "2030-05-10", Time: "16:00"}
+	require.NoError(t, mem.CreateSession(ctx, late))
+	require.NoError(t, s.Schedule(ctx, late))
+	_, err = mem.GetReminder(ctx, ID(late.ID, 24*time.Hour))
+	assert.ErrorIs(t, err, store.ErrNotFound)
+	_, err = mem.GetReminder(ctx, ID(late.ID, time.Hour))
+	assert.NoError(t, err)
+}
+
+func TestCancelResendAndUnschedule(t *testing.T) {
+	ctx := context.Background()
+	mem, session, c := setup(t)
+	mail := &recorder{}
+	s := newTestScheduler(mem, mail, c)
+	require.NoError(t, s.Schedule(ctx, session))
+	id := ID(session.ID, 24*time.Hour)
+
+	r, err := s.Cancel(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReminderCancelled, r.Status)
+	_, err = s.Cancel(ctx, "missing")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	// Cancelled reminders are skipped, and stay cancelled when the session
+	// moves.
+	session.Time = "11:00"
+	require.NoError(t, mem.UpdateSession(ctx, session))
+	require.NoError(t, s.Schedule(ctx, session))
+	c.t = time.Date(2030, 5, 9, 11, 0, 0, 0, time.UTC)
+	require.NoError(t, s.SendDue(ctx))
+	assert.Empty(t, mail.recipients())
+
+	// Resend sends straight away, and a sent reminder cannot be cancelled.
+	r, err = s.Resend(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReminderPending, r.Status)
+	require.NoError(t, s.SendDue(ctx))
+	assert.Equal(t, []string{"ada@example.com"}, mail.recipients())
+	_, err = s.Cancel(ctx, id)
+	assert.ErrorIs(t, err, ErrAlreadySent)
+
+	// Deleting the session cancels what is still pending.
+	require.NoError(t, mem.DeleteSession(ctx, session.ID))
+	require.NoError(t, s.Unschedule(ctx, session.ID))
+	r, err = mem.GetReminder(ctx, ID(session.ID, time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, models.ReminderCancelled, r.Status)
+	r, err = mem.GetReminder(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReminderSent, r.Status)
+}
+
+func TestLeaseKeepsOtherInstancesOut(t *testing.T) {
+	ctx := context.Background()
+	mem, session, c := setup(t)
+	mail := &recorder{}
+	s := newTestScheduler(mem, mail, c)
+	require.NoError(t, s.Schedule(ctx, session))
+	id := ID(session.ID, time.Hour)
+
+	// Simulate an instance that claimed the reminder and crashed.
+	c.t = time.Date(2030, 5, 10, 9, 0, 0, 0, time.UTC)
+	lease := c.t.Add(leaseDuration)
+	_, err := mem.UpdateReminder(ctx, id, func(r *models.Reminder) error {
+		r.Status, r.LeaseUntil = models.ReminderSending, &lease
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, s.SendDue(ctx))
+	assert.Empty(t, mail.recipients())
+	_, err = s.Resend(ctx, id)
+	assert.ErrorIs(t, err, ErrInProgress)
+
+	c.t = lease.Add(time.Second)
+	require.NoError(t, s.SendDue(ctx))
+	assert.Equal(t, []string{"ada@example.com"}, mail.recipients())
+}
+
+func TestLeadLabelAndStartsIn(t *testing.T) {
+	assert.Equal(t, "24h", leadLabel(24*time.Hour))
+	assert.Equal(t, "1h30m", leadLabel(90*time.Minute))
+	assert.Equal(t, "45m", leadLabel(45*time.Minute))
+
+	assert.Equal(t, "24 hours", startsIn(23*time.Hour+59*time.Minute))
+	assert.Equal(t, "1 hour", startsIn(59*time.Minute+40*time.Second))
+	assert.Equal(t, "45 minutes", startsIn(45*time.Minute))
+}
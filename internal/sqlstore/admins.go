@@ -0,0 +1,80 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+var _ store.AdminStore = (*Store)(nil)
+
+const adminColumns = "username, password_hash, role, disabled, created_at, password_changed_at"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAdmin(row rowScanner) (*models.Admin, error) {
+	var a models.Admin
+	if err := row.Scan(&a.Username, &a.PasswordHash, &a.Role, &a.Disabled, &a.CreatedAt, &a.PasswordChangedAt); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (s *Store) ListAdmins(ctx context.Context) ([]*models.Admin, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+adminColumns+" FROM admins ORDER BY username")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*models.Admin
+	for rows.Next() {
+		a, err := scanAdmin(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) GetAdmin(ctx context.Context, username string) (*models.Admin, error) {
+	a, err := scanAdmin(s.db.QueryRowContext(ctx, s.rebind("SELECT "+adminColumns+" FROM admins WHERE username = ?"), username))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, store.ErrNotFound
+	}
+	return a, err
+}
+
+func (s *Store) CreateAdmin(ctx context.Context, admin *models.Admin) error {
+	_, err := s.exec(ctx, "INSERT INTO admins ("+adminColumns+") VALUES (?, ?, ?, ?, ?, ?)",
+		admin.Username, admin.PasswordHash, admin.Role, admin.Disabled, admin.CreatedAt.UTC(), admin.PasswordChangedAt.UTC())
+	if err != nil {
+		if _, lookupErr := s.GetAdmin(ctx, admin.Username); lookupErr == nil {
+			return &store.DuplicateError{ExistingID: admin.Username}
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *Store) UpdateAdmin(ctx context.Context, admin *models.Admin) error {
+	res, err := s.exec(ctx, "UPDATE admins SET password_hash = ?, role = ?, disabled = ?, password_changed_at = ? WHERE username = ?",
+		admin.PasswordHash, admin.Role, admin.Disabled, admin.PasswordChangedAt.UTC(), admin.Username)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
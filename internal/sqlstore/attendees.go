@@ -0,0 +1,421 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+// querier is the subset of *sql.DB and *sql.Tx the attendee helpers need, so
+// they can run inside a transaction. With SQLite's single connection, a
+// query on the DB while a transaction is open would block forever.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (s *Store) ListAttendees(ctx context.Context) ([]*models.Attendee, error) {
+	return list(ctx, s, "attendees", func(a *models.Attendee, id string) { a.ID = id })
+}
+
+func (s *Store) GetAttendee(ctx context.Context, id string) (*models.Attendee, error) {
+	var raw string
+	err := s.db.QueryRowContext(ctx, s.rebind("SELECT data FROM attendees WHERE workshop_id = ? AND id = ?"), s.workshop, id).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeAttendee(id, raw)
+}
+
+func decodeAttendee(id, raw string) (*models.Attendee, error) {
+	var a models.Attendee
+	if err := json.Unmarshal([]byte(raw), &a); err != nil {
+		return nil, fmt.Errorf("decoding attendees/%s: %w", id, err)
+	}
+	a.ID = id
+	return &a, nil
+}
+
+// attendeeIDByEmail returns the ID of the attendee owning a normalized email,
+// or sql.ErrNoRows.
+func (s *Store) attendeeIDByEmail(ctx context.Context, q querier, email string) (string, error) {
+	var id string
+	err := q.QueryRowContext(ctx, s.rebind("SELECT id FROM attendees WHERE workshop_id = ? AND email_normalized = ?"), s.workshop, email).Scan(&id)
+	return id, err
+}
+
+// lockCapacity reads the event capacity. On Postgres the settings row is
+// locked until the transaction ends, which serializes every write that
+// decides who holds a seat; SQLite already allows a single writer.
+func (s *Store) lockCapacity(ctx context.Context, tx *sql.Tx) (int, error) {
+	query := "SELECT capacity FROM event_settings WHERE workshop_id = ?"
+	if s.driver == DriverPostgres {
+		query += " FOR UPDATE"
+	}
+	var capacity int
+	err := tx.QueryRowContext(ctx, s.rebind(query), s.workshop).Scan(&capacity)
+	return capacity, err
+}
+
+func (s *Store) attendeeStats(ctx context.Context, q querier) (store.AttendeeStats, error) {
+	var stats store.AttendeeStats
+	rows, err := q.QueryContext(ctx, s.rebind("SELECT status, COUNT(*) FROM attendees WHERE workshop_id = ? GROUP BY status"), s.workshop)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return stats, err
+		}
+		stats.Add(status, n)
+	}
+	return stats, rows.Err()
+}
+
+// writeAttendee stores a's document and status column. email is the
+// normalized email key, or nil to release it.
+func (s *Store) writeAttendee(ctx context.Context, q querier, a *models.Attendee, email interface{}) error {
+	stored := *a
+	stored.WaitlistPosition = 0 // computed on read, never stored
+	raw, err := json.Marshal(&stored)
+	if err != nil {
+		return err
+	}
+
+	res, err := q.ExecContext(ctx, s.rebind("UPDATE attendees SET data = ?, status = ?, email_normalized = ?, updated_at = ? WHERE workshop_id = ? AND id = ?"),
+		string(raw), a.Status, email, time.Now().UTC(), s.workshop, a.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) CreateAttendee(ctx context.Context, attendee *models.Attendee) error {
+	email := models.NormalizeEmail(attendee.Email)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	capacity, err := s.lockCapacity(ctx, tx)
+	if err != nil {
+		return err
+	}
+	existing, err := s.attendeeIDByEmail(ctx, tx, email)
+	if err == nil {
+		return &store.DuplicateError{ExistingID: existing}
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	stats, err := s.attendeeStats(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	attendee.Status = models.StatusRegistered
+	attendee.WaitlistPosition = 0
+	if !stats.HasSeat(capacity) {
+		attendee.Status = models.StatusWaitlisted
+	}
+	raw, err := json.Marshal(attendee)
+	if err != nil {
+		return err
+	}
+
+	// created_at mirrors the registration time so ordering by createdAt can
+	// use the column.
+	id := store.NewID()
+	now := time.Now().UTC()
+	created := now
+	if !attendee.CreatedAt.IsZero() {
+		created = attendee.CreatedAt.UTC()
+	}
+	_, err = tx.ExecContext(ctx, s.rebind("INSERT INTO attendees (id, workshop_id, data, status, email_normalized, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)"),
+		id, s.workshop, string(raw), attendee.Status, email, created, now)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		// A concurrent registration may have claimed the email between the
+		// lookup and the insert; the unique index rejects ours.
+		tx.Rollback()
+		if existing, lookupErr := s.attendeeIDByEmail(ctx, s.db, email); lookupErr == nil {
+			return &store.DuplicateError{ExistingID: existing}
+		}
+		return err
+	}
+
+	attendee.ID = id
+	if attendee.Status == models.StatusWaitlisted {
+		attendee.WaitlistPosition = stats.Waitlisted + 1
+	}
+	return nil
+}
+
+func (s *Store) UpdateAttendee(ctx context.Context, attendee *models.Attendee) error {
+	email := models.NormalizeEmail(attendee.Email)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var raw string
+	err = tx.QueryRowContext(ctx, s.rebind("SELECT data FROM attendees WHERE workshop_id = ? AND id = ?"), s.workshop, attendee.ID).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	stored, err := decodeAttendee(attendee.ID, raw)
+	if err != nil {
+		return err
+	}
+	if stored.Status == models.StatusCancelled {
+		return store.ErrCancelled
+	}
+	existing, err := s.attendeeIDByEmail(ctx, tx, email)
+	if err == nil && existing != attendee.ID {
+		return &store.DuplicateError{ExistingID: existing}
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	stored.Name, stored.Email, stored.Designation = attendee.Name, attendee.Email, attendee.Designation
+	if err := s.writeAttendee(ctx, tx, stored, email); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	*attendee = *stored
+	return nil
+}
+
+func (s *Store) CancelAttendee(ctx context.Context, id string) (*models.Attendee, []*models.Attendee, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	capacity, err := s.lockCapacity(ctx, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var raw string
+	err = tx.QueryRowContext(ctx, s.rebind("SELECT data FROM attendees WHERE workshop_id = ? AND id = ?"), s.workshop, id).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err := decodeAttendee(id, raw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if a.Status != models.StatusCancelled {
+		now := time.Now().UTC()
+		a.Status = models.StatusCancelled
+		a.CancelledAt = &now
+		if err := s.writeAttendee(ctx, tx, a, nil); err != nil {
+			return nil, nil, err
+		}
+	}
+	promoted, err := s.promote(ctx, tx, capacity)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, promoted, tx.Commit()
+}
+
+func (s *Store) CheckInAttendee(ctx context.Context, id, operator string) (*models.Attendee, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the row on Postgres so two volunteers scanning the same ticket
+	// cannot both check it in.
+	query := "SELECT data, email_normalized FROM attendees WHERE workshop_id = ? AND id = ?"
+	if s.driver == DriverPostgres {
+		query += " FOR UPDATE"
+	}
+	var raw string
+	var email sql.NullString
+	err = tx.QueryRowContext(ctx, s.rebind(query), s.workshop, id).Scan(&raw, &email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	a, err := decodeAttendee(id, raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.CheckIn(a, operator, time.Now().UTC()); err != nil {
+		return a, err
+	}
+
+	var key interface{}
+	if email.Valid {
+		key = email.String
+	}
+	if err := s.writeAttendee(ctx, tx, a, key); err != nil {
+		return nil, err
+	}
+	return a, tx.Commit()
+}
+
+func (s *Store) Waitlist(ctx context.Context) ([]*models.Attendee, error) {
+	return s.waitlist(ctx, s.db, 0)
+}
+
+// waitlist returns waitlisted attendees in promotion order, at most limit
+// of them unless limit is 0.
+func (s *Store) waitlist(ctx context.Context, q querier, limit int) ([]*models.Attendee, error) {
+	query := "SELECT id, data FROM attendees WHERE workshop_id = ? AND status = 'waitlisted' ORDER BY created_at, id"
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	rows, err := q.QueryContext(ctx, s.rebind(query), s.workshop)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*models.Attendee
+	for rows.Next() {
+		var id, raw string
+		if err := rows.Scan(&id, &raw); err != nil {
+			return nil, err
+		}
+		a, err := decodeAttendee(id, raw)
+		if err != nil {
+			return nil, err
+		}
+		a.WaitlistPosition = len(out) + 1
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// promote moves waitlisted attendees into the seats open under capacity.
+func (s *Store) promote(ctx context.Context, tx *sql.Tx, capacity int) ([]*models.Attendee, error) {
+	stats, err := s.attendeeStats(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	n := stats.Promotable(capacity)
+	if n == 0 {
+		return nil, nil
+	}
+
+	promoted, err := s.waitlist(ctx, tx, n)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	for _, a := range promoted {
+		a.Status = models.StatusRegistered
+		a.WaitlistPosition = 0
+		a.PromotedAt = &now
+		if err := s.writeAttendee(ctx, tx, a, models.NormalizeEmail(a.Email)); err != nil {
+			return nil, err
+		}
+	}
+	return promoted, nil
+}
+
+func (s *Store) AttendeeStats(ctx context.Context) (store.AttendeeStats, error) {
+	return s.attendeeStats(ctx, s.db)
+}
+
+func (s *Store) MergeAttendees(ctx context.Context, keep *models.Attendee, duplicateIDs []string) ([]*models.Attendee, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	capacity, err := s.lockCapacity(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Delete first so a duplicate holding the email key releases it before
+	// the keeper claims it.
+	for _, id := range duplicateIDs {
+		if _, err := tx.ExecContext(ctx, s.rebind("DELETE FROM attendees WHERE workshop_id = ? AND id = ?"), s.workshop, id); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.writeAttendee(ctx, tx, keep, models.NormalizeEmail(keep.Email)); err != nil {
+		return nil, err
+	}
+
+	promoted, err := s.promote(ctx, tx, capacity)
+	if err != nil {
+		return nil, err
+	}
+	return promoted, tx.Commit()
+}
+
+func (s *Store) EventSettings(ctx context.Context) (*models.EventSettings, error) {
+	var settings models.EventSettings
+	err := s.db.QueryRowContext(ctx, s.rebind("SELECT capacity FROM event_settings WHERE workshop_id = ?"), s.workshop).Scan(&settings.Capacity)
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (s *Store) UpdateEventSettings(ctx context.Context, settings *models.EventSettings) ([]*models.Attendee, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := s.lockCapacity(ctx, tx); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, s.rebind("UPDATE event_settings SET capacity = ? WHERE workshop_id = ?"), settings.Capacity, s.workshop); err != nil {
+		return nil, err
+	}
+
+	promoted, err := s.promote(ctx, tx, settings.Capacity)
+	if err != nil {
+		return nil, err
+	}
+	return promoted, tx.Commit()
+}
@@ -0,0 +1,164 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+var _ store.EnrollmentStore = (*Store)(nil)
+
+func (s *Store) Enroll(ctx context.Context, sessionID, attendeeID string) (*models.Enrollment, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// On Postgres the session row serializes enrollments competing for its
+	// places, and the attendee row those checking the attendee's schedule.
+	lock := ""
+	if s.driver == DriverPostgres {
+		lock = " FOR UPDATE"
+	}
+	var raw string
+	err = tx.QueryRowContext(ctx, s.rebind("SELECT data FROM sessions WHERE workshop_id = ? AND id = ?"+lock), s.workshop, sessionID).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	session, err := decodeSession(sessionID, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx, s.rebind("SELECT data FROM attendees WHERE workshop_id = ? AND id = ?"+lock), s.workshop, attendeeID).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	a, err := decodeAttendee(attendeeID, raw)
+	if err != nil {
+		return nil, err
+	}
+	if !a.HoldsSeat() {
+		return nil, store.ErrNoSeat
+	}
+
+	e := &models.Enrollment{SessionID: sessionID, AttendeeID: attendeeID}
+	err = tx.QueryRowContext(ctx, s.rebind("SELECT created_at FROM enrollments WHERE session_id = ? AND attendee_id = ?"),
+		sessionID, attendeeID).Scan(&e.CreatedAt)
+	if err == nil {
+		e.CreatedAt = e.CreatedAt.UTC()
+		return e, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	var enrolled int
+	if err := tx.QueryRowContext(ctx, s.rebind("SELECT COUNT(*) FROM enrollments WHERE session_id = ?"), sessionID).Scan(&enrolled); err != nil {
+		return nil, err
+	}
+	schedule, err := s.schedule(ctx, tx, attendeeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.CheckEnrollment(session, enrolled, schedule); err != nil {
+		return nil, err
+	}
+
+	e.CreatedAt = time.Now().UTC()
+	if _, err := tx.ExecContext(ctx, s.rebind("INSERT INTO enrollments (session_id, attendee_id, workshop_id, created_at) VALUES (?, ?, ?, ?)"),
+		sessionID, attendeeID, s.workshop, e.CreatedAt); err != nil {
+		return nil, err
+	}
+	return e, tx.Commit()
+}
+
+// schedule returns the sessions an attendee is enrolled in.
+func (s *Store) schedule(ctx context.Context, q querier, attendeeID string) ([]*models.Session, error) {
+	return s.querySessions(ctx, q, `SELECT s.id, s.data FROM sessions s
+		JOIN enrollments e ON e.session_id = s.id
+		WHERE e.workshop_id = ? AND e.attendee_id = ?`, s.workshop, attendeeID)
+}
+
+func (s *Store) Unenroll(ctx context.Context, sessionID, attendeeID string) error {
+	_, err := s.exec(ctx, "DELETE FROM enrollments WHERE workshop_id = ? AND session_id = ? AND attendee_id = ?", s.workshop, sessionID, attendeeID)
+	return err
+}
+
+// enrollmentWhere returns the WHERE clause and arguments selecting filter
+// in the store's workshop, and whether the filter selects anything less
+// than every enrollment.
+func (s *Store) enrollmentWhere(filter store.EnrollmentFilter) (string, []interface{}, bool) {
+	conds := []string{"workshop_id = ?"}
+	args := []interface{}{s.workshop}
+	if filter.SessionID != "" {
+		conds = append(conds, "session_id = ?")
+		args = append(args, filter.SessionID)
+	}
+	if filter.AttendeeID != "" {
+		conds = append(conds, "attendee_id = ?")
+		args = append(args, filter.AttendeeID)
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args, len(conds) > 1
+}
+
+func (s *Store) ListEnrollments(ctx context.Context, filter store.EnrollmentFilter) ([]*models.Enrollment, error) {
+	where, args, _ := s.enrollmentWhere(filter)
+	rows, err := s.db.QueryContext(ctx, s.rebind("SELECT session_id, attendee_id, created_at FROM enrollments"+where+
+		" ORDER BY created_at, session_id, attendee_id"), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*models.Enrollment
+	for rows.Next() {
+		e := new(models.Enrollment)
+		if err := rows.Scan(&e.SessionID, &e.AttendeeID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.CreatedAt = e.CreatedAt.UTC()
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) DeleteEnrollments(ctx context.Context, filter store.EnrollmentFilter) error {
+	where, args, selective := s.enrollmentWhere(filter)
+	if !selective {
+		return nil
+	}
+	_, err := s.exec(ctx, "DELETE FROM enrollments"+where, args...)
+	return err
+}
+
+func (s *Store) EnrollmentCounts(ctx context.Context) (map[string]int, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind("SELECT session_id, COUNT(*) FROM enrollments WHERE workshop_id = ? GROUP BY session_id"), s.workshop)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var id string
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		counts[id] = n
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,151 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+var _ store.ImportStore = (*Store)(nil)
+
+// importBatches calls write for consecutive batches of the n documents of an
+// import, [from, to), each in its own transaction, and returns how many
+// documents were committed.
+func (s *Store) importBatches(ctx context.Context, n int, write func(tx *sql.Tx, from, to int) error) (int, error) {
+	done := 0
+	for done < n {
+		to := min(done+store.ImportBatchSize, n)
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return done, err
+		}
+		if err := write(tx, done, to); err != nil {
+			tx.Rollback()
+			return done, err
+		}
+		if err := tx.Commit(); err != nil {
+			return done, err
+		}
+		done = to
+	}
+	return done, nil
+}
+
+// ImportAttendees locks the event capacity in each batch's transaction, like
+// CreateAttendee, so concurrent registrations cannot oversell seats.
+func (s *Store) ImportAttendees(ctx context.Context, attendees []*models.Attendee) (int, error) {
+	return s.importBatches(ctx, len(attendees), func(tx *sql.Tx, from, to int) error {
+		capacity, err := s.lockCapacity(ctx, tx)
+		if err != nil {
+			return err
+		}
+		stats, err := s.attendeeStats(ctx, tx)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		for _, a := range attendees[from:to] {
+			email := models.NormalizeEmail(a.Email)
+			existing, err := s.attendeeIDByEmail(ctx, tx, email)
+			if err == nil {
+				return &store.DuplicateError{ExistingID: existing}
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			a.Status = models.StatusRegistered
+			a.WaitlistPosition = 0
+			if !stats.HasSeat(capacity) {
+				a.Status = models.StatusWaitlisted
+			}
+			raw, err := json.Marshal(a)
+			if err != nil {
+				return err
+			}
+			if a.Status == models.StatusWaitlisted {
+				a.WaitlistPosition = stats.Waitlisted + 1
+			}
+			stats.Add(a.Status, 1)
+
+			created := now
+			if !a.CreatedAt.IsZero() {
+				created = a.CreatedAt.UTC()
+			}
+			if _, err := tx.ExecContext(ctx, s.rebind("INSERT INTO attendees (id, workshop_id, data, status, email_normalized, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)"),
+				a.ID, s.workshop, string(raw), a.Status, email, created, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Store) ImportSpeakers(ctx context.Context, speakers []*models.Speaker) (int, error) {
+	return s.importBatches(ctx, len(speakers), func(tx *sql.Tx, from, to int) error {
+		now := time.Now().UTC()
+		for _, sp := range speakers[from:to] {
+			if err := s.insertWithID(ctx, tx, "speakers", sp.ID, sp, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ImportSessions checks speakers and rooms again in each batch's
+// transaction, as CreateSession does.
+func (s *Store) ImportSessions(ctx context.Context, sessions []*models.Session) (int, error) {
+	return s.importBatches(ctx, len(sessions), func(tx *sql.Tx, from, to int) error {
+		now := time.Now().UTC()
+		for _, se := range sessions[from:to] {
+			se.SyncSpeakers()
+			if err := s.checkRoom(ctx, tx, se); err != nil {
+				return err
+			}
+			if err := s.insertWithID(ctx, tx, "sessions", se.ID, se, now); err != nil {
+				return err
+			}
+			if err := s.writeSessionSpeakers(ctx, tx, se.ID, se.SpeakerIDs); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// IDsInUse looks in every workshop: documents are keyed by ID alone.
+func (s *Store) IDsInUse(ctx context.Context, collection string, ids []string) (map[string]bool, error) {
+	if collection != "speakers" && collection != "sessions" {
+		return nil, fmt.Errorf("sqlstore: cannot look up IDs of %s", collection)
+	}
+	out := map[string]bool{}
+	if len(ids) == 0 {
+		return out, nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	rows, err := s.db.QueryContext(ctx, s.rebind("SELECT id FROM "+collection+" WHERE id IN ("+placeholders+")"), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out[id] = true
+	}
+	return out, rows.Err()
+}
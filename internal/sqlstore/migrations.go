@@ -0,0 +1,361 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"appdirect-workshop/internal/models"
+)
+
+// migration is a numbered, forward-only schema change. Statements must be
+// valid for both SQLite and Postgres; data changes that need per-row logic go
+// in run, which executes after the statements in the same transaction.
+type migration struct {
+	version    int
+	name       string
+	statements []string
+	run        func(ctx context.Context, s *Store, tx *sql.Tx) error
+}
+
+// migrations are applied in order on startup. Never edit or reorder an entry
+// that has shipped; append a new one instead.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create document tables",
+		statements: []string{
+			`CREATE TABLE attendees (
+				id TEXT PRIMARY KEY,
+				data TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE TABLE speakers (
+				id TEXT PRIMARY KEY,
+				data TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE TABLE sessions (
+				id TEXT PRIMARY KEY,
+				data TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 2,
+		name:    "unique attendee emails",
+		statements: []string{
+			`ALTER TABLE attendees ADD COLUMN email_normalized TEXT`,
+			`CREATE UNIQUE INDEX attendees_email_normalized ON attendees (email_normalized)`,
+		},
+		run: backfillAttendeeEmails,
+	},
+	{
+		version: 3,
+		name:    "create admins",
+		statements: []string{
+			`CREATE TABLE admins (
+				username TEXT PRIMARY KEY,
+				password_hash TEXT NOT NULL,
+				disabled BOOLEAN NOT NULL DEFAULT FALSE,
+				created_at TIMESTAMP NOT NULL,
+				password_changed_at TIMESTAMP NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 4,
+		name:    "admin roles",
+		statements: []string{
+			// Accounts created before roles existed had full access.
+			`ALTER TABLE admins ADD COLUMN role TEXT NOT NULL DEFAULT 'owner'`,
+		},
+	},
+	{
+		version: 5,
+		name:    "event capacity and waitlist",
+		statements: []string{
+			// Registrations made before the waitlist existed hold a seat.
+			`ALTER TABLE attendees ADD COLUMN status TEXT NOT NULL DEFAULT 'registered'`,
+			`CREATE INDEX attendees_status_created_at ON attendees (status, created_at)`,
+			`CREATE TABLE event_settings (
+				id INTEGER PRIMARY KEY,
+				capacity INTEGER NOT NULL
+			)`,
+			`INSERT INTO event_settings (id, capacity) VALUES (1, 0)`,
+		},
+		run: backfillAttendeeStatus,
+	},
+	{
+		version: 6,
+		name:    "session reminders",
+		statements: []string{
+			`CREATE TABLE reminders (
+				id TEXT PRIMARY KEY,
+				data TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 7,
+		name:    "session enrollments",
+		statements: []string{
+			`CREATE TABLE enrollments (
+				session_id TEXT NOT NULL,
+				attendee_id TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				PRIMARY KEY (session_id, attendee_id)
+			)`,
+			`CREATE INDEX enrollments_attendee_id ON enrollments (attendee_id)`,
+		},
+	},
+	{
+		version: 8,
+		name:    "session speakers",
+		statements: []string{
+			// Speakers named by a session cannot be deleted out from under
+			// it; DeleteSpeaker removes these rows first when cascading.
+			`CREATE TABLE session_speakers (
+				session_id TEXT NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+				speaker_id TEXT NOT NULL REFERENCES speakers (id),
+				PRIMARY KEY (session_id, speaker_id)
+			)`,
+			`CREATE INDEX session_speakers_speaker_id ON session_speakers (speaker_id)`,
+		},
+		run: backfillSessionSpeakers,
+	},
+	{
+		version: 9,
+		name:    "workshops",
+		statements: []string{
+			`CREATE TABLE workshops (
+				id TEXT PRIMARY KEY,
+				data TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+			// Rows stored before workshops existed belong to the default
+			// workshop, whose ID in these columns is empty.
+			`ALTER TABLE attendees ADD COLUMN workshop_id TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE speakers ADD COLUMN workshop_id TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE sessions ADD COLUMN workshop_id TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE reminders ADD COLUMN workshop_id TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE enrollments ADD COLUMN workshop_id TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE event_settings ADD COLUMN workshop_id TEXT NOT NULL DEFAULT ''`,
+			// The same person may register for several workshops.
+			`DROP INDEX attendees_email_normalized`,
+			`CREATE UNIQUE INDEX attendees_email_normalized ON attendees (workshop_id, email_normalized)`,
+			`DROP INDEX attendees_status_created_at`,
+			`CREATE INDEX attendees_status_created_at ON attendees (workshop_id, status, created_at)`,
+			`CREATE INDEX speakers_workshop_id ON speakers (workshop_id)`,
+			`CREATE INDEX sessions_workshop_id ON sessions (workshop_id)`,
+			`CREATE INDEX reminders_workshop_id ON reminders (workshop_id)`,
+			`CREATE INDEX enrollments_workshop_id ON enrollments (workshop_id)`,
+			`CREATE UNIQUE INDEX event_settings_workshop_id ON event_settings (workshop_id)`,
+		},
+	},
+}
+
+// backfillAttendeeEmails claims each normalized email for its earliest
+// registration. Later duplicates keep a NULL key, which the unique index
+// ignores, until an admin merges them.
+func backfillAttendeeEmails(ctx context.Context, s *Store, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, "SELECT id, data FROM attendees ORDER BY created_at, id")
+	if err != nil {
+		return err
+	}
+
+	claimed := map[string]string{}
+	var order []string
+	for rows.Next() {
+		var id, raw string
+		if err := rows.Scan(&id, &raw); err != nil {
+			rows.Close()
+			return err
+		}
+		var a struct {
+			Email string `json:"email"`
+		}
+		if err := json.Unmarshal([]byte(raw), &a); err != nil {
+			rows.Close()
+			return fmt.Errorf("decoding attendees/%s: %w", id, err)
+		}
+		email := models.NormalizeEmail(a.Email)
+		if _, ok := claimed[email]; ok || email == "" {
+			continue
+		}
+		claimed[email] = id
+		order = append(order, email)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, email := range order {
+		if _, err := tx.ExecContext(ctx, s.rebind("UPDATE attendees SET email_normalized = ? WHERE id = ?"), email, claimed[email]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillAttendeeStatus writes the registered status into documents stored
+// before statuses existed, so filters on the status field match them.
+func backfillAttendeeStatus(ctx context.Context, s *Store, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, "SELECT id, data FROM attendees")
+	if err != nil {
+		return err
+	}
+
+	updated := map[string]string{}
+	for rows.Next() {
+		var id, raw string
+		if err := rows.Scan(&id, &raw); err != nil {
+			rows.Close()
+			return err
+		}
+		var doc map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+			rows.Close()
+			return fmt.Errorf("decoding attendees/%s: %w", id, err)
+		}
+		if _, ok := doc["status"]; ok {
+			continue
+		}
+		doc["status"] = json.RawMessage(`"` + models.StatusRegistered + `"`)
+		out, err := json.Marshal(doc)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		updated[id] = string(out)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, raw := range updated {
+		if _, err := tx.ExecContext(ctx, s.rebind("UPDATE attendees SET data = ? WHERE id = ?"), raw, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillSessionSpeakers moves each session's speakerId into speakerIds and
+// records it in session_speakers. IDs of speakers deleted before deletion
+// was checked are dropped from the session.
+func backfillSessionSpeakers(ctx context.Context, s *Store, tx *sql.Tx) error {
+	speakers := map[string]bool{}
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM speakers")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		speakers[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	sessions, err := s.querySessions(ctx, tx, "SELECT id, data FROM sessions")
+	if err != nil {
+		return err
+	}
+	for _, se := range sessions {
+		se.SyncSpeakers()
+		kept := []string{}
+		for _, id := range se.SpeakerIDs {
+			if speakers[id] {
+				kept = append(kept, id)
+			}
+		}
+		se.SpeakerIDs, se.SpeakerID = kept, ""
+		se.SyncSpeakers()
+
+		raw, err := json.Marshal(se)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, s.rebind("UPDATE sessions SET data = ? WHERE id = ?"), string(raw), se.ID); err != nil {
+			return err
+		}
+		for _, id := range se.SpeakerIDs {
+			if _, err := tx.ExecContext(ctx, s.rebind("INSERT INTO session_speakers (session_id, speaker_id) VALUES (?, ?)"), se.ID, id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// migrate applies every migration newer than the recorded schema version.
+// Each migration runs in its own transaction together with its bookkeeping
+// row so a failure leaves the schema at the previous version.
+func (s *Store) migrate(ctx context.Context) error {
+	if _, err := s.exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`); err != nil {
+		return err
+	}
+
+	var current int
+	if err := s.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := s.apply(ctx, m); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		log.Printf("Applied SQL migration %d: %s", m.version, m.name)
+	}
+	return nil
+}
+
+func (s *Store) apply(ctx context.Context, m migration) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range m.statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	if m.run != nil {
+		if err := m.run(ctx, s, tx); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, s.rebind("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"),
+		m.version, m.name, time.Now().UTC()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,163 @@
+package sqlstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+// fieldExpr returns the SQL expression for a JSON field. createdAt maps to
+// the created_at column, which holds the same instant as a native timestamp
+// that sorts correctly.
+func (s *Store) fieldExpr(name string, kind store.FieldKind) string {
+	if kind == store.TimeField {
+		return "created_at"
+	}
+	if s.driver == DriverPostgres {
+		return fmt.Sprintf("COALESCE(data::jsonb ->> '%s', '')", name)
+	}
+	return fmt.Sprintf("COALESCE(json_extract(data, '$.%s'), '')", name)
+}
+
+// page runs a keyset query: filters, ordering by the OrderBy field then ID,
+// and a WHERE clause that starts after the cursor instead of an OFFSET.
+// Field names are checked against fields before being put into SQL.
+func page[T any](ctx context.Context, s *Store, table string, opts store.ListOptions, fields map[string]store.FieldKind, setID func(*T, string)) (*store.Page[T], error) {
+	cursor, err := store.DecodeCursor(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	where := []string{"workshop_id = ?"}
+	args := []interface{}{s.workshop}
+
+	names := make([]string, 0, len(opts.Filters))
+	for name := range opts.Filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		kind, ok := fields[name]
+		if !ok || kind != store.StringField {
+			return nil, fmt.Errorf("cannot filter %s by %q", table, name)
+		}
+		where = append(where, s.fieldExpr(name, kind)+" = ?")
+		args = append(args, opts.Filters[name])
+	}
+
+	dir, cmp := "ASC", ">"
+	if opts.Descending {
+		dir, cmp = "DESC", "<"
+	}
+
+	order := "id " + dir
+	orderExpr := ""
+	var orderKind store.FieldKind
+	if opts.OrderBy != "" {
+		kind, ok := fields[opts.OrderBy]
+		if !ok {
+			return nil, fmt.Errorf("cannot order %s by %q", table, opts.OrderBy)
+		}
+		orderKind = kind
+		orderExpr = s.fieldExpr(opts.OrderBy, kind)
+		order = orderExpr + " " + dir + ", " + order
+	}
+
+	if cursor != nil {
+		if orderExpr == "" {
+			where = append(where, "id "+cmp+" ?")
+			args = append(args, cursor.ID)
+		} else {
+			var value interface{} = cursor.Value
+			if orderKind == store.TimeField {
+				t, err := store.ParseTime(cursor.Value)
+				if err != nil {
+					return nil, store.ErrInvalidPageToken
+				}
+				value = t
+			}
+			where = append(where, fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", orderExpr, cmp, orderExpr, cmp))
+			args = append(args, value, value, cursor.ID)
+		}
+	}
+
+	query := fmt.Sprintf("SELECT id, data, %s FROM %s WHERE %s", orderColumn(orderExpr), table, strings.Join(where, " AND "))
+	// Fetch one extra row to learn whether another page follows.
+	query += fmt.Sprintf(" ORDER BY %s LIMIT %d", order, opts.Limit+1)
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := &store.Page[T]{Items: []*T{}}
+	var lastID, lastValue string
+	for rows.Next() {
+		var id, raw string
+		var key interface{}
+		if err := rows.Scan(&id, &raw, &key); err != nil {
+			return nil, err
+		}
+		if len(out.Items) == opts.Limit {
+			out.NextPageToken = store.EncodeCursor(store.Cursor{
+				OrderBy:    opts.OrderBy,
+				Descending: opts.Descending,
+				Value:      lastValue,
+				ID:         lastID,
+			})
+			break
+		}
+
+		v := new(T)
+		if err := json.Unmarshal([]byte(raw), v); err != nil {
+			return nil, fmt.Errorf("decoding %s/%s: %w", table, id, err)
+		}
+		setID(v, id)
+		out.Items = append(out.Items, v)
+		lastID, lastValue = id, cursorValue(key)
+	}
+	return out, rows.Err()
+}
+
+// orderColumn selects the ordering value so the next cursor can be built
+// from the last row; a constant stands in when ordering by ID only.
+func orderColumn(expr string) string {
+	if expr == "" {
+		return "''"
+	}
+	return expr
+}
+
+// cursorValue formats a scanned ordering value for a store.Cursor. Both
+// drivers return timestamp columns as time.Time.
+func cursorValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return store.FormatTime(v)
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func (s *Store) PageAttendees(ctx context.Context, opts store.ListOptions) (*store.Page[models.Attendee], error) {
+	return page(ctx, s, "attendees", opts, store.AttendeeFields, func(a *models.Attendee, id string) { a.ID = id })
+}
+
+func (s *Store) PageSpeakers(ctx context.Context, opts store.ListOptions) (*store.Page[models.Speaker], error) {
+	return page(ctx, s, "speakers", opts, store.SpeakerFields, func(sp *models.Speaker, id string) { sp.ID = id })
+}
+
+func (s *Store) PageSessions(ctx context.Context, opts store.ListOptions) (*store.Page[models.Session], error) {
+	return page(ctx, s, "sessions", opts, store.SessionFields, func(se *models.Session, id string) { se.ID = id })
+}
@@ -0,0 +1,88 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+var _ store.ReminderStore = (*Store)(nil)
+
+func (s *Store) ListReminders(ctx context.Context) ([]*models.Reminder, error) {
+	return list(ctx, s, "reminders", func(r *models.Reminder, id string) { r.ID = id })
+}
+
+func (s *Store) GetReminder(ctx context.Context, id string) (*models.Reminder, error) {
+	var raw string
+	err := s.db.QueryRowContext(ctx, s.rebind("SELECT data FROM reminders WHERE workshop_id = ? AND id = ?"), s.workshop, id).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeReminder(id, raw)
+}
+
+func decodeReminder(id, raw string) (*models.Reminder, error) {
+	var r models.Reminder
+	if err := json.Unmarshal([]byte(raw), &r); err != nil {
+		return nil, fmt.Errorf("decoding reminders/%s: %w", id, err)
+	}
+	r.ID = id
+	return &r, nil
+}
+
+func (s *Store) UpdateReminder(ctx context.Context, id string, update func(*models.Reminder) error) (*models.Reminder, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// An empty placeholder makes sure there is a row to lock, so on Postgres
+	// two updates racing to create the same reminder are serialized too. It
+	// is rolled back with everything else if update fails.
+	now := time.Now().UTC()
+	_, err = tx.ExecContext(ctx, s.rebind("INSERT INTO reminders (id, workshop_id, data, created_at, updated_at) VALUES (?, ?, '{}', ?, ?) ON CONFLICT (id) DO NOTHING"),
+		id, s.workshop, now, now)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT data FROM reminders WHERE workshop_id = ? AND id = ?"
+	if s.driver == DriverPostgres {
+		query += " FOR UPDATE"
+	}
+	var raw string
+	err = tx.QueryRowContext(ctx, s.rebind(query), s.workshop, id).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The ID belongs to a reminder of another workshop.
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	r, err := decodeReminder(id, raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := update(r); err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, s.rebind("UPDATE reminders SET data = ?, updated_at = ? WHERE workshop_id = ? AND id = ?"), string(encoded), now, s.workshop, id); err != nil {
+		return nil, err
+	}
+	return r, tx.Commit()
+}
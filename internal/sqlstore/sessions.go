@@ -0,0 +1,122 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+// The speakers of each session are mirrored into session_speakers, whose
+// foreign keys keep a session from naming a speaker that does not exist.
+
+// writeSession replaces a session's document, returning store.ErrNotFound
+// when it does not exist.
+func (s *Store) writeSession(ctx context.Context, q querier, session *models.Session) error {
+	raw, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	res, err := q.ExecContext(ctx, s.rebind("UPDATE sessions SET data = ?, updated_at = ? WHERE workshop_id = ? AND id = ?"),
+		string(raw), time.Now().UTC(), s.workshop, session.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// writeSessionSpeakers replaces the session_speakers rows of a session,
+// returning a *store.MissingSpeakersError if a speaker does not exist.
+func (s *Store) writeSessionSpeakers(ctx context.Context, tx *sql.Tx, sessionID string, speakerIDs []string) error {
+	var missing []string
+	for _, id := range speakerIDs {
+		var found string
+		err := tx.QueryRowContext(ctx, s.rebind("SELECT id FROM speakers WHERE workshop_id = ? AND id = ?"), s.workshop, id).Scan(&found)
+		if errors.Is(err, sql.ErrNoRows) {
+			missing = append(missing, id)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		return &store.MissingSpeakersError{IDs: missing}
+	}
+
+	if _, err := tx.ExecContext(ctx, s.rebind("DELETE FROM session_speakers WHERE session_id = ?"), sessionID); err != nil {
+		return err
+	}
+	for _, id := range speakerIDs {
+		if _, err := tx.ExecContext(ctx, s.rebind("INSERT INTO session_speakers (session_id, speaker_id) VALUES (?, ?)"),
+			sessionID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRoom returns a *store.RoomConflictError if another session is booked
+// into the session's room at the same time. On Postgres it first takes a
+// lock that keeps other session writes out until tx ends, so two bookings
+// cannot both see the room free.
+func (s *Store) checkRoom(ctx context.Context, tx *sql.Tx, session *models.Session) error {
+	if session.Room == "" {
+		return nil
+	}
+	if s.driver == DriverPostgres {
+		if _, err := tx.ExecContext(ctx, "LOCK TABLE sessions IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+			return err
+		}
+	}
+	room := s.fieldExpr("room", store.StringField)
+	others, err := s.querySessions(ctx, tx, "SELECT id, data FROM sessions WHERE workshop_id = ? AND LOWER("+room+") = LOWER(?) ORDER BY id",
+		s.workshop, session.Room)
+	if err != nil {
+		return err
+	}
+	return store.CheckRoom(session, others)
+}
+
+// querySessions decodes the sessions selected by a query returning id and
+// data columns.
+func (s *Store) querySessions(ctx context.Context, q querier, query string, args ...interface{}) ([]*models.Session, error) {
+	rows, err := q.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*models.Session
+	for rows.Next() {
+		var id, raw string
+		if err := rows.Scan(&id, &raw); err != nil {
+			return nil, err
+		}
+		session, err := decodeSession(id, raw)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, session)
+	}
+	return out, rows.Err()
+}
+
+func decodeSession(id, raw string) (*models.Session, error) {
+	var se models.Session
+	if err := json.Unmarshal([]byte(raw), &se); err != nil {
+		return nil, fmt.Errorf("decoding sessions/%s: %w", id, err)
+	}
+	se.ID = id
+	return &se, nil
+}
@@ -0,0 +1,340 @@
+// Package sqlstore implements the store interfaces on top of database/sql so
+// the API can run on-prem against SQLite (single node) or Postgres.
+//
+// Documents are kept as JSON in a data column next to a handful of indexed
+// columns, so adding a model field does not require a schema change while the
+// server still manages the table layout through migrations.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the "pgx" driver
+	_ "modernc.org/sqlite"             // registers the "sqlite" driver
+)
+
+var (
+	_ store.AttendeeStore = (*Store)(nil)
+	_ store.SpeakerStore  = (*Store)(nil)
+	_ store.SessionStore  = (*Store)(nil)
+	_ store.WorkshopStore = (*Store)(nil)
+)
+
+// Driver names accepted by Open.
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "pgx"
+)
+
+// Store is a SQL-backed implementation of the store interfaces.
+type Store struct {
+	db     *sql.DB
+	driver string
+	// workshop selects the rows of one workshop; see Scoped.
+	workshop string
+}
+
+// Open connects to the database and applies any pending migrations.
+// For SQLite the DSN is a file path; for Postgres it is a connection URL.
+func Open(ctx context.Context, driver, dsn string) (*Store, error) {
+	switch driver {
+	case DriverSQLite:
+		dsn = sqliteDSN(dsn)
+	case DriverPostgres:
+	default:
+		return nil, fmt.Errorf("unsupported SQL driver %q", driver)
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if driver == DriverSQLite {
+		// SQLite allows a single writer; serializing connections avoids
+		// SQLITE_BUSY errors under concurrent requests.
+		db.SetMaxOpenConns(1)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &Store{db: db, driver: driver}
+	if err := s.migrate(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating schema: %w", err)
+	}
+	return s, nil
+}
+
+// Close releases the underlying database handle.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// sqliteDSN enables foreign keys and a busy timeout on every connection.
+func sqliteDSN(path string) string {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+}
+
+// rebind rewrites "?" placeholders to "$n" for Postgres.
+func (s *Store) rebind(query string) string {
+	if s.driver != DriverPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (s *Store) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return s.db.ExecContext(ctx, s.rebind(query), args...)
+}
+
+// list decodes every document in a table into a T ordered by ID, matching
+// Firestore's default ordering.
+func list[T any](ctx context.Context, s *Store, table string, setID func(*T, string)) ([]*T, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(fmt.Sprintf("SELECT id, data FROM %s WHERE workshop_id = ? ORDER BY id", table)), s.workshop)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*T
+	for rows.Next() {
+		var id, raw string
+		if err := rows.Scan(&id, &raw); err != nil {
+			return nil, err
+		}
+
+		v := new(T)
+		if err := json.Unmarshal([]byte(raw), v); err != nil {
+			return nil, fmt.Errorf("decoding %s/%s: %w", table, id, err)
+		}
+		setID(v, id)
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
+
+// insert stores a new document under a freshly generated ID.
+func (s *Store) insert(ctx context.Context, table string, data interface{}) (string, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	id := store.NewID()
+	now := time.Now().UTC()
+	_, err = s.exec(ctx, fmt.Sprintf("INSERT INTO %s (id, workshop_id, data, created_at, updated_at) VALUES (?, ?, ?, ?, ?)", table),
+		id, s.workshop, string(raw), now, now)
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// replace overwrites an existing document, returning store.ErrNotFound when
+// no row has the given ID.
+func (s *Store) replace(ctx context.Context, table, id string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.exec(ctx, fmt.Sprintf("UPDATE %s SET data = ?, updated_at = ? WHERE workshop_id = ? AND id = ?", table),
+		string(raw), time.Now().UTC(), s.workshop, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) delete(ctx context.Context, table, id string) error {
+	_, err := s.exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE workshop_id = ? AND id = ?", table), s.workshop, id)
+	return err
+}
+
+// Speakers
+
+func (s *Store) ListSpeakers(ctx context.Context) ([]*models.Speaker, error) {
+	return list(ctx, s, "speakers", func(sp *models.Speaker, id string) { sp.ID = id })
+}
+
+func (s *Store) GetSpeakers(ctx context.Context, ids []string) (map[string]*models.Speaker, error) {
+	out := make(map[string]*models.Speaker, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	rows, err := s.db.QueryContext(ctx, s.rebind("SELECT id, data FROM speakers WHERE workshop_id = ? AND id IN ("+placeholders+")"),
+		append([]interface{}{s.workshop}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, raw string
+		if err := rows.Scan(&id, &raw); err != nil {
+			return nil, err
+		}
+		sp := new(models.Speaker)
+		if err := json.Unmarshal([]byte(raw), sp); err != nil {
+			return nil, fmt.Errorf("decoding speakers/%s: %w", id, err)
+		}
+		sp.ID = id
+		out[id] = sp
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) CreateSpeaker(ctx context.Context, speaker *models.Speaker) error {
+	id, err := s.insert(ctx, "speakers", speaker)
+	if err != nil {
+		return err
+	}
+	speaker.ID = id
+	return nil
+}
+
+func (s *Store) UpdateSpeaker(ctx context.Context, speaker *models.Speaker) error {
+	return s.replace(ctx, "speakers", speaker.ID, speaker)
+}
+
+// DeleteSpeaker locks the sessions naming the speaker, through
+// session_speakers, in the transaction that deletes it.
+func (s *Store) DeleteSpeaker(ctx context.Context, id string, cascade bool) ([]string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	lock := ""
+	if s.driver == DriverPostgres {
+		lock = " FOR UPDATE"
+	}
+	sessions, err := s.querySessions(ctx, tx, `SELECT id, data FROM sessions WHERE workshop_id = ? AND id IN (
+		SELECT session_id FROM session_speakers WHERE speaker_id = ?) ORDER BY id`+lock, s.workshop, id)
+	if err != nil {
+		return nil, err
+	}
+	var sessionIDs []string
+	for _, se := range sessions {
+		sessionIDs = append(sessionIDs, se.ID)
+	}
+	if len(sessionIDs) > 0 && !cascade {
+		return sessionIDs, &store.SpeakerInUseError{SessionIDs: sessionIDs}
+	}
+
+	for _, se := range sessions {
+		se.RemoveSpeaker(id)
+		if err := s.writeSession(ctx, tx, se); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, s.rebind(`DELETE FROM session_speakers WHERE speaker_id = ? AND session_id IN (
+		SELECT id FROM sessions WHERE workshop_id = ?)`), id, s.workshop); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, s.rebind("DELETE FROM speakers WHERE workshop_id = ? AND id = ?"), s.workshop, id); err != nil {
+		return nil, err
+	}
+	return sessionIDs, tx.Commit()
+}
+
+// Sessions
+
+func (s *Store) ListSessions(ctx context.Context) ([]*models.Session, error) {
+	return list(ctx, s, "sessions", func(se *models.Session, id string) { se.ID = id })
+}
+
+func (s *Store) CreateSession(ctx context.Context, session *models.Session) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	session.SyncSpeakers()
+	if err := s.checkRoom(ctx, tx, session); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	id := store.NewID()
+	now := time.Now().UTC()
+	if _, err := tx.ExecContext(ctx, s.rebind("INSERT INTO sessions (id, workshop_id, data, created_at, updated_at) VALUES (?, ?, ?, ?, ?)"),
+		id, s.workshop, string(raw), now, now); err != nil {
+		return err
+	}
+	if err := s.writeSessionSpeakers(ctx, tx, id, session.SpeakerIDs); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	session.ID = id
+	return nil
+}
+
+func (s *Store) UpdateSession(ctx context.Context, session *models.Session) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	session.SyncSpeakers()
+	if err := s.checkRoom(ctx, tx, session); err != nil {
+		return err
+	}
+	if err := s.writeSession(ctx, tx, session); err != nil {
+		return err
+	}
+	if err := s.writeSessionSpeakers(ctx, tx, session.ID, session.SpeakerIDs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Store) DeleteSession(ctx context.Context, id string) error {
+	return s.delete(ctx, "sessions", id)
+}
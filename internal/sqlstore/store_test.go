@@ -0,0 +1,653 @@
+package sqlstore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestStore(t *testing.T) (*Store, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "workshop.db")
+	s, err := Open(context.Background(), DriverSQLite, path)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s, path
+}
+
+func TestRebind(t *testing.T) {
+	s := &Store{driver: DriverPostgres}
+	assert.Equal(t, "SELECT * FROM t WHERE a = $1 AND b = $2", s.rebind("SELECT * FROM t WHERE a = ? AND b = ?"))
+
+	s = &Store{driver: DriverSQLite}
+	assert.Equal(t, "SELECT ?", s.rebind("SELECT ?"))
+}
+
+func TestMigrationsAreIdempotent(t *testing.T) {
+	ctx := context.Background()
+	s, path := openTestStore(t)
+	require.NoError(t, s.Close())
+
+	reopened, err := Open(ctx, DriverSQLite, path)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	var version int
+	require.NoError(t, reopened.db.QueryRowContext(ctx, "SELECT MAX(version) FROM schema_migrations").Scan(&version))
+	assert.Equal(t, migrations[len(migrations)-1].version, version)
+}
+
+func TestAttendees(t *testing.T) {
+	ctx := context.Background()
+	s, _ := openTestStore(t)
+
+	createdAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	attendee := &models.Attendee{Name: "Grace", Email: "grace@example.com", CreatedAt: createdAt}
+	require.NoError(t, s.CreateAttendee(ctx, attendee))
+
+	assert.Equal(t, models.StatusRegistered, attendee.Status)
+	stats, err := s.AttendeeStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, store.AttendeeStats{Registered: 1}, stats)
+
+	attendees, err := s.ListAttendees(ctx)
+	require.NoError(t, err)
+	require.Len(t, attendees, 1)
+	assert.Equal(t, attendee.ID, attendees[0].ID)
+	assert.Equal(t, "grace@example.com", attendees[0].Email)
+	assert.True(t, createdAt.Equal(attendees[0].CreatedAt))
+}
+
+func TestSpeakerAndSessionLifecycle(t *testing.T) {
+	ctx := context.Background()
+	s, _ := openTestStore(t)
+
+	speaker := &models.Speaker{Name: "Ada"}
+	require.NoError(t, s.CreateSpeaker(ctx, speaker))
+	speaker.Name = "Ada Lovelace"
+	require.NoError(t, s.UpdateSpeaker(ctx, speaker))
+
+	speakers, err := s.ListSpeakers(ctx)
+	require.NoError(t, err)
+	require.Len(t, speakers, 1)
+	assert.Equal(t, "Ada Lovelace", speakers[0].Name)
+
+	session := &models.Session{Title: "Keynote", SpeakerID: speaker.ID}
+	require.NoError(t, s.CreateSession(ctx, session))
+	require.NoError(t, s.DeleteSession(ctx, session.ID))
+	_, err = s.DeleteSpeaker(ctx, speaker.ID, false)
+	require.NoError(t, err)
+
+	sessions, err := s.ListSessions(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+	speakers, err = s.ListSpeakers(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, speakers)
+}
+
+func TestSessionSpeakers(t *testing.T) {
+	ctx := context.Background()
+	s, _ := openTestStore(t)
+
+	ada := &models.Speaker{Name: "Ada"}
+	grace := &models.Speaker{Name: "Grace"}
+	require.NoError(t, s.CreateSpeaker(ctx, ada))
+	require.NoError(t, s.CreateSpeaker(ctx, grace))
+
+	err := s.CreateSession(ctx, &models.Session{Title: "Ghost", SpeakerIDs: []string{ada.ID, "nobody"}})
+	var missing *store.MissingSpeakersError
+	require.ErrorAs(t, err, &missing)
+	assert.Equal(t, []string{"nobody"}, missing.IDs)
+
+	found, err := s.GetSpeakers(ctx, []string{grace.ID, "nobody", ada.ID})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "Grace", found[grace.ID].Name)
+
+	panel := &models.Session{Title: "Panel", SpeakerIDs: []string{ada.ID, grace.ID}}
+	require.NoError(t, s.CreateSession(ctx, panel))
+	assert.Equal(t, ada.ID, panel.SpeakerID)
+	talk := &models.Session{Title: "Talk", SpeakerID: grace.ID}
+	require.NoError(t, s.CreateSession(ctx, talk))
+	assert.Equal(t, []string{grace.ID}, talk.SpeakerIDs)
+
+	ids, err := s.DeleteSpeaker(ctx, grace.ID, false)
+	var inUse *store.SpeakerInUseError
+	require.ErrorAs(t, err, &inUse)
+	assert.ElementsMatch(t, []string{panel.ID, talk.ID}, ids)
+	speakers, err := s.ListSpeakers(ctx)
+	require.NoError(t, err)
+	assert.Len(t, speakers, 2)
+
+	ids, err = s.DeleteSpeaker(ctx, grace.ID, true)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{panel.ID, talk.ID}, ids)
+
+	sessions, err := s.ListSessions(ctx)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	for _, se := range sessions {
+		assert.False(t, se.HasSpeaker(grace.ID), se.Title)
+	}
+
+	// The join rows went with the speaker, so Ada can now be deleted once
+	// the panel is gone.
+	require.NoError(t, s.DeleteSession(ctx, panel.ID))
+	_, err = s.DeleteSpeaker(ctx, ada.ID, false)
+	require.NoError(t, err)
+}
+
+func TestSessionSpeakersBackfill(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "legacy.db")
+
+	// Sessions stored before migration 8 name one speaker, which may since
+	// have been deleted.
+	saved := migrations
+	migrations = migrations[:7]
+	legacy, err := Open(ctx, DriverSQLite, path)
+	migrations = saved
+	require.NoError(t, err)
+	now := time.Now().UTC()
+	_, err = legacy.exec(ctx, "INSERT INTO speakers (id, data, created_at, updated_at) VALUES (?, ?, ?, ?)", "ada", `{"name":"Ada"}`, now, now)
+	require.NoError(t, err)
+	for id, speaker := range map[string]string{"s1": "ada", "s2": "gone"} {
+		_, err := legacy.exec(ctx, "INSERT INTO sessions (id, data, created_at, updated_at) VALUES (?, ?, ?, ?)",
+			id, `{"title":"Talk","speakerId":"`+speaker+`"}`, now, now)
+		require.NoError(t, err)
+	}
+	require.NoError(t, legacy.Close())
+
+	s, err := Open(ctx, DriverSQLite, path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	sessions, err := s.ListSessions(ctx)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, []string{"ada"}, sessions[0].SpeakerIDs)
+	assert.Empty(t, sessions[1].SpeakerID, "dangling speakers are dropped")
+
+	_, err = s.DeleteSpeaker(ctx, "ada", false)
+	var inUse *store.SpeakerInUseError
+	require.ErrorAs(t, err, &inUse)
+	assert.Equal(t, []string{"s1"}, inUse.SessionIDs)
+}
+
+func TestRoomDoubleBooking(t *testing.T) {
+	ctx := context.Background()
+	s, _ := openTestStore(t)
+
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	keynote := &models.Session{Title: "Keynote", StartsAt: start, EndsAt: start.Add(time.Hour), Room: "Hall A"}
+	require.NoError(t, s.CreateSession(ctx, keynote))
+
+	clash := &models.Session{Title: "Clash", StartsAt: start.Add(30 * time.Minute), EndsAt: start.Add(90 * time.Minute), Room: "hall a"}
+	var conflict *store.RoomConflictError
+	require.ErrorAs(t, s.CreateSession(ctx, clash), &conflict)
+	assert.Equal(t, keynote.ID, conflict.SessionID)
+
+	clash.Room = "Room 2"
+	require.NoError(t, s.CreateSession(ctx, clash))
+	clash.Room = "Hall A"
+	require.ErrorAs(t, s.UpdateSession(ctx, clash), &conflict)
+
+	keynote.EndsAt = start.Add(30 * time.Minute)
+	require.NoError(t, s.UpdateSession(ctx, keynote))
+	require.NoError(t, s.UpdateSession(ctx, clash), "back-to-back sessions share a room")
+}
+
+func TestUpdateMissingDocument(t *testing.T) {
+	s, _ := openTestStore(t)
+
+	err := s.UpdateSession(context.Background(), &models.Session{ID: "missing", Title: "Nothing"})
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestCreateAttendeeRejectsDuplicateEmail(t *testing.T) {
+	ctx := context.Background()
+	s, _ := openTestStore(t)
+
+	first := &models.Attendee{Name: "Grace", Email: "grace@example.com"}
+	require.NoError(t, s.CreateAttendee(ctx, first))
+
+	err := s.CreateAttendee(ctx, &models.Attendee{Name: "Grace", Email: " Grace@Example.com"})
+	var dup *store.DuplicateError
+	require.ErrorAs(t, err, &dup)
+	assert.Equal(t, first.ID, dup.ExistingID)
+}
+
+func TestEmailBackfillAndMerge(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "legacy.db")
+
+	// Build a database at schema version 1 holding duplicate registrations.
+	saved := migrations
+	migrations = migrations[:1]
+	legacy, err := Open(ctx, DriverSQLite, path)
+	migrations = saved
+	require.NoError(t, err)
+	for i, row := range []struct{ id, email string }{{"a1", "grace@example.com"}, {"a2", "GRACE@example.com"}} {
+		createdAt := time.Date(2025, 1, i+1, 0, 0, 0, 0, time.UTC)
+		_, err := legacy.exec(ctx, "INSERT INTO attendees (id, data, created_at, updated_at) VALUES (?, ?, ?, ?)",
+			row.id, `{"name":"Grace","email":"`+row.email+`"}`, createdAt, createdAt)
+		require.NoError(t, err)
+	}
+	require.NoError(t, legacy.Close())
+
+	s, err := Open(ctx, DriverSQLite, path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	id, err := s.attendeeIDByEmail(ctx, s.db, "grace@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "a1", id, "earliest registration claims the email")
+
+	attendees, err := s.PageAttendees(ctx, store.ListOptions{Limit: 10, Filters: map[string]string{"status": models.StatusRegistered}})
+	require.NoError(t, err)
+	assert.Len(t, attendees.Items, 2, "legacy registrations are backfilled as registered")
+
+	keep := &models.Attendee{ID: "a1", Name: "Grace Hopper", Email: "grace@example.com", Status: models.StatusRegistered}
+	_, err = s.MergeAttendees(ctx, keep, []string{"a2"})
+	require.NoError(t, err)
+
+	merged, err := s.ListAttendees(ctx)
+	require.NoError(t, err)
+	require.Len(t, merged, 1)
+	assert.Equal(t, "Grace Hopper", merged[0].Name)
+}
+
+func TestUpdateAttendee(t *testing.T) {
+	ctx := context.Background()
+	s, _ := openTestStore(t)
+
+	grace := &models.Attendee{Name: "Grace", Email: "grace@example.com"}
+	ada := &models.Attendee{Name: "Ada", Email: "ada@example.com"}
+	require.NoError(t, s.CreateAttendee(ctx, grace))
+	require.NoError(t, s.CreateAttendee(ctx, ada))
+
+	update := &models.Attendee{ID: grace.ID, Name: "Grace Hopper", Email: "hopper@example.com", Status: models.StatusCancelled}
+	require.NoError(t, s.UpdateAttendee(ctx, update))
+	assert.Equal(t, models.StatusRegistered, update.Status, "status is not editable")
+	id, err := s.attendeeIDByEmail(ctx, s.db, "hopper@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, grace.ID, id)
+
+	var dup *store.DuplicateError
+	err = s.UpdateAttendee(ctx, &models.Attendee{ID: grace.ID, Email: "ADA@example.com"})
+	require.ErrorAs(t, err, &dup)
+	assert.Equal(t, ada.ID, dup.ExistingID)
+
+	_, _, err = s.CancelAttendee(ctx, grace.ID)
+	require.NoError(t, err)
+	assert.ErrorIs(t, s.UpdateAttendee(ctx, &models.Attendee{ID: grace.ID, Email: "hopper@example.com"}), store.ErrCancelled)
+	assert.ErrorIs(t, s.UpdateAttendee(ctx, &models.Attendee{ID: "missing"}), store.ErrNotFound)
+}
+
+func TestCheckInAttendee(t *testing.T) {
+	ctx := context.Background()
+	s, _ := openTestStore(t)
+
+	_, err := s.UpdateEventSettings(ctx, &models.EventSettings{Capacity: 1})
+	require.NoError(t, err)
+	ada := &models.Attendee{Name: "Ada", Email: "ada@example.com"}
+	bob := &models.Attendee{Name: "Bob", Email: "bob@example.com"}
+	require.NoError(t, s.CreateAttendee(ctx, ada))
+	require.NoError(t, s.CreateAttendee(ctx, bob))
+
+	checkedIn, err := s.CheckInAttendee(ctx, ada.ID, "door1")
+	require.NoError(t, err)
+	require.NotNil(t, checkedIn.CheckedInAt)
+	assert.Equal(t, "door1", checkedIn.CheckedInBy)
+
+	again, err := s.CheckInAttendee(ctx, ada.ID, "door2")
+	assert.ErrorIs(t, err, store.ErrCheckedIn)
+	assert.Equal(t, "door1", again.CheckedInBy, "the first check-in is kept")
+
+	// Editing the registration keeps the check-in and the email key.
+	require.NoError(t, s.UpdateAttendee(ctx, &models.Attendee{ID: ada.ID, Name: "Ada Lovelace", Email: "ada@example.com"}))
+	got, err := s.GetAttendee(ctx, ada.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, got.CheckedInAt)
+	var dup *store.DuplicateError
+	assert.ErrorAs(t, s.CreateAttendee(ctx, &models.Attendee{Name: "A", Email: "ADA@example.com"}), &dup)
+
+	_, err = s.CheckInAttendee(ctx, bob.ID, "door1")
+	assert.ErrorIs(t, err, store.ErrNoSeat)
+	_, err = s.CheckInAttendee(ctx, "missing", "door1")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestEnrollments(t *testing.T) {
+	ctx := context.Background()
+	s, _ := openTestStore(t)
+
+	ada := &models.Attendee{Name: "Ada", Email: "ada@example.com"}
+	bob := &models.Attendee{Name: "Bob", Email: "bob@example.com"}
+	require.NoError(t, s.CreateAttendee(ctx, ada))
+	require.NoError(t, s.CreateAttendee(ctx, bob))
+	workshop := &models.Session{Title: "Workshop", Date: "2030-05-10", Time: "10:00", Duration: 90, Capacity: 1}
+	talk := &models.Session{Title: "Talk", Date: "2030-05-10", Time: "11:00"}
+	require.NoError(t, s.CreateSession(ctx, workshop))
+	require.NoError(t, s.CreateSession(ctx, talk))
+
+	first, err := s.Enroll(ctx, workshop.ID, ada.ID)
+	require.NoError(t, err)
+	again, err := s.Enroll(ctx, workshop.ID, ada.ID)
+	require.NoError(t, err)
+	assert.True(t, first.CreatedAt.Equal(again.CreatedAt), "enrolling twice keeps the first enrollment")
+
+	_, err = s.Enroll(ctx, workshop.ID, bob.ID)
+	assert.ErrorIs(t, err, store.ErrSessionFull)
+	var conflict *store.ConflictError
+	_, err = s.Enroll(ctx, talk.ID, ada.ID)
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, workshop.ID, conflict.SessionID)
+	_, err = s.Enroll(ctx, "missing", ada.ID)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+	_, err = s.Enroll(ctx, talk.ID, bob.ID)
+	require.NoError(t, err)
+
+	counts, err := s.EnrollmentCounts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{workshop.ID: 1, talk.ID: 1}, counts)
+	list, err := s.ListEnrollments(ctx, store.EnrollmentFilter{AttendeeID: bob.ID})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, talk.ID, list[0].SessionID)
+
+	require.NoError(t, s.Unenroll(ctx, workshop.ID, ada.ID))
+	_, err = s.Enroll(ctx, workshop.ID, bob.ID)
+	require.ErrorAs(t, err, &conflict, "Bob is in the talk")
+	require.NoError(t, s.DeleteEnrollments(ctx, store.EnrollmentFilter{}), "an empty filter deletes nothing")
+	require.NoError(t, s.DeleteEnrollments(ctx, store.EnrollmentFilter{SessionID: talk.ID}))
+	_, err = s.Enroll(ctx, workshop.ID, bob.ID)
+	require.NoError(t, err)
+	list, err = s.ListEnrollments(ctx, store.EnrollmentFilter{})
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	_, _, err = s.CancelAttendee(ctx, ada.ID)
+	require.NoError(t, err)
+	_, err = s.Enroll(ctx, talk.ID, ada.ID)
+	assert.ErrorIs(t, err, store.ErrNoSeat)
+}
+
+func TestUpdateReminder(t *testing.T) {
+	ctx := context.Background()
+	s, _ := openTestStore(t)
+
+	due := time.Date(2030, 5, 9, 10, 0, 0, 0, time.UTC)
+	created, err := s.UpdateReminder(ctx, "s1-24h", func(r *models.Reminder) error {
+		assert.True(t, r.CreatedAt.IsZero(), "a new reminder starts empty")
+		r.SessionID, r.Lead, r.DueAt, r.Status, r.CreatedAt = "s1", "24h", due, models.ReminderPending, due
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "s1-24h", created.ID)
+
+	// A failing update writes nothing, not even the placeholder row.
+	_, err = s.UpdateReminder(ctx, "s1-1h", func(r *models.Reminder) error { return store.ErrNotFound })
+	assert.ErrorIs(t, err, store.ErrNotFound)
+	_, err = s.GetReminder(ctx, "s1-1h")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	_, err = s.UpdateReminder(ctx, "s1-24h", func(r *models.Reminder) error {
+		assert.Equal(t, models.ReminderPending, r.Status)
+		r.Status = models.ReminderSent
+		return nil
+	})
+	require.NoError(t, err)
+
+	list, err := s.ListReminders(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, models.ReminderSent, list[0].Status)
+	assert.True(t, due.Equal(list[0].DueAt))
+}
+
+func TestCapacityAndWaitlist(t *testing.T) {
+	ctx := context.Background()
+	s, _ := openTestStore(t)
+
+	_, err := s.UpdateEventSettings(ctx, &models.EventSettings{Capacity: 1})
+	require.NoError(t, err)
+
+	base := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	var attendees []*models.Attendee
+	for i, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		a := &models.Attendee{Name: "A", Email: email, CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+		require.NoError(t, s.CreateAttendee(ctx, a))
+		attendees = append(attendees, a)
+	}
+	assert.Equal(t, models.StatusRegistered, attendees[0].Status)
+	assert.Equal(t, models.StatusWaitlisted, attendees[1].Status)
+	assert.Equal(t, 1, attendees[1].WaitlistPosition)
+	assert.Equal(t, 2, attendees[2].WaitlistPosition)
+
+	cancelled, promoted, err := s.CancelAttendee(ctx, attendees[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusCancelled, cancelled.Status)
+	require.Len(t, promoted, 1)
+	assert.Equal(t, attendees[1].ID, promoted[0].ID)
+
+	// The cancelled email is free again and joins the back of the waitlist.
+	again := &models.Attendee{Name: "A", Email: "a@example.com", CreatedAt: base.Add(time.Hour)}
+	require.NoError(t, s.CreateAttendee(ctx, again))
+	assert.Equal(t, 2, again.WaitlistPosition)
+
+	promoted, err = s.UpdateEventSettings(ctx, &models.EventSettings{Capacity: 5})
+	require.NoError(t, err)
+	require.Len(t, promoted, 2)
+	assert.Equal(t, attendees[2].ID, promoted[0].ID)
+	assert.Equal(t, again.ID, promoted[1].ID)
+
+	stats, err := s.AttendeeStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, store.AttendeeStats{Registered: 3, Cancelled: 1}, stats)
+	waitlist, err := s.Waitlist(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, waitlist)
+}
+
+func TestAdmins(t *testing.T) {
+	ctx := context.Background()
+	s, path := openTestStore(t)
+
+	changed := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	admin := &models.Admin{Username: "alice", PasswordHash: "hash", Role: models.RoleOrganizer, CreatedAt: changed, PasswordChangedAt: changed}
+	require.NoError(t, s.CreateAdmin(ctx, admin))
+
+	var dup *store.DuplicateError
+	assert.ErrorAs(t, s.CreateAdmin(ctx, admin), &dup)
+
+	admin.Disabled = true
+	admin.PasswordHash = "rotated"
+	admin.Role = models.RoleViewer
+	require.NoError(t, s.UpdateAdmin(ctx, admin))
+	assert.ErrorIs(t, s.UpdateAdmin(ctx, &models.Admin{Username: "nobody"}), store.ErrNotFound)
+
+	require.NoError(t, s.Close())
+	s, err := Open(ctx, DriverSQLite, path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	got, err := s.GetAdmin(ctx, "alice")
+	require.NoError(t, err)
+	assert.True(t, got.Disabled)
+	assert.Equal(t, "rotated", got.PasswordHash)
+	assert.Equal(t, models.RoleViewer, got.Role)
+	assert.True(t, changed.Equal(got.PasswordChangedAt))
+
+	_, err = s.GetAdmin(ctx, "nobody")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	list, err := s.ListAdmins(ctx)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+func TestPageAttendees(t *testing.T) {
+	ctx := context.Background()
+	s, _ := openTestStore(t)
+
+	base := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i, a := range []models.Attendee{
+		{Name: "Carol", Email: "carol@example.com", Designation: "Engineer"},
+		{Name: "Alice", Email: "alice@example.com", Designation: "Manager"},
+		{Name: "Bob", Email: "bob@example.com", Designation: "Engineer"},
+		{Name: "Dave", Email: "dave@example.com", Designation: "Engineer"},
+	} {
+		// Mix whole and fractional seconds, which sort differently as text
+		a.CreatedAt = base.Add(time.Duration(i) * 500 * time.Millisecond)
+		require.NoError(t, s.CreateAttendee(ctx, &a))
+	}
+
+	collect := func(opts store.ListOptions) []string {
+		var names []string
+		for {
+			page, err := s.PageAttendees(ctx, opts)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(page.Items), opts.Limit)
+			for _, a := range page.Items {
+				names = append(names, a.Name)
+			}
+			if page.NextPageToken == "" {
+				return names
+			}
+			opts.PageToken = page.NextPageToken
+		}
+	}
+
+	assert.Equal(t, []string{"Alice", "Bob", "Carol", "Dave"}, collect(store.ListOptions{Limit: 3, OrderBy: "name"}))
+	assert.Equal(t, []string{"Dave", "Bob", "Alice", "Carol"}, collect(store.ListOptions{Limit: 1, OrderBy: "createdAt", Descending: true}))
+	assert.Equal(t, []string{"Carol", "Bob", "Dave"}, collect(store.ListOptions{Limit: 2, OrderBy: "createdAt", Filters: map[string]string{"designation": "Engineer"}}))
+	assert.Len(t, collect(store.ListOptions{Limit: 2}), 4)
+
+	_, err := s.PageAttendees(ctx, store.ListOptions{Limit: 1, OrderBy: "data); DROP TABLE attendees; --"})
+	assert.Error(t, err)
+}
+
+func TestWorkshops(t *testing.T) {
+	ctx := context.Background()
+	s, _ := openTestStore(t)
+
+	require.NoError(t, s.CreateWorkshop(ctx, &models.Workshop{ID: "spring", Name: "Spring"}))
+	var dup *store.DuplicateError
+	assert.ErrorAs(t, s.CreateWorkshop(ctx, &models.Workshop{ID: "spring", Name: "Again"}), &dup)
+
+	// The same email may register once in every workshop.
+	spring := s.Scoped("spring").Attendees
+	require.NoError(t, s.CreateAttendee(ctx, &models.Attendee{Name: "Grace", Email: "grace@example.com"}))
+	require.NoError(t, spring.CreateAttendee(ctx, &models.Attendee{Name: "Grace", Email: "grace@example.com"}))
+	require.NoError(t, spring.CreateAttendee(ctx, &models.Attendee{Name: "Ada", Email: "ada@example.com"}))
+	_, err := spring.UpdateEventSettings(ctx, &models.EventSettings{Capacity: 5})
+	require.NoError(t, err)
+
+	stats, err := s.AttendeeStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Registered)
+	settings, err := s.EventSettings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, settings.Capacity)
+	stats, err = spring.AttendeeStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Registered)
+
+	require.NoError(t, s.DeleteWorkshop(ctx, "spring"))
+	_, err = s.GetWorkshop(ctx, "spring")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+	attendees, err := spring.ListAttendees(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, attendees)
+	stats, err = s.AttendeeStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Registered)
+}
+
+func TestCloneWorkshop(t *testing.T) {
+	ctx := context.Background()
+	s, _ := openTestStore(t)
+
+	speaker := &models.Speaker{ID: store.NewID(), Name: "Ada"}
+	startsAt := time.Date(2027, 3, 1, 10, 0, 0, 0, time.UTC)
+	sessions := []*models.Session{
+		{ID: store.NewID(), Title: "Keynote", SpeakerIDs: []string{speaker.ID}, StartsAt: startsAt, EndsAt: startsAt.Add(time.Hour), Room: "Hall"},
+		{ID: store.NewID(), Title: "Panel", StartsAt: startsAt.Add(time.Hour), EndsAt: startsAt.Add(2 * time.Hour), Room: "Hall"},
+	}
+	require.NoError(t, s.CloneWorkshop(ctx, &models.Workshop{ID: "spring", Name: "Spring"}, []*models.Speaker{speaker}, sessions))
+
+	spring := s.Scoped("spring")
+	cloned, err := spring.Sessions.ListSessions(ctx)
+	require.NoError(t, err)
+	assert.Len(t, cloned, 2)
+	_, err = spring.Speakers.DeleteSpeaker(ctx, speaker.ID, false)
+	var inUse *store.SpeakerInUseError
+	assert.ErrorAs(t, err, &inUse, "session_speakers rows are written for the clone")
+
+	var dup *store.DuplicateError
+	assert.ErrorAs(t, s.CloneWorkshop(ctx, &models.Workshop{ID: "spring", Name: "Again"}, nil, nil), &dup)
+
+	// A failed clone leaves nothing behind.
+	missing := []*models.Session{{ID: store.NewID(), Title: "Orphan", SpeakerIDs: []string{"missing"}}}
+	var missingErr *store.MissingSpeakersError
+	assert.ErrorAs(t, s.CloneWorkshop(ctx, &models.Workshop{ID: "summer", Name: "Summer"}, nil, missing), &missingErr)
+	_, err = s.GetWorkshop(ctx, "summer")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestImports(t *testing.T) {
+	ctx := context.Background()
+	s, _ := openTestStore(t)
+
+	speakers := make([]*models.Speaker, store.ImportBatchSize+1)
+	for i := range speakers {
+		speakers[i] = &models.Speaker{ID: store.NewID(), Name: "Speaker"}
+	}
+	n, err := s.ImportSpeakers(ctx, speakers)
+	require.NoError(t, err)
+	assert.Equal(t, len(speakers), n, "stored in two batches")
+
+	startsAt := time.Date(2027, 3, 1, 10, 0, 0, 0, time.UTC)
+	sessions := []*models.Session{{ID: "keynote", Title: "Keynote", SpeakerIDs: []string{speakers[0].ID}, StartsAt: startsAt, EndsAt: startsAt.Add(time.Hour), Room: "Hall"}}
+	n, err = s.ImportSessions(ctx, sessions)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	_, err = s.DeleteSpeaker(ctx, speakers[0].ID, false)
+	var inUse *store.SpeakerInUseError
+	assert.ErrorAs(t, err, &inUse, "session_speakers rows are written for imported sessions")
+
+	// IDs are primary keys, so another workshop cannot reuse them.
+	taken, err := s.Scoped("spring").Imports.IDsInUse(ctx, "sessions", []string{"keynote", "panel"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"keynote": true}, taken)
+
+	_, err = s.UpdateEventSettings(ctx, &models.EventSettings{Capacity: 1})
+	require.NoError(t, err)
+	attendees := []*models.Attendee{
+		{ID: "a1", Name: "A", Email: "a@example.com", Designation: "E", CreatedAt: startsAt},
+		{ID: "b1", Name: "B", Email: "b@example.com", Designation: "E", CreatedAt: startsAt.Add(time.Minute)},
+	}
+	n, err = s.ImportAttendees(ctx, attendees)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, models.StatusRegistered, attendees[0].Status)
+	assert.Equal(t, models.StatusWaitlisted, attendees[1].Status)
+	assert.Equal(t, 1, attendees[1].WaitlistPosition)
+
+	n, err = s.ImportAttendees(ctx, []*models.Attendee{{ID: "c1", Name: "C", Email: "c@example.com", Designation: "E"}, {ID: "a2", Name: "A", Email: "A@example.com", Designation: "E"}})
+	var dup *store.DuplicateError
+	require.ErrorAs(t, err, &dup)
+	assert.Equal(t, "a1", dup.ExistingID)
+	assert.Zero(t, n)
+	_, err = s.GetAttendee(ctx, "c1")
+	assert.ErrorIs(t, err, store.ErrNotFound, "the failed batch is rolled back")
+}
@@ -0,0 +1,208 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"appdirect-workshop/internal/models"
+	"appdirect-workshop/internal/store"
+)
+
+// workshopTables are the tables holding data of a workshop, in an order
+// that deletes rows before the rows they reference.
+var workshopTables = []string{"enrollments", "reminders", "sessions", "speakers", "attendees", "event_settings"}
+
+func (s *Store) ListWorkshops(ctx context.Context) ([]*models.Workshop, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, data, created_at, updated_at FROM workshops ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*models.Workshop
+	for rows.Next() {
+		w, err := scanWorkshop(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) GetWorkshop(ctx context.Context, id string) (*models.Workshop, error) {
+	w, err := scanWorkshop(s.db.QueryRowContext(ctx, s.rebind("SELECT id, data, created_at, updated_at FROM workshops WHERE id = ?"), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, store.ErrNotFound
+	}
+	return w, err
+}
+
+// scanWorkshop decodes a row of id, data, created_at and updated_at.
+func scanWorkshop(row interface{ Scan(...interface{}) error }) (*models.Workshop, error) {
+	var id, raw string
+	var created, updated time.Time
+	if err := row.Scan(&id, &raw, &created, &updated); err != nil {
+		return nil, err
+	}
+	var w models.Workshop
+	if err := json.Unmarshal([]byte(raw), &w); err != nil {
+		return nil, fmt.Errorf("decoding workshops/%s: %w", id, err)
+	}
+	w.ID, w.CreatedAt, w.UpdatedAt = id, created.UTC(), updated.UTC()
+	return &w, nil
+}
+
+// CreateWorkshop also gives the workshop its event settings row.
+func (s *Store) CreateWorkshop(ctx context.Context, workshop *models.Workshop) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now, err := s.createWorkshop(ctx, tx, workshop)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	workshop.CreatedAt, workshop.UpdatedAt = now, now
+	return nil
+}
+
+// createWorkshop inserts the workshop row and its event settings, returning
+// the creation time.
+func (s *Store) createWorkshop(ctx context.Context, tx *sql.Tx, workshop *models.Workshop) (time.Time, error) {
+	raw, err := json.Marshal(workshop)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var existing string
+	err = tx.QueryRowContext(ctx, s.rebind("SELECT id FROM workshops WHERE id = ?"), workshop.ID).Scan(&existing)
+	if err == nil {
+		return time.Time{}, &store.DuplicateError{ExistingID: existing}
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, err
+	}
+
+	now := time.Now().UTC()
+	if _, err := tx.ExecContext(ctx, s.rebind("INSERT INTO workshops (id, data, created_at, updated_at) VALUES (?, ?, ?, ?)"),
+		workshop.ID, string(raw), now, now); err != nil {
+		return time.Time{}, err
+	}
+	if _, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO event_settings (id, capacity, workshop_id)
+		SELECT COALESCE(MAX(id), 0) + 1, 0, ? FROM event_settings
+		WHERE NOT EXISTS (SELECT 1 FROM event_settings WHERE workshop_id = ?)`), workshop.ID, workshop.ID); err != nil {
+		return time.Time{}, err
+	}
+	return now, nil
+}
+
+// CloneWorkshop writes the workshop, its speakers and its sessions in one
+// transaction.
+func (s *Store) CloneWorkshop(ctx context.Context, workshop *models.Workshop, speakers []*models.Speaker, sessions []*models.Session) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now, err := s.createWorkshop(ctx, tx, workshop)
+	if err != nil {
+		return err
+	}
+	clone := &Store{db: s.db, driver: s.driver, workshop: workshop.ID}
+	for _, sp := range speakers {
+		if err := clone.insertWithID(ctx, tx, "speakers", sp.ID, sp, now); err != nil {
+			return err
+		}
+	}
+	for _, se := range sessions {
+		se.SyncSpeakers()
+		if err := clone.checkRoom(ctx, tx, se); err != nil {
+			return err
+		}
+		if err := clone.insertWithID(ctx, tx, "sessions", se.ID, se, now); err != nil {
+			return err
+		}
+		if err := clone.writeSessionSpeakers(ctx, tx, se.ID, se.SpeakerIDs); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	workshop.CreatedAt, workshop.UpdatedAt = now, now
+	return nil
+}
+
+// insertWithID inserts a document of the workshop under a given ID.
+func (s *Store) insertWithID(ctx context.Context, tx *sql.Tx, table, id string, data interface{}, now time.Time) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, s.rebind(fmt.Sprintf("INSERT INTO %s (id, workshop_id, data, created_at, updated_at) VALUES (?, ?, ?, ?, ?)", table)),
+		id, s.workshop, string(raw), now, now)
+	return err
+}
+
+func (s *Store) UpdateWorkshop(ctx context.Context, workshop *models.Workshop) error {
+	stored, err := s.GetWorkshop(ctx, workshop.ID)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(workshop)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	if _, err := s.exec(ctx, "UPDATE workshops SET data = ?, updated_at = ? WHERE id = ?", string(raw), now, workshop.ID); err != nil {
+		return err
+	}
+	workshop.CreatedAt, workshop.UpdatedAt = stored.CreatedAt, now
+	return nil
+}
+
+func (s *Store) DeleteWorkshop(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// session_speakers rows go with their sessions.
+	for _, table := range workshopTables {
+		if _, err := tx.ExecContext(ctx, s.rebind("DELETE FROM "+table+" WHERE workshop_id = ?"), id); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, s.rebind("DELETE FROM workshops WHERE id = ?"), id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Scoped returns a Store sharing the database connection whose queries only
+// see the rows of the workshop.
+func (s *Store) Scoped(id string) store.Stores {
+	scoped := &Store{db: s.db, driver: s.driver, workshop: id}
+	return store.Stores{
+		Attendees:   scoped,
+		Speakers:    scoped,
+		Sessions:    scoped,
+		Admins:      s,
+		Reminders:   scoped,
+		Enrollments: scoped,
+		Workshops:   s,
+		Imports:     scoped,
+	}
+}
@@ -0,0 +1,152 @@
+// Package storage opens the storage backend selected through the environment
+// so the server and the command line tools share one configuration.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"appdirect-workshop/internal/firestore"
+	"appdirect-workshop/internal/memory"
+	"appdirect-workshop/internal/sqlstore"
+	"appdirect-workshop/internal/store"
+)
+
+// Open initializes the storage backend selected by STORAGE_BACKEND
+// ("firestore" by default, "memory", "sqlite" or "postgres") and returns the
+// stores together with a function that releases the backend on shutdown.
+func Open(ctx context.Context) (store.Stores, func(), error) {
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		backend = "firestore"
+	}
+
+	switch backend {
+	case "firestore":
+		return openFirestore(ctx)
+	case "memory":
+		return openMemory()
+	case "sqlite":
+		path := os.Getenv("SQLITE_PATH")
+		if path == "" {
+			path = "workshop.db"
+		}
+		log.Printf("Using SQLite storage: %s", path)
+		return openSQL(ctx, sqlstore.DriverSQLite, path)
+	case "postgres":
+		dsn := os.Getenv("DATABASE_URL")
+		if dsn == "" {
+			return store.Stores{}, nil, fmt.Errorf("DATABASE_URL is required for STORAGE_BACKEND=postgres")
+		}
+		log.Println("Using Postgres storage")
+		return openSQL(ctx, sqlstore.DriverPostgres, dsn)
+	default:
+		return store.Stores{}, nil, fmt.Errorf("unknown STORAGE_BACKEND %q", backend)
+	}
+}
+
+func openFirestore(ctx context.Context) (store.Stores, func(), error) {
+	projectID := os.Getenv("FIREBASE_PROJECT_ID")
+	serviceAccountPath := os.Getenv("FIREBASE_SERVICE_ACCOUNT_PATH")
+
+	// For Cloud Run, use Application Default Credentials (ADC)
+	// Set FIREBASE_SERVICE_ACCOUNT_PATH=ADC or leave empty in Cloud Run
+	// For local development, use service account file path
+	if serviceAccountPath == "" && os.Getenv("K_SERVICE") != "" {
+		// Running in Cloud Run, use ADC
+		serviceAccountPath = "ADC"
+	}
+
+	databaseID := os.Getenv("FIRESTORE_DATABASE_ID")
+	log.Printf("DEBUG: FIRESTORE_DATABASE_ID is: '%s'", databaseID)
+	fsClient, err := firestore.NewClient(ctx, projectID, databaseID, serviceAccountPath)
+	if err != nil {
+		return store.Stores{}, nil, fmt.Errorf("failed to initialize Firestore: %w", err)
+	}
+
+	// Registrations from before the email index existed are indexed before
+	// any request checks an address against it. Workshops other than the
+	// default one postdate the index.
+	n, err := fsClient.BackfillEmailIndex(ctx)
+	if err != nil {
+		fsClient.Close()
+		return store.Stores{}, nil, fmt.Errorf("failed to backfill the attendee email index: %w", err)
+	}
+	if n > 0 {
+		log.Printf("Backfilled %d attendee email index entries", n)
+	}
+
+	stores := store.Stores{
+		Attendees:   fsClient,
+		Speakers:    fsClient,
+		Sessions:    fsClient,
+		Admins:      fsClient,
+		Reminders:   fsClient,
+		Enrollments: fsClient,
+		Workshops:   fsClient,
+		Imports:     fsClient,
+	}
+	return stores, func() { fsClient.Close() }, nil
+}
+
+// openMemory returns an in-memory store. When MEMORY_SNAPSHOT_PATH is set the
+// snapshot is loaded on start and written back on shutdown.
+func openMemory() (store.Stores, func(), error) {
+	mem := memory.New()
+	snapshotPath := os.Getenv("MEMORY_SNAPSHOT_PATH")
+
+	if snapshotPath != "" {
+		if err := mem.Load(snapshotPath); err != nil {
+			return store.Stores{}, nil, fmt.Errorf("failed to load memory snapshot: %w", err)
+		}
+		log.Printf("Using in-memory storage with snapshot: %s", snapshotPath)
+	} else {
+		log.Println("Using in-memory storage (data is lost on shutdown)")
+	}
+
+	closeFn := func() {
+		if snapshotPath == "" {
+			return
+		}
+		if err := mem.Save(snapshotPath); err != nil {
+			log.Printf("Failed to save memory snapshot: %v", err)
+			return
+		}
+		log.Printf("Saved memory snapshot to %s", snapshotPath)
+	}
+
+	stores := store.Stores{
+		Attendees:   mem,
+		Speakers:    mem,
+		Sessions:    mem,
+		Admins:      mem,
+		Reminders:   mem,
+		Enrollments: mem,
+		Workshops:   mem,
+		Imports:     mem,
+	}
+	return stores, closeFn, nil
+}
+
+// openSQL connects to a SQL database and migrates its schema to the latest
+// version before any request is served.
+func openSQL(ctx context.Context, driver, dsn string) (store.Stores, func(), error) {
+	db, err := sqlstore.Open(ctx, driver, dsn)
+	if err != nil {
+		return store.Stores{}, nil, fmt.Errorf("failed to open SQL storage: %w", err)
+	}
+
+	stores := store.Stores{
+		Attendees:   db,
+		Speakers:    db,
+		Sessions:    db,
+		Admins:      db,
+		Reminders:   db,
+		Enrollments: db,
+		Workshops:   db,
+		Imports:     db,
+	}
+	return stores, func() { db.Close() }, nil
+}
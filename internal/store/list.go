@@ -0,0 +1,112 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrInvalidPageToken is returned for page tokens that are malformed or were
+// issued for a different ordering.
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// FieldKind says how a field used for ordering compares.
+type FieldKind int
+
+const (
+	StringField FieldKind = iota
+	TimeField
+)
+
+// Fields that list queries may order or filter by, keyed by their JSON name.
+// Filters are exact matches and only apply to string fields.
+var (
+	AttendeeFields = map[string]FieldKind{
+		"createdAt":   TimeField,
+		"name":        StringField,
+		"email":       StringField,
+		"designation": StringField,
+		"status":      StringField,
+	}
+	SpeakerFields = map[string]FieldKind{
+		"name": StringField,
+	}
+	SessionFields = map[string]FieldKind{
+		"title":     StringField,
+		"date":      StringField,
+		"time":      StringField,
+		"speakerId": StringField,
+		"room":      StringField,
+		"track":     StringField,
+	}
+)
+
+// ListOptions selects one page of a collection. Results are ordered by
+// OrderBy and then by ID, so every document has a stable position.
+type ListOptions struct {
+	// Limit is the maximum number of items returned; it must be positive.
+	Limit int
+	// PageToken continues after the last item of a previous page.
+	PageToken string
+	// OrderBy is a JSON field name, or empty to order by ID only.
+	OrderBy    string
+	Descending bool
+	// Filters maps JSON field names to values that must match exactly.
+	Filters map[string]string
+}
+
+// Page is one page of results. NextPageToken is empty on the last page.
+type Page[T any] struct {
+	Items         []*T   `json:"items"`
+	NextPageToken string `json:"nextPageToken,omitempty"`
+}
+
+// Cursor is the position after the last item of a page. Value is the
+// item's OrderBy field, formatted with FormatTime for time fields.
+type Cursor struct {
+	OrderBy    string `json:"o,omitempty"`
+	Descending bool   `json:"d,omitempty"`
+	Value      string `json:"v,omitempty"`
+	ID         string `json:"i"`
+}
+
+// cursorTimeLayout has a fixed width so formatted times sort as strings.
+const cursorTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// FormatTime formats t for a Cursor value or an in-memory comparison.
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(cursorTimeLayout)
+}
+
+// ParseTime parses a value produced by FormatTime.
+func ParseTime(v string) (time.Time, error) {
+	return time.Parse(cursorTimeLayout, v)
+}
+
+// EncodeCursor returns the opaque page token for c.
+func EncodeCursor(c Cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor returns the cursor in opts.PageToken, or nil if there is none.
+// Tokens issued for a different ordering are rejected.
+func DecodeCursor(opts ListOptions) (*Cursor, error) {
+	if opts.PageToken == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(opts.PageToken)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidPageToken
+	}
+	if c.OrderBy != opts.OrderBy || c.Descending != opts.Descending {
+		return nil, ErrInvalidPageToken
+	}
+	return &c, nil
+}
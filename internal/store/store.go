@@ -0,0 +1,385 @@
+// Package store defines the storage interfaces the HTTP handlers depend on.
+// Backends (Firestore, in-memory, SQL) implement these so handlers never
+// touch a concrete database client.
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"appdirect-workshop/internal/models"
+)
+
+// ErrNotFound is returned when a document with the requested ID does not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrCancelled is returned when changing a registration that was cancelled.
+var ErrCancelled = errors.New("registration cancelled")
+
+// ErrNoSeat is returned when checking in an attendee who is waitlisted or
+// cancelled.
+var ErrNoSeat = errors.New("registration does not hold a seat")
+
+// ErrCheckedIn is returned when checking in an attendee a second time.
+var ErrCheckedIn = errors.New("already checked in")
+
+// ErrSessionFull is returned when enrolling in a session whose capacity is
+// taken.
+var ErrSessionFull = errors.New("session is full")
+
+// ConflictError is returned when enrolling an attendee in a session that
+// overlaps one they are already enrolled in.
+type ConflictError struct {
+	SessionID string
+}
+
+func (e *ConflictError) Error() string {
+	return "overlaps session " + e.SessionID
+}
+
+// RoomConflictError is returned when a session is booked into a room that
+// another session occupies at the same time.
+type RoomConflictError struct {
+	Room      string
+	SessionID string
+}
+
+func (e *RoomConflictError) Error() string {
+	return "room " + e.Room + " is booked by session " + e.SessionID
+}
+
+// MissingSpeakersError is returned when a session names speakers that do
+// not exist.
+type MissingSpeakersError struct {
+	IDs []string
+}
+
+func (e *MissingSpeakersError) Error() string {
+	return "unknown speakers " + strings.Join(e.IDs, ", ")
+}
+
+// SpeakerInUseError is returned when deleting a speaker that sessions still
+// name, without cascading.
+type SpeakerInUseError struct {
+	SessionIDs []string
+}
+
+func (e *SpeakerInUseError) Error() string {
+	return "speaker is assigned to sessions " + strings.Join(e.SessionIDs, ", ")
+}
+
+// DuplicateError is returned when a write would create a second document for
+// a key that must be unique, such as an attendee's normalized email.
+type DuplicateError struct {
+	ExistingID string
+}
+
+func (e *DuplicateError) Error() string {
+	return "duplicate of " + e.ExistingID
+}
+
+// Stores groups the storage interfaces a backend provides.
+type Stores struct {
+	Attendees   AttendeeStore
+	Speakers    SpeakerStore
+	Sessions    SessionStore
+	Admins      AdminStore
+	Reminders   ReminderStore
+	Enrollments EnrollmentStore
+	Workshops   WorkshopStore
+	Imports     ImportStore
+}
+
+// AttendeeStore persists workshop registrations.
+type AttendeeStore interface {
+	ListAttendees(ctx context.Context) ([]*models.Attendee, error)
+	// PageAttendees returns one page of attendees; see ListOptions.
+	PageAttendees(ctx context.Context, opts ListOptions) (*Page[models.Attendee], error)
+	// GetAttendee returns ErrNotFound if no attendee has the ID.
+	GetAttendee(ctx context.Context, id string) (*models.Attendee, error)
+	// CreateAttendee stores a new attendee and sets its ID and Status. The
+	// attendee is registered while seats remain and waitlisted, with its
+	// WaitlistPosition set, once the event is full. It returns a
+	// *DuplicateError if the normalized email belongs to an attendee who
+	// has not cancelled.
+	CreateAttendee(ctx context.Context, attendee *models.Attendee) error
+	// UpdateAttendee replaces the name, email and designation of the
+	// attendee with attendee.ID and fills the remaining fields of attendee
+	// from the stored registration. It returns ErrNotFound, ErrCancelled, or
+	// a *DuplicateError if the new email belongs to another attendee.
+	UpdateAttendee(ctx context.Context, attendee *models.Attendee) error
+	// CancelAttendee marks an attendee cancelled, releasing its email, and
+	// promotes the earliest waitlisted attendees into any freed seats in the
+	// same transaction. It returns the cancelled attendee and those promoted,
+	// or ErrNotFound. Cancelling twice is not an error.
+	CancelAttendee(ctx context.Context, id string) (*models.Attendee, []*models.Attendee, error)
+	// CheckInAttendee records that the attendee arrived at the event,
+	// checked in by operator. It returns ErrNotFound, ErrNoSeat, or
+	// ErrCheckedIn together with the attendee as first checked in.
+	CheckInAttendee(ctx context.Context, id, operator string) (*models.Attendee, error)
+	// Waitlist returns the waitlisted attendees in promotion order with their
+	// WaitlistPosition set.
+	Waitlist(ctx context.Context) ([]*models.Attendee, error)
+	AttendeeStats(ctx context.Context) (AttendeeStats, error)
+	// MergeAttendees atomically replaces keep and deletes duplicateIDs,
+	// leaving keep as the registration that owns its normalized email. Seats
+	// freed by the merge are filled from the waitlist and the promoted
+	// attendees returned.
+	MergeAttendees(ctx context.Context, keep *models.Attendee, duplicateIDs []string) ([]*models.Attendee, error)
+	EventSettings(ctx context.Context) (*models.EventSettings, error)
+	// UpdateEventSettings stores settings and, if capacity grew, promotes
+	// waitlisted attendees into the new seats. Lowering capacity below the
+	// number registered never removes anyone; new registrations are
+	// waitlisted until enough attendees cancel.
+	UpdateEventSettings(ctx context.Context, settings *models.EventSettings) ([]*models.Attendee, error)
+}
+
+// AttendeeStats counts attendees by status.
+type AttendeeStats struct {
+	Registered int `json:"registered" firestore:"registered"`
+	Waitlisted int `json:"waitlisted" firestore:"waitlisted"`
+	Cancelled  int `json:"cancelled" firestore:"cancelled"`
+}
+
+// Add adjusts the count for status by n. An empty status counts as
+// registered.
+func (s *AttendeeStats) Add(status string, n int) {
+	switch status {
+	case models.StatusWaitlisted:
+		s.Waitlisted += n
+	case models.StatusCancelled:
+		s.Cancelled += n
+	default:
+		s.Registered += n
+	}
+}
+
+// HasSeat reports whether another attendee can register under capacity,
+// where 0 means unlimited.
+func (s AttendeeStats) HasSeat(capacity int) bool {
+	return capacity == 0 || s.Registered < capacity
+}
+
+// Promotable returns how many waitlisted attendees fit in the open seats.
+func (s AttendeeStats) Promotable(capacity int) int {
+	if capacity == 0 || capacity-s.Registered > s.Waitlisted {
+		return s.Waitlisted
+	}
+	if capacity <= s.Registered {
+		return 0
+	}
+	return capacity - s.Registered
+}
+
+// CheckIn marks a checked in by operator at now, for backends implementing
+// CheckInAttendee. It returns ErrNoSeat or ErrCheckedIn without changing a.
+func CheckIn(a *models.Attendee, operator string, now time.Time) error {
+	if !a.HoldsSeat() {
+		return ErrNoSeat
+	}
+	if a.CheckedInAt != nil {
+		return ErrCheckedIn
+	}
+	a.CheckedInAt, a.CheckedInBy = &now, operator
+	return nil
+}
+
+// SpeakerStore persists speaker profiles.
+type SpeakerStore interface {
+	ListSpeakers(ctx context.Context) ([]*models.Speaker, error)
+	PageSpeakers(ctx context.Context, opts ListOptions) (*Page[models.Speaker], error)
+	// GetSpeakers reads the speakers with the given IDs in one batch, keyed
+	// by ID. IDs with no speaker are left out of the map.
+	GetSpeakers(ctx context.Context, ids []string) (map[string]*models.Speaker, error)
+	// CreateSpeaker stores a new speaker and sets its ID.
+	CreateSpeaker(ctx context.Context, speaker *models.Speaker) error
+	// UpdateSpeaker replaces the speaker with speaker.ID, returning
+	// ErrNotFound if it does not exist.
+	UpdateSpeaker(ctx context.Context, speaker *models.Speaker) error
+	// DeleteSpeaker deletes the speaker with id and returns the IDs of the
+	// sessions that named it. If there are any, it returns a
+	// *SpeakerInUseError and deletes nothing, unless cascade is set, in
+	// which case the speaker is removed from those sessions in the same
+	// transaction. Deleting a missing speaker is not an error.
+	DeleteSpeaker(ctx context.Context, id string, cascade bool) ([]string, error)
+}
+
+// SessionStore persists agenda sessions.
+type SessionStore interface {
+	ListSessions(ctx context.Context) ([]*models.Session, error)
+	PageSessions(ctx context.Context, opts ListOptions) (*Page[models.Session], error)
+	// CreateSession stores a new session and sets its ID. It returns a
+	// *MissingSpeakersError if session.SpeakerIDs names a speaker that does
+	// not exist, or a *RoomConflictError if another session is in the same
+	// room at the same time (see CheckRoom). Both are checked in the
+	// transaction that saves the session.
+	CreateSession(ctx context.Context, session *models.Session) error
+	// UpdateSession replaces the session with session.ID, returning
+	// ErrNotFound if it does not exist or the errors of CreateSession.
+	UpdateSession(ctx context.Context, session *models.Session) error
+	DeleteSession(ctx context.Context, id string) error
+}
+
+// EnrollmentStore persists which sessions attendees have chosen to attend.
+type EnrollmentStore interface {
+	// Enroll adds the attendee to the session. In the same transaction it
+	// checks that the attendee holds a seat at the event and that the
+	// session has room and does not overlap another session the attendee is
+	// enrolled in, returning ErrNotFound if either does not exist,
+	// ErrNoSeat, ErrSessionFull or a *ConflictError. Enrolling twice is not
+	// an error and returns the existing enrollment.
+	Enroll(ctx context.Context, sessionID, attendeeID string) (*models.Enrollment, error)
+	// Unenroll removes the attendee from the session. Removing an
+	// enrollment that does not exist is not an error.
+	Unenroll(ctx context.Context, sessionID, attendeeID string) error
+	// ListEnrollments returns the enrollments matching filter, oldest first.
+	ListEnrollments(ctx context.Context, filter EnrollmentFilter) ([]*models.Enrollment, error)
+	// DeleteEnrollments removes the enrollments matching filter, releasing
+	// their places. An empty filter deletes nothing.
+	DeleteEnrollments(ctx context.Context, filter EnrollmentFilter) error
+	// EnrollmentCounts returns the number of attendees enrolled in each
+	// session, keyed by session ID. Sessions nobody enrolled in are absent.
+	EnrollmentCounts(ctx context.Context) (map[string]int, error)
+}
+
+// EnrollmentFilter selects enrollments by session, attendee, or both. The
+// zero value matches every enrollment.
+type EnrollmentFilter struct {
+	SessionID  string
+	AttendeeID string
+}
+
+// Matches reports whether e is selected by the filter.
+func (f EnrollmentFilter) Matches(e *models.Enrollment) bool {
+	return (f.SessionID == "" || e.SessionID == f.SessionID) && (f.AttendeeID == "" || e.AttendeeID == f.AttendeeID)
+}
+
+// CheckEnrollment decides whether an attendee may join session, for backends
+// implementing Enroll. enrolled is the number already in the session and
+// schedule the other sessions the attendee is enrolled in. It returns
+// ErrSessionFull or a *ConflictError.
+func CheckEnrollment(session *models.Session, enrolled int, schedule []*models.Session) error {
+	if session.Capacity > 0 && enrolled >= session.Capacity {
+		return ErrSessionFull
+	}
+	for _, other := range schedule {
+		if other.ID != session.ID && session.Overlaps(other) {
+			return &ConflictError{SessionID: other.ID}
+		}
+	}
+	return nil
+}
+
+// CheckRoom decides whether session may be saved with its room, for
+// backends implementing CreateSession and UpdateSession. others are the
+// sessions already stored; session itself is skipped among them. It returns
+// a *RoomConflictError for the first other session in the same room at an
+// overlapping time.
+func CheckRoom(session *models.Session, others []*models.Session) error {
+	for _, other := range others {
+		if other.ID != session.ID && session.SharesRoom(other) && session.Overlaps(other) {
+			return &RoomConflictError{Room: session.Room, SessionID: other.ID}
+		}
+	}
+	return nil
+}
+
+// WorkshopStore persists workshops. The attendees, speakers, sessions,
+// enrollments, reminders and event settings of each workshop are kept apart
+// from every other workshop's; Scoped opens them.
+type WorkshopStore interface {
+	// ListWorkshops returns every workshop ordered by ID.
+	ListWorkshops(ctx context.Context) ([]*models.Workshop, error)
+	// GetWorkshop returns ErrNotFound if no workshop has the ID.
+	GetWorkshop(ctx context.Context, id string) (*models.Workshop, error)
+	// CreateWorkshop stores a new workshop under workshop.ID, returning a
+	// *DuplicateError if the ID is taken.
+	CreateWorkshop(ctx context.Context, workshop *models.Workshop) error
+	// CloneWorkshop creates a workshop like CreateWorkshop, holding the given
+	// speakers and sessions under the IDs they carry. Sessions may only name
+	// speakers among them. Nothing is left behind when it fails.
+	CloneWorkshop(ctx context.Context, workshop *models.Workshop, speakers []*models.Speaker, sessions []*models.Session) error
+	// UpdateWorkshop replaces the workshop with workshop.ID, returning
+	// ErrNotFound if it does not exist.
+	UpdateWorkshop(ctx context.Context, workshop *models.Workshop) error
+	// DeleteWorkshop deletes the workshop and everything stored in it.
+	// Deleting a missing workshop is not an error.
+	DeleteWorkshop(ctx context.Context, id string) error
+	// Scoped returns the stores holding the data of the workshop with id.
+	// The empty ID selects the data stored before workshops existed, which
+	// the default workshop keeps using. Admins and Workshops are shared by
+	// every workshop.
+	Scoped(id string) Stores
+}
+
+// ImportBatchSize is the most writes an ImportStore commits at once, the
+// limit Firestore puts on a single commit.
+const ImportBatchSize = 500
+
+// ImportStore stores many new documents for bulk imports. Each method stores
+// the documents in order under the IDs they carry, committing them in
+// batches of at most ImportBatchSize writes. A batch is all-or-nothing but a
+// failure leaves earlier batches in place; the methods return how many
+// documents were stored. Callers validate the documents first, so the checks
+// of the matching Create methods are only repeated where noted.
+type ImportStore interface {
+	// ImportAttendees registers attendees as CreateAttendee does, setting
+	// Status and WaitlistPosition from the seats left. It returns a
+	// *DuplicateError if a normalized email belongs to an attendee who has
+	// not cancelled.
+	ImportAttendees(ctx context.Context, attendees []*models.Attendee) (int, error)
+	ImportSpeakers(ctx context.Context, speakers []*models.Speaker) (int, error)
+	// ImportSessions stores sessions whose speakers and rooms were checked
+	// against the stored sessions and each other.
+	ImportSessions(ctx context.Context, sessions []*models.Session) (int, error)
+	// IDsInUse returns which of ids a new document of collection, "speakers"
+	// or "sessions", cannot be stored under: IDs are unique within a
+	// workshop, and across workshops in backends that key documents by ID
+	// alone.
+	IDsInUse(ctx context.Context, collection string, ids []string) (map[string]bool, error)
+}
+
+// AdminStore persists administrator accounts keyed by username.
+type AdminStore interface {
+	ListAdmins(ctx context.Context) ([]*models.Admin, error)
+	// GetAdmin returns ErrNotFound if no account has the username.
+	GetAdmin(ctx context.Context, username string) (*models.Admin, error)
+	// CreateAdmin returns a *DuplicateError if the username is taken.
+	CreateAdmin(ctx context.Context, admin *models.Admin) error
+	// UpdateAdmin replaces an existing account, returning ErrNotFound if it
+	// does not exist.
+	UpdateAdmin(ctx context.Context, admin *models.Admin) error
+}
+
+// ReminderStore persists the session reminder jobs.
+type ReminderStore interface {
+	// ListReminders returns every reminder ordered by ID.
+	ListReminders(ctx context.Context) ([]*models.Reminder, error)
+	// GetReminder returns ErrNotFound if no reminder has the ID.
+	GetReminder(ctx context.Context, id string) (*models.Reminder, error)
+	// UpdateReminder atomically reads the reminder with id, applies update
+	// and stores the result, so concurrent schedulers never act on the same
+	// state twice. A missing reminder is passed to update with only its ID
+	// set, and is created by the write. If update returns an error nothing
+	// is written and UpdateReminder returns that error. The backend may call
+	// update more than once when it retries a conflicting transaction.
+	UpdateReminder(ctx context.Context, id string, update func(*models.Reminder) error) (*models.Reminder, error)
+}
+
+const idAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// NewID returns a random 20 character document ID in the same shape as
+// Firestore's auto-generated IDs, for backends that generate their own.
+func NewID() string {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	for i := range b {
+		b[i] = idAlphabet[int(b[i])%len(idAlphabet)]
+	}
+	return string(b)
+}
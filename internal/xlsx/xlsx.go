@@ -0,0 +1,174 @@
+// Package xlsx streams Office Open XML spreadsheets (.xlsx) holding a single
+// worksheet of text, one row at a time, so large exports never sit in
+// memory.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the media type of a workbook.
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// maxSheetName is the longest worksheet name spreadsheet apps accept.
+const maxSheetName = 31
+
+// Writer writes a workbook with one worksheet. Rows go straight to the
+// underlying writer; Close finishes the file.
+type Writer struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+	err   error
+}
+
+// NewWriter starts a workbook whose worksheet is named sheet and whose
+// first row is header, shown in bold.
+func NewWriter(w io.Writer, sheet string, header []string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+	for _, part := range []struct{ name, body string }{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", workbook(sheet)},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+		{"xl/styles.xml", styles},
+	} {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, xml.Header+part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	x := &Writer{zip: zw, sheet: bufio.NewWriter(f)}
+	x.sheet.WriteString(xml.Header + `<worksheet xmlns="` + mainNS + `"><sheetData>`)
+	x.row(header, ` s="1"`)
+	return x, x.err
+}
+
+// WriteRow appends a row of text cells.
+func (x *Writer) WriteRow(cells []string) error {
+	x.row(cells, "")
+	return x.err
+}
+
+func (x *Writer) row(cells []string, style string) {
+	if x.err != nil {
+		return
+	}
+	x.rows++
+	r := strconv.Itoa(x.rows)
+	b := x.sheet
+	b.WriteString(`<row r="` + r + `">`)
+	for i, value := range cells {
+		if value == "" {
+			continue
+		}
+		b.WriteString(`<c r="` + column(i) + r + `" t="inlineStr"` + style + `><is><t xml:space="preserve">`)
+		xml.EscapeText(b, []byte(value))
+		b.WriteString(`</t></is></c>`)
+	}
+	_, x.err = b.WriteString(`</row>`)
+}
+
+// Flush writes buffered rows to the underlying writer.
+func (x *Writer) Flush() error {
+	if x.err != nil {
+		return x.err
+	}
+	if x.err = x.sheet.Flush(); x.err != nil {
+		return x.err
+	}
+	x.err = x.zip.Flush()
+	return x.err
+}
+
+// Close ends the worksheet and the file. It does not close the underlying
+// writer.
+func (x *Writer) Close() error {
+	if x.err != nil {
+		return x.err
+	}
+	x.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+// column returns the letters naming the 0-based column i: A-Z, AA, AB, ...
+func column(i int) string {
+	var name []byte
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = append([]byte{byte('A' + (i-1)%26)}, name...)
+	}
+	return string(name)
+}
+
+// sheetName drops the characters worksheet names may not contain and
+// shortens the name to maxSheetName characters.
+func sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if runes := []rune(name); len(runes) > maxSheetName {
+		name = string(runes[:maxSheetName])
+	}
+	if name == "" {
+		return "Sheet1"
+	}
+	return name
+}
+
+func workbook(sheet string) string {
+	var name strings.Builder
+	xml.EscapeText(&name, []byte(sheetName(sheet)))
+	return `<workbook xmlns="` + mainNS + `" xmlns:r="` + relNS + `"><sheets>` +
+		`<sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+}
+
+const (
+	mainNS = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	relNS  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+	contentTypes = `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`
+
+	rootRels = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="` + relNS + `/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	workbookRels = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="` + relNS + `/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="` + relNS + `/styles" Target="styles.xml"/>` +
+		`</Relationships>`
+
+	// styles defines cell format 1, bold text, for the header row.
+	styles = `<styleSheet xmlns="` + mainNS + `">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+		`</styleSheet>`
+)
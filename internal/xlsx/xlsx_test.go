@@ -0,0 +1,47 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "Attendees: [March]", []string{"Name", "Email"})
+	require.NoError(t, err)
+	require.NoError(t, w.WriteRow([]string{"Ada <Lovelace> & Co", "ada@example.com"}))
+	require.NoError(t, w.WriteRow([]string{"", "anon@example.com"}))
+	require.NoError(t, w.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		body, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		parts[f.Name] = string(body)
+	}
+
+	assert.Contains(t, parts, "[Content_Types].xml")
+	assert.Contains(t, parts["xl/workbook.xml"], `<sheet name="Attendees March" sheetId="1" r:id="rId1"/>`)
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<row r="1"><c r="A1" t="inlineStr" s="1"><is><t xml:space="preserve">Name</t></is></c>`)
+	assert.Contains(t, sheet, `<t xml:space="preserve">Ada &lt;Lovelace&gt; &amp; Co</t>`)
+	assert.Contains(t, sheet, `<row r="3"><c r="B3" t="inlineStr"><is><t xml:space="preserve">anon@example.com</t></is></c></row>`)
+	assert.True(t, strings.HasSuffix(sheet, `</sheetData></worksheet>`))
+}
+
+func TestColumn(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		assert.Equal(t, want, column(i), "column %d", i)
+	}
+}
@@ -2,6 +2,7 @@ import { BrowserRouter as Router, Routes, Route } from 'react-router-dom'
 import Home from './pages/Home'
 import AdminLogin from './pages/AdminLogin'
 import AdminDashboard from './pages/AdminDashboard'
+import ManageRegistration from './pages/ManageRegistration'
 import { AuthProvider } from './context/AuthContext'
 
 function App() {
@@ -12,6 +13,8 @@ function App() {
           <Route path="/" element={<Home />} />
           <Route path="/admin/login" element={<AdminLogin />} />
           <Route path="/admin/dashboard" element={<AdminDashboard />} />
+          <Route path="/registration/:token" element={<ManageRegistration />} />
+          <Route path="/workshops/:workshopId/registration/:token" element={<ManageRegistration />} />
         </Routes>
       </Router>
     </AuthProvider>
@@ -22,8 +22,11 @@ function RegistrationForm() {
     designation: '',
   })
   const [attendeeCount, setAttendeeCount] = useState(0)
+  const [remaining, setRemaining] = useState(null)
   const [loading, setLoading] = useState(false)
   const [showSuccess, setShowSuccess] = useState(false)
+  const [waitlistPosition, setWaitlistPosition] = useState(0)
+  const [manageLink, setManageLink] = useState('')
   const [error, setError] = useState('')
 
   useEffect(() => {
@@ -36,6 +39,7 @@ function RegistrationForm() {
     try {
       const response = await attendeesAPI.getCount()
       setAttendeeCount(response.data.count)
+      setRemaining(response.data.remaining ?? null)
     } catch (error) {
       console.error('Error fetching attendee count:', error)
     }
@@ -53,11 +57,19 @@ function RegistrationForm() {
     }
 
     try {
-      await attendeesAPI.register(formData)
+      const response = await attendeesAPI.register(formData)
+      setWaitlistPosition(
+        response.data?.status === 'waitlisted' ? response.data.waitlistPosition : 0
+      )
+      setManageLink(
+        response.data?.manageToken
+          ? `${window.location.origin}/registration/${response.data.manageToken}`
+          : ''
+      )
       setShowSuccess(true)
       setFormData({ name: '', email: '', designation: '' })
       fetchAttendeeCount()
-      setTimeout(() => setShowSuccess(false), 3000)
+      setTimeout(() => setShowSuccess(false), 8000)
     } catch (error) {
       setError(
         error.response?.data?.error || 'Registration failed. Please try again.'
@@ -102,7 +114,11 @@ function RegistrationForm() {
                 Attendees Registered
               </div>
               <div className="mt-4 text-sm text-gray-500">
-                Join the growing community!
+                {remaining === null
+                  ? 'Join the growing community!'
+                  : remaining > 0
+                    ? `${remaining} seats left`
+                    : 'The event is full - new registrations join the waitlist'}
               </div>
             </div>
           </motion.div>
@@ -187,7 +203,11 @@ function RegistrationForm() {
                   whileTap={{ scale: 0.98 }}
                   className="w-full py-4 bg-gradient-to-r from-blue-600 to-purple-600 text-white rounded-lg font-semibold text-lg shadow-lg hover:shadow-xl transition-all duration-300 disabled:opacity-50 disabled:cursor-not-allowed"
                 >
-                  {loading ? 'Registering...' : 'Register'}
+                  {loading
+                    ? 'Registering...'
+                    : remaining === 0
+                      ? 'Join Waitlist'
+                      : 'Register'}
                 </motion.button>
               </div>
             </form>
@@ -211,11 +231,23 @@ function RegistrationForm() {
             >
               <CheckCircle className="w-16 h-16 text-green-500 mx-auto mb-4" />
               <h3 className="text-2xl font-bold text-gray-900 mb-2">
-                Registration Successful!
+                {waitlistPosition
+                  ? "You're on the Waitlist"
+                  : 'Registration Successful!'}
               </h3>
               <p className="text-gray-600">
-                Thank you for registering. We'll see you at the workshop!
+                {waitlistPosition
+                  ? `The workshop is full. You are number ${waitlistPosition} on the waitlist and will get a seat automatically if one frees up.`
+                  : "Thank you for registering. We'll see you at the workshop!"}
               </p>
+              {manageLink && (
+                <p className="text-sm text-gray-500 mt-4">
+                  Keep this link to update or cancel your registration:{' '}
+                  <a href={manageLink} className="text-blue-600 break-all hover:underline">
+                    {manageLink}
+                  </a>
+                </p>
+              )}
             </motion.div>
           </motion.div>
         )}
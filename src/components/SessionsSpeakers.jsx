@@ -1,28 +1,22 @@
 import { useEffect, useState } from 'react'
 import { motion } from 'framer-motion'
-import { sessionsAPI, speakersAPI } from '../services/api'
+import { agendaAPI, sessionsAPI } from '../services/api'
 import { Calendar, Clock, User } from 'lucide-react'
 
 function SessionsSpeakers() {
   const [sessions, setSessions] = useState([])
-  const [speakers, setSpeakers] = useState([])
   const [loading, setLoading] = useState(true)
 
   useEffect(() => {
     const fetchData = async () => {
       try {
-        const [sessionsRes, speakersRes] = await Promise.all([
-          sessionsAPI.getAll(),
-          speakersAPI.getAll(),
-        ])
+        const sessionsRes = await sessionsAPI.getAllWithSpeakers()
         // Ensure we always have arrays, even if API returns null/undefined
         setSessions(Array.isArray(sessionsRes?.data) ? sessionsRes.data : [])
-        setSpeakers(Array.isArray(speakersRes?.data) ? speakersRes.data : [])
       } catch (error) {
         console.error('Error fetching data:', error)
         // Set empty arrays on error to prevent crashes
         setSessions([])
-        setSpeakers([])
       } finally {
         setLoading(false)
       }
@@ -30,10 +24,6 @@ function SessionsSpeakers() {
     fetchData()
   }, [])
 
-  const getSpeakerById = (speakerId) => {
-    return speakers.find((s) => s.id === speakerId)
-  }
-
   if (loading) {
     return (
       <section id="sessions" className="py-20 px-4 bg-white">
@@ -60,6 +50,15 @@ function SessionsSpeakers() {
           <p className="text-xl text-gray-600">
             Explore our exciting lineup of AI workshops and expert speakers
           </p>
+          {sessions.length > 0 && (
+            <a
+              href={agendaAPI.calendarURL()}
+              className="inline-flex items-center gap-2 mt-4 text-blue-600 font-semibold hover:underline"
+            >
+              <Calendar className="w-4 h-4" />
+              Add the agenda to your calendar
+            </a>
+          )}
         </motion.div>
 
         {sessions.length === 0 ? (
@@ -72,7 +71,7 @@ function SessionsSpeakers() {
           <div className="grid grid-cols-1 md:grid-cols-2 lg:grid-cols-3 gap-8">
             {sessions.map((session, index) => {
               if (!session || !session.id) return null
-              const speaker = session.speakerId ? getSpeakerById(session.speakerId) : null
+              const sessionSpeakers = Array.isArray(session.speakers) ? session.speakers : []
               return (
                 <motion.div
                   key={session.id}
@@ -95,23 +94,33 @@ function SessionsSpeakers() {
                   <div className="flex items-center gap-2 mb-4">
                     <Clock className="w-4 h-4 text-gray-500" />
                     <span className="text-sm text-gray-600">{session.time || 'TBA'}</span>
+                    {session.room && (
+                      <span className="text-sm text-gray-600">· {session.room}</span>
+                    )}
                   </div>
-                  {speaker && (
-                    <div className="mt-4 pt-4 border-t border-blue-200">
-                      <div className="flex items-center gap-3">
-                        <div className="w-12 h-12 bg-gradient-to-br from-blue-500 to-purple-500 rounded-full flex items-center justify-center text-white font-bold">
-                          {speaker.name?.charAt(0) || '?'}
-                        </div>
-                        <div>
-                          <div className="flex items-center gap-2">
-                            <User className="w-4 h-4 text-gray-500" />
-                            <span className="font-semibold text-gray-900">
-                              {speaker.name || 'Unknown Speaker'}
-                            </span>
+                  {session.track && (
+                    <span className="inline-block mb-4 px-2 py-1 text-xs font-semibold text-indigo-700 bg-indigo-100 rounded">
+                      {session.track}
+                    </span>
+                  )}
+                  {sessionSpeakers.length > 0 && (
+                    <div className="mt-4 pt-4 border-t border-blue-200 space-y-3">
+                      {sessionSpeakers.map((speaker) => (
+                        <div key={speaker.id} className="flex items-center gap-3">
+                          <div className="w-12 h-12 bg-gradient-to-br from-blue-500 to-purple-500 rounded-full flex items-center justify-center text-white font-bold">
+                            {speaker.name?.charAt(0) || '?'}
+                          </div>
+                          <div>
+                            <div className="flex items-center gap-2">
+                              <User className="w-4 h-4 text-gray-500" />
+                              <span className="font-semibold text-gray-900">
+                                {speaker.name || 'Unknown Speaker'}
+                              </span>
+                            </div>
+                            <p className="text-sm text-gray-600">{speaker.bio || 'No bio available.'}</p>
                           </div>
-                          <p className="text-sm text-gray-600">{speaker.bio || 'No bio available.'}</p>
                         </div>
-                      </div>
+                      ))}
                     </div>
                   )}
                 </motion.div>
@@ -33,6 +33,16 @@ describe('RegistrationForm', () => {
     })
   })
 
+  it('shows remaining seats and the waitlist once full', async () => {
+    api.attendeesAPI.getCount.mockResolvedValue({ data: { count: 50, remaining: 0 } })
+    render(<RegistrationForm />)
+
+    await waitFor(() => {
+      expect(screen.getByText(/new registrations join the waitlist/i)).toBeInTheDocument()
+    })
+    expect(screen.getByRole('button', { name: /Join Waitlist/i })).toBeInTheDocument()
+  })
+
   it('validates required fields on submit', async () => {
     render(<RegistrationForm />)
     const submitButton = screen.getByRole('button', { name: /Register/i })
@@ -1,11 +1,12 @@
 import { useState, useEffect } from 'react'
 import { attendeesAPI } from '../../services/api'
-import { Search, Mail, Briefcase } from 'lucide-react'
+import { Search, Mail, Briefcase, Download } from 'lucide-react'
 
 function AttendeeList() {
   const [attendees, setAttendees] = useState([])
   const [loading, setLoading] = useState(true)
   const [searchTerm, setSearchTerm] = useState('')
+  const [exporting, setExporting] = useState('')
 
   useEffect(() => {
     fetchAttendees()
@@ -22,6 +23,25 @@ function AttendeeList() {
     }
   }
 
+  // The export needs the admin token, so it is fetched as a Blob rather than
+  // opened as a link
+  const exportAttendees = async (format) => {
+    setExporting(format)
+    try {
+      const response = await attendeesAPI.export(format)
+      const url = URL.createObjectURL(response.data)
+      const link = document.createElement('a')
+      link.href = url
+      link.download = `attendees.${format}`
+      link.click()
+      URL.revokeObjectURL(url)
+    } catch (error) {
+      console.error('Error exporting attendees:', error)
+    } finally {
+      setExporting('')
+    }
+  }
+
   const filteredAttendees = attendees.filter(
     (attendee) =>
       attendee.name.toLowerCase().includes(searchTerm.toLowerCase()) ||
@@ -40,7 +60,22 @@ function AttendeeList() {
   return (
     <div className="bg-white rounded-xl shadow-lg p-6">
       <div className="mb-6">
-        <h2 className="text-2xl font-bold text-gray-900 mb-4">Attendee List</h2>
+        <div className="flex items-center justify-between mb-4">
+          <h2 className="text-2xl font-bold text-gray-900">Attendee List</h2>
+          <div className="flex gap-2">
+            {[['csv', 'CSV'], ['xlsx', 'Excel']].map(([format, label]) => (
+              <button
+                key={format}
+                onClick={() => exportAttendees(format)}
+                disabled={exporting !== ''}
+                className="flex items-center px-3 py-2 text-sm border border-gray-300 rounded-lg hover:bg-gray-50 disabled:opacity-50"
+              >
+                <Download className="w-4 h-4 mr-2" />
+                {exporting === format ? 'Exporting...' : `Export ${label}`}
+              </button>
+            ))}
+          </div>
+        </div>
         <div className="relative">
           <Search className="absolute left-3 top-1/2 transform -translate-y-1/2 w-5 h-5 text-gray-400" />
           <input
@@ -13,7 +13,11 @@ function SessionManagement() {
     description: '',
     date: '',
     time: '',
-    speakerId: '',
+    speakerIds: [],
+    duration: 60,
+    capacity: 0,
+    room: '',
+    track: '',
   })
 
   useEffect(() => {
@@ -43,7 +47,11 @@ function SessionManagement() {
         description: session.description,
         date: session.date,
         time: session.time,
-        speakerId: session.speakerId || '',
+        speakerIds: session.speakerIds || (session.speakerId ? [session.speakerId] : []),
+        duration: session.duration || 60,
+        capacity: session.capacity || 0,
+        room: session.room || '',
+        track: session.track || '',
       })
     } else {
       setEditingSession(null)
@@ -52,7 +60,11 @@ function SessionManagement() {
         description: '',
         date: '',
         time: '',
-        speakerId: '',
+        speakerIds: [],
+        duration: 60,
+        capacity: 0,
+        room: '',
+        track: '',
       })
     }
     setIsModalOpen(true)
@@ -66,7 +78,11 @@ function SessionManagement() {
       description: '',
       date: '',
       time: '',
-      speakerId: '',
+      speakerIds: [],
+      duration: 60,
+      capacity: 0,
+      room: '',
+      track: '',
     })
   }
 
@@ -82,7 +98,7 @@ function SessionManagement() {
       handleCloseModal()
     } catch (error) {
       console.error('Error saving session:', error)
-      alert('Error saving session. Please try again.')
+      alert(error.response?.data?.error || 'Error saving session. Please try again.')
     }
   }
 
@@ -98,9 +114,20 @@ function SessionManagement() {
     }
   }
 
-  const getSpeakerName = (speakerId) => {
-    const speaker = speakers.find((s) => s.id === speakerId)
-    return speaker ? speaker.name : 'No speaker assigned'
+  const getSpeakerNames = (session) => {
+    const ids = session.speakerIds || (session.speakerId ? [session.speakerId] : [])
+    const names = ids
+      .map((id) => speakers.find((s) => s.id === id))
+      .filter(Boolean)
+      .map((s) => s.name)
+    return names.length > 0 ? names.join(', ') : 'No speaker assigned'
+  }
+
+  const toggleSpeaker = (id) => {
+    const ids = formData.speakerIds.includes(id)
+      ? formData.speakerIds.filter((s) => s !== id)
+      : [...formData.speakerIds, id]
+    setFormData({ ...formData, speakerIds: ids })
   }
 
   if (loading) {
@@ -137,7 +164,13 @@ function SessionManagement() {
                 <div className="text-xs text-gray-500 space-y-1">
                   <div>Date: {session.date}</div>
                   <div>Time: {session.time}</div>
-                  <div>Speaker: {getSpeakerName(session.speakerId)}</div>
+                  {session.room && <div>Room: {session.room}</div>}
+                  {session.track && <div>Track: {session.track}</div>}
+                  <div>Speakers: {getSpeakerNames(session)}</div>
+                  <div>
+                    Enrolled: {session.enrolled || 0}
+                    {session.capacity > 0 && ` / ${session.capacity}`}
+                  </div>
                 </div>
               </div>
               <div className="flex gap-2">
@@ -232,24 +265,84 @@ function SessionManagement() {
                   />
                 </div>
               </div>
+              <div className="grid grid-cols-2 gap-4">
+                <div>
+                  <label className="block text-sm font-semibold text-gray-700 mb-2">
+                    Length (minutes)
+                  </label>
+                  <input
+                    type="number"
+                    min="1"
+                    max="1440"
+                    value={formData.duration}
+                    onChange={(e) =>
+                      setFormData({ ...formData, duration: parseInt(e.target.value, 10) || 0 })
+                    }
+                    className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent outline-none"
+                  />
+                </div>
+                <div>
+                  <label className="block text-sm font-semibold text-gray-700 mb-2">
+                    Seats (0 = unlimited)
+                  </label>
+                  <input
+                    type="number"
+                    min="0"
+                    value={formData.capacity}
+                    onChange={(e) =>
+                      setFormData({ ...formData, capacity: parseInt(e.target.value, 10) || 0 })
+                    }
+                    className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent outline-none"
+                  />
+                </div>
+              </div>
+              <div className="grid grid-cols-2 gap-4">
+                <div>
+                  <label className="block text-sm font-semibold text-gray-700 mb-2">
+                    Room
+                  </label>
+                  <input
+                    type="text"
+                    value={formData.room}
+                    onChange={(e) =>
+                      setFormData({ ...formData, room: e.target.value })
+                    }
+                    className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent outline-none"
+                  />
+                </div>
+                <div>
+                  <label className="block text-sm font-semibold text-gray-700 mb-2">
+                    Track
+                  </label>
+                  <input
+                    type="text"
+                    value={formData.track}
+                    onChange={(e) =>
+                      setFormData({ ...formData, track: e.target.value })
+                    }
+                    className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent outline-none"
+                  />
+                </div>
+              </div>
               <div>
                 <label className="block text-sm font-semibold text-gray-700 mb-2">
-                  Speaker
+                  Speakers
                 </label>
-                <select
-                  value={formData.speakerId}
-                  onChange={(e) =>
-                    setFormData({ ...formData, speakerId: e.target.value })
-                  }
-                  className="w-full px-4 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent outline-none bg-white"
-                >
-                  <option value="">Select a speaker</option>
+                <div className="max-h-40 overflow-y-auto border border-gray-300 rounded-lg px-4 py-2 space-y-1">
+                  {speakers.length === 0 && (
+                    <p className="text-sm text-gray-500">No speakers yet</p>
+                  )}
                   {speakers.map((speaker) => (
-                    <option key={speaker.id} value={speaker.id}>
+                    <label key={speaker.id} className="flex items-center gap-2 text-sm text-gray-700">
+                      <input
+                        type="checkbox"
+                        checked={formData.speakerIds.includes(speaker.id)}
+                        onChange={() => toggleSpeaker(speaker.id)}
+                      />
                       {speaker.name}
-                    </option>
+                    </label>
                   ))}
-                </select>
+                </div>
               </div>
               <div className="flex gap-3">
                 <button
@@ -63,8 +63,23 @@ function SpeakerManagement() {
         await speakersAPI.delete(id)
         fetchSpeakers()
       } catch (error) {
-        console.error('Error deleting speaker:', error)
-        alert('Error deleting speaker. Please try again.')
+        const sessions = error.response?.status === 409 && error.response.data?.sessions
+        if (!sessions) {
+          console.error('Error deleting speaker:', error)
+          alert('Error deleting speaker. Please try again.')
+          return
+        }
+        const ok = window.confirm(
+          `This speaker is assigned to ${sessions.length} session(s). Remove them from those sessions and delete anyway?`
+        )
+        if (!ok) return
+        try {
+          await speakersAPI.delete(id, 'cascade')
+          fetchSpeakers()
+        } catch (err) {
+          console.error('Error deleting speaker:', err)
+          alert('Error deleting speaker. Please try again.')
+        }
       }
     }
   }
@@ -1,7 +1,11 @@
 import { createContext, useContext, useState, useEffect } from 'react'
+import { adminAPI } from '../services/api'
 
 const AuthContext = createContext()
 
+// Refresh the session token well before the server-side expiry
+const REFRESH_INTERVAL_MS = 30 * 60 * 1000
+
 export const useAuth = () => {
   const context = useContext(AuthContext)
   if (!context) {
@@ -12,28 +16,66 @@ export const useAuth = () => {
 
 export const AuthProvider = ({ children }) => {
   const [isAuthenticated, setIsAuthenticated] = useState(false)
+  const [account, setAccount] = useState(null)
 
   useEffect(() => {
-    const authStatus = localStorage.getItem('adminAuthenticated')
-    if (authStatus === 'true') {
+    if (localStorage.getItem('adminToken')) {
       setIsAuthenticated(true)
     }
   }, [])
 
-  const login = () => {
+  // Load the caller's role and permissions so the dashboard can hide
+  // controls the server would reject
+  useEffect(() => {
+    if (!isAuthenticated) {
+      setAccount(null)
+      return
+    }
+    adminAPI
+      .me()
+      .then((response) => setAccount(response.data))
+      .catch(() => clearSession())
+  }, [isAuthenticated])
+
+  useEffect(() => {
+    if (!isAuthenticated) return undefined
+
+    const timer = setInterval(async () => {
+      try {
+        const response = await adminAPI.refresh()
+        localStorage.setItem('adminToken', response.data.token)
+      } catch (error) {
+        clearSession()
+      }
+    }, REFRESH_INTERVAL_MS)
+    return () => clearInterval(timer)
+  }, [isAuthenticated])
+
+  const clearSession = () => {
+    setIsAuthenticated(false)
+    localStorage.removeItem('adminToken')
+  }
+
+  const login = (token) => {
+    localStorage.setItem('adminToken', token)
     setIsAuthenticated(true)
-    localStorage.setItem('adminAuthenticated', 'true')
   }
 
-  const logout = () => {
-    setIsAuthenticated(false)
-    localStorage.removeItem('adminAuthenticated')
+  const can = (permission) =>
+    Boolean(account?.permissions?.includes(permission))
+
+  const logout = async () => {
+    try {
+      await adminAPI.logout()
+    } catch (error) {
+      // The token may already be expired; clear it locally either way
+    }
+    clearSession()
   }
 
   return (
-    <AuthContext.Provider value={{ isAuthenticated, login, logout }}>
+    <AuthContext.Provider value={{ isAuthenticated, account, can, login, logout }}>
       {children}
     </AuthContext.Provider>
   )
 }
-
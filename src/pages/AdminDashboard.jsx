@@ -11,13 +11,12 @@ import { LogOut, Users, Mic, Calendar, BarChart3 } from 'lucide-react'
 
 function AdminDashboard() {
   const [activeTab, setActiveTab] = useState('attendees')
-  const { logout } = useAuth()
+  const { account, can, logout } = useAuth()
   const navigate = useNavigate()
 
   useEffect(() => {
     // Check authentication on mount
-    const authStatus = localStorage.getItem('adminAuthenticated')
-    if (authStatus !== 'true') {
+    if (!localStorage.getItem('adminToken')) {
       navigate('/admin/login')
     }
   }, [navigate])
@@ -27,21 +26,37 @@ function AdminDashboard() {
     navigate('/admin/login')
   }
 
+  // Only show the tabs the caller's role can use
   const tabs = [
-    { id: 'attendees', label: 'Attendees', icon: Users },
-    { id: 'speakers', label: 'Speakers', icon: Mic },
-    { id: 'sessions', label: 'Sessions', icon: Calendar },
-    { id: 'analytics', label: 'Analytics', icon: BarChart3 },
-  ]
+    { id: 'attendees', label: 'Attendees', icon: Users, permission: 'attendees:read' },
+    { id: 'speakers', label: 'Speakers', icon: Mic, permission: 'speakers:write' },
+    { id: 'sessions', label: 'Sessions', icon: Calendar, permission: 'sessions:write' },
+    { id: 'analytics', label: 'Analytics', icon: BarChart3, permission: 'attendees:read' },
+  ].filter((tab) => can(tab.permission))
+
+  const visible = tabs.some((tab) => tab.id === activeTab)
+
+  useEffect(() => {
+    if (!visible && tabs.length > 0) {
+      setActiveTab(tabs[0].id)
+    }
+  }, [visible, tabs, activeTab])
 
   return (
     <div className="min-h-screen bg-gray-50">
       {/* Header */}
       <header className="bg-white shadow-sm border-b">
         <div className="max-w-7xl mx-auto px-4 py-4 flex justify-between items-center">
-          <h1 className="text-2xl font-bold text-gray-900">
-            Admin Dashboard
-          </h1>
+          <div>
+            <h1 className="text-2xl font-bold text-gray-900">
+              Admin Dashboard
+            </h1>
+            {account && (
+              <p className="text-sm text-gray-500">
+                Signed in as {account.username} ({account.role})
+              </p>
+            )}
+          </div>
           <button
             onClick={handleLogout}
             className="flex items-center gap-2 px-4 py-2 text-red-600 hover:bg-red-50 rounded-lg transition-colors"
@@ -85,10 +100,10 @@ function AdminDashboard() {
           animate={{ opacity: 1, y: 0 }}
           transition={{ duration: 0.3 }}
         >
-          {activeTab === 'attendees' && <AttendeeList />}
-          {activeTab === 'speakers' && <SpeakerManagement />}
-          {activeTab === 'sessions' && <SessionManagement />}
-          {activeTab === 'analytics' && <Analytics />}
+          {visible && activeTab === 'attendees' && <AttendeeList />}
+          {visible && activeTab === 'speakers' && <SpeakerManagement />}
+          {visible && activeTab === 'sessions' && <SessionManagement />}
+          {visible && activeTab === 'analytics' && <Analytics />}
         </motion.div>
       </main>
     </div>
@@ -6,6 +6,7 @@ import { adminAPI } from '../services/api'
 import { Lock, XCircle } from 'lucide-react'
 
 function AdminLogin() {
+  const [username, setUsername] = useState('admin')
   const [password, setPassword] = useState('')
   const [error, setError] = useState('')
   const [loading, setLoading] = useState(false)
@@ -18,12 +19,12 @@ function AdminLogin() {
     setLoading(true)
 
     try {
-      await adminAPI.login(password)
-      login() // Update auth context state
+      const response = await adminAPI.login(username, password)
+      login(response.data.token) // Store the session token in auth context
       navigate('/admin/dashboard')
     } catch (error) {
       setError(
-        error.response?.data?.error || 'Invalid password. Please try again.'
+        error.response?.data?.error || 'Invalid username or password. Please try again.'
       )
     } finally {
       setLoading(false)
@@ -45,10 +46,25 @@ function AdminLogin() {
           <h1 className="text-3xl font-bold text-gray-900 mb-2">
             Admin Login
           </h1>
-          <p className="text-gray-600">Enter password to access admin dashboard</p>
+          <p className="text-gray-600">Sign in to access the admin dashboard</p>
         </div>
 
         <form onSubmit={handleSubmit} className="space-y-6">
+          <div>
+            <label className="block text-sm font-semibold text-gray-700 mb-2">
+              Username
+            </label>
+            <input
+              type="text"
+              value={username}
+              onChange={(e) => setUsername(e.target.value)}
+              className="w-full px-4 py-3 border border-gray-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent outline-none transition-all"
+              placeholder="Enter admin username"
+              autoComplete="username"
+              required
+            />
+          </div>
+
           <div>
             <label className="block text-sm font-semibold text-gray-700 mb-2">
               Password
@@ -0,0 +1,259 @@
+import { useState, useEffect } from 'react'
+import { useParams, Link } from 'react-router-dom'
+import { motion } from 'framer-motion'
+import { registrationsFor, sessionsAPI } from '../services/api'
+import { CheckCircle, XCircle } from 'lucide-react'
+
+const STATUS_LABELS = {
+  registered: 'Registered',
+  waitlisted: 'On the waitlist',
+  cancelled: 'Cancelled',
+}
+
+function ManageRegistration() {
+  const { token, workshopId } = useParams()
+  const registrationsAPI = registrationsFor(workshopId)
+  const [registration, setRegistration] = useState(null)
+  const [formData, setFormData] = useState({ name: '', email: '', designation: '' })
+  const [loading, setLoading] = useState(true)
+  const [saving, setSaving] = useState(false)
+  const [message, setMessage] = useState('')
+  const [error, setError] = useState('')
+  const [sessions, setSessions] = useState([])
+  const [enrolled, setEnrolled] = useState(new Set())
+
+  useEffect(() => {
+    fetchRegistration()
+    fetchSessions()
+  }, [token, workshopId])
+
+  const fetchSessions = async () => {
+    try {
+      const [all, mine] = await Promise.all([
+        sessionsAPI.getAllIn(workshopId, { orderBy: 'date' }),
+        registrationsAPI.getSessions(token),
+      ])
+      setSessions(all.data || [])
+      setEnrolled(new Set(mine.data.map((session) => session.id)))
+    } catch (error) {
+      console.error('Error fetching sessions:', error)
+    }
+  }
+
+  const toggleSession = async (session) => {
+    setError('')
+    setMessage('')
+    try {
+      if (enrolled.has(session.id)) {
+        await registrationsAPI.unenroll(token, session.id)
+      } else {
+        await registrationsAPI.enroll(token, session.id)
+      }
+      fetchSessions()
+    } catch (error) {
+      const overlap = sessions.find((s) => s.id === error.response?.data?.sessionId)
+      setError(
+        overlap
+          ? `${session.title} overlaps ${overlap.title}, which you are already attending.`
+          : error.response?.data?.error || 'Could not update your sessions. Please try again.'
+      )
+    }
+  }
+
+  const show = (data) => {
+    setRegistration(data)
+    setFormData({ name: data.name, email: data.email, designation: data.designation })
+  }
+
+  const fetchRegistration = async () => {
+    try {
+      const response = await registrationsAPI.get(token)
+      show(response.data)
+    } catch (error) {
+      setError(error.response?.data?.error || 'Registration not found')
+    } finally {
+      setLoading(false)
+    }
+  }
+
+  const handleSave = async (e) => {
+    e.preventDefault()
+    setError('')
+    setMessage('')
+    setSaving(true)
+    try {
+      const response = await registrationsAPI.update(token, formData)
+      show(response.data)
+      setMessage('Your registration has been updated.')
+    } catch (error) {
+      setError(error.response?.data?.error || 'Update failed. Please try again.')
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  const handleCancel = async () => {
+    if (!confirm('Cancel your registration? Your seat will be given to the next person on the waitlist.')) return
+    setError('')
+    setMessage('')
+    setSaving(true)
+    try {
+      const response = await registrationsAPI.cancel(token)
+      show(response.data)
+      setMessage('Your registration has been cancelled.')
+    } catch (error) {
+      setError(error.response?.data?.error || 'Cancellation failed. Please try again.')
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  if (loading) {
+    return (
+      <div className="min-h-screen flex items-center justify-center">
+        <div className="animate-spin rounded-full h-12 w-12 border-b-2 border-blue-600"></div>
+      </div>
+    )
+  }
+
+  const cancelled = registration?.status === 'cancelled'
+
+  return (
+    <div className="min-h-screen bg-gradient-to-br from-indigo-50 to-purple-50 flex items-center justify-center px-4 py-12">
+      <motion.div
+        initial={{ opacity: 0, y: 20 }}
+        animate={{ opacity: 1, y: 0 }}
+        transition={{ duration: 0.6 }}
+        className="bg-white rounded-xl shadow-lg p-8 max-w-lg w-full"
+      >
+        <h1 className="text-3xl font-bold text-gray-900 mb-2">Your Registration</h1>
+
+        {!registration ? (
+          <div className="flex items-center gap-2 text-red-600 bg-red-50 p-3 rounded-lg mt-6">
+            <XCircle className="w-5 h-5" />
+            <span>{error}</span>
+          </div>
+        ) : (
+          <>
+            <p className="text-gray-600 mb-6">
+              Status:{' '}
+              <span className="font-semibold">{STATUS_LABELS[registration.status] || registration.status}</span>
+              {registration.waitlistPosition > 0 && ` (number ${registration.waitlistPosition})`}
+            </p>
+
+            {registration.status === 'registered' && (
+              <div className="text-center mb-6">
+                <img
+                  src={registrationsAPI.ticketURL(token)}
+                  alt="Your ticket"
+                  className="mx-auto w-48 h-48"
+                />
+                <p className="text-sm text-gray-500 mt-2">
+                  {registration.checkedInAt ? 'Checked in. Enjoy the workshop!' : 'Show this QR code at the door to check in.'}
+                </p>
+              </div>
+            )}
+
+            <form onSubmit={handleSave} className="space-y-4">
+              {['name', 'email', 'designation'].map((field) => (
+                <div key={field}>
+                  <label className="block text-sm font-semibold text-gray-700 mb-2 capitalize">
+                    {field}
+                  </label>
+                  <input
+                    type={field === 'email' ? 'email' : 'text'}
+                    value={formData[field]}
+                    onChange={(e) => setFormData({ ...formData, [field]: e.target.value })}
+                    disabled={cancelled}
+                    className="w-full px-4 py-3 border border-gray-300 rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-transparent outline-none transition-all disabled:bg-gray-100"
+                    required
+                  />
+                </div>
+              ))}
+
+              {message && (
+                <div className="flex items-center gap-2 text-green-700 bg-green-50 p-3 rounded-lg">
+                  <CheckCircle className="w-5 h-5" />
+                  <span>{message}</span>
+                </div>
+              )}
+              {error && (
+                <div className="flex items-center gap-2 text-red-600 bg-red-50 p-3 rounded-lg">
+                  <XCircle className="w-5 h-5" />
+                  <span>{error}</span>
+                </div>
+              )}
+
+              {!cancelled && (
+                <div className="flex gap-3">
+                  <button
+                    type="submit"
+                    disabled={saving}
+                    className="flex-1 py-3 bg-gradient-to-r from-blue-600 to-purple-600 text-white rounded-lg font-semibold disabled:opacity-50"
+                  >
+                    Save Changes
+                  </button>
+                  <button
+                    type="button"
+                    onClick={handleCancel}
+                    disabled={saving}
+                    className="flex-1 py-3 bg-red-600 text-white rounded-lg font-semibold hover:bg-red-700 disabled:opacity-50"
+                  >
+                    Cancel Registration
+                  </button>
+                </div>
+              )}
+            </form>
+
+            {registration.status === 'registered' && sessions.length > 0 && (
+              <div className="mt-8">
+                <h2 className="text-xl font-bold text-gray-900 mb-3">Your Sessions</h2>
+                <ul className="space-y-2">
+                  {sessions.map((session) => {
+                    const joined = enrolled.has(session.id)
+                    const full = session.capacity > 0 && session.enrolled >= session.capacity
+                    return (
+                      <li key={session.id} className="flex items-center justify-between gap-3 border border-gray-200 rounded-lg p-3">
+                        <div>
+                          <div className="font-semibold text-gray-900">{session.title}</div>
+                          <div className="text-sm text-gray-500">
+                            {session.date} {session.time}
+                            {session.capacity > 0 && ` · ${Math.max(session.capacity - session.enrolled, 0)} seats left`}
+                          </div>
+                        </div>
+                        <button
+                          type="button"
+                          onClick={() => toggleSession(session)}
+                          disabled={!joined && full}
+                          className={`px-3 py-2 rounded-lg text-sm font-semibold disabled:opacity-50 ${
+                            joined ? 'bg-gray-200 text-gray-700' : 'bg-blue-600 text-white'
+                          }`}
+                        >
+                          {joined ? 'Leave' : full ? 'Full' : 'Attend'}
+                        </button>
+                      </li>
+                    )
+                  })}
+                </ul>
+                {enrolled.size > 0 && (
+                  <a
+                    href={registrationsAPI.scheduleURL(token)}
+                    className="inline-block mt-3 text-sm text-blue-600 hover:underline"
+                  >
+                    Add your sessions to your calendar
+                  </a>
+                )}
+              </div>
+            )}
+          </>
+        )}
+
+        <Link to="/" className="block text-center text-blue-600 hover:underline mt-6">
+          Back to the workshop
+        </Link>
+      </motion.div>
+    </div>
+  )
+}
+
+export default ManageRegistration
@@ -11,27 +11,112 @@ const api = axios.create({
   },
 })
 
+// Attach the admin session token issued by /admin/login to every request
+api.interceptors.request.use((config) => {
+  const token = localStorage.getItem('adminToken')
+  if (token) {
+    config.headers.Authorization = `Bearer ${token}`
+  }
+  return config
+})
+
+// List endpoints return { items, nextPageToken }. listAll follows the page
+// tokens and resolves like an axios response whose data is every item.
+const listAll = async (path, params = {}) => {
+  const items = []
+  let pageToken
+  do {
+    const response = await api.get(path, {
+      params: { ...params, limit: 500, pageToken },
+    })
+    items.push(...response.data.items)
+    pageToken = response.data.nextPageToken
+  } while (pageToken)
+  return { data: items }
+}
+
+// Routes of one workshop are served under /workshops/{workshopId}; without
+// one they serve the default workshop.
+const workshopPath = (workshopId) => (workshopId ? `/workshops/${workshopId}` : '')
+
+export const workshopsAPI = {
+  getAll: () => api.get('/workshops'),
+  get: (id) => api.get(`/workshops/${id}`),
+  create: (data) => api.post('/workshops', data),
+  update: (id, data) => api.put(`/workshops/${id}`, data),
+  delete: (id) => api.delete(`/workshops/${id}`),
+  // Copy a workshop's speakers and sessions into a new one, moving the
+  // sessions by offsetDays
+  clone: (id, data) => api.post(`/workshops/${id}/clone`, data),
+}
+
 export const attendeesAPI = {
-  getAll: () => api.get('/attendees'),
+  getAll: (params) => listAll('/attendees', params),
+  getPage: (params) => api.get('/attendees', { params }),
   register: (data) => api.post('/attendees', data),
   getCount: () => api.get('/attendees/count'),
+  // Spreadsheet of attendees as a Blob; format is 'csv' or 'xlsx'
+  export: (format, params = {}) =>
+    api.get('/attendees/export', { params: { ...params, format }, responseType: 'blob' }),
 }
 
+// Self-service endpoints authorized by the manageToken returned on
+// registration. Tokens from another workshop than the default one only work
+// under its path, so registrationsFor takes the workshop from the manage link.
+export const registrationsFor = (workshopId) => {
+  const base = `${workshopPath(workshopId)}/registrations`
+  return {
+    get: (token) => api.get(`${base}/${token}`),
+    update: (token, data) => api.put(`${base}/${token}`, data),
+    cancel: (token) => api.delete(`${base}/${token}`),
+    // URL of the QR ticket PNG, for use as an <img> src
+    ticketURL: (token) => `${API_URL}${base}/${token}/ticket`,
+    getSessions: (token) => api.get(`${base}/${token}/sessions`),
+    // URL of the registrant's sessions as an iCalendar file
+    scheduleURL: (token) => `${API_URL}${base}/${token}/schedule.ics`,
+    enroll: (token, sessionId) => api.put(`${base}/${token}/sessions/${sessionId}`),
+    unenroll: (token, sessionId) => api.delete(`${base}/${token}/sessions/${sessionId}`),
+  }
+}
+
+export const registrationsAPI = registrationsFor()
+
 export const speakersAPI = {
-  getAll: () => api.get('/speakers'),
+  getAll: (params) => listAll('/speakers', params),
   create: (data) => api.post('/speakers', data),
   update: (id, data) => api.put(`/speakers/${id}`, data),
-  delete: (id) => api.delete(`/speakers/${id}`),
+  delete: (id, mode) => api.delete(`/speakers/${id}`, { params: mode ? { mode } : {} }),
+}
+
+export const agendaAPI = {
+  // URL of the whole agenda as an iCalendar file
+  calendarURL: () => `${API_URL}/agenda.ics`,
 }
 
 export const sessionsAPI = {
-  getAll: () => api.get('/sessions'),
+  getAll: (params) => listAll('/sessions', params),
+  getAllIn: (workshopId, params) => listAll(`${workshopPath(workshopId)}/sessions`, params),
+  // Sessions with a speakers array of full speaker profiles
+  getAllWithSpeakers: () => listAll('/sessions', { expand: 'speakers' }),
   create: (data) => api.post('/sessions', data),
   update: (id, data) => api.put(`/sessions/${id}`, data),
   delete: (id) => api.delete(`/sessions/${id}`),
 }
 
 export const adminAPI = {
-  login: (password) => api.post('/admin/login', { password }),
+  login: (username, password) => api.post('/admin/login', { username, password }),
+  logout: () => api.post('/admin/logout'),
+  refresh: () => api.post('/admin/refresh'),
+  me: () => api.get('/admin/me'),
 }
 
+// Bulk imports of a File; kind is 'attendees', 'speakers' or 'sessions'.
+// The format follows the file's extension. Rejected files answer 422 with
+// the per-row report in error.response.data.
+export const importAPI = {
+  upload: (kind, file, { dryRun = false } = {}) =>
+    api.post(`/admin/import/${kind}`, file, {
+      params: { format: file.name.toLowerCase().endsWith('.json') ? 'json' : 'csv', dryRun },
+      headers: { 'Content-Type': file.type || 'application/octet-stream' },
+    }),
+}